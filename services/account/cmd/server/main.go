@@ -15,6 +15,7 @@ import (
 	"github.com/ashish19912009/zrms/services/account/internal/repository"
 	"github.com/ashish19912009/zrms/services/account/internal/service"
 	"github.com/ashish19912009/zrms/services/account/pb"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
@@ -84,7 +85,7 @@ func main() {
 	defer authzClient.Close()
 
 	// connecting with authN (authentication) micro service
-	authnClient, err := client.NewAuthNServiceClient(cfg.AuthNService.Host, cfg.AuthNService.Port)
+	authnClient, err := client.NewAuthNServiceClient(cfg.AuthNService.Host, cfg.AuthNService.Port, cfg.AuthNService.ClientID, os.Getenv("AUTHN_CLIENT_SECRET"))
 	if err != nil {
 		logger.Fatal("failed to connect to authn service: %v", err, nil)
 	}
@@ -92,8 +93,12 @@ func main() {
 
 	repo := repository.NewRepository(db)
 	adminRepo := repository.NewAdminRepository(db)
-	svc := service.NewAccountService(repo, authzClient)
-	svcAdmin, err := service.NewAdminService(adminRepo, repo, authzClient)
+	hasher, err := passwordhash.New(cfg.PasswordHash)
+	if err != nil {
+		log.Fatalf("Failed to initialize password hasher: %v", err)
+	}
+	svc := service.NewAccountService(repo, authzClient, hasher, cfg.PasswordPolicy)
+	svcAdmin, err := service.NewAdminService(adminRepo, repo, authzClient, authnClient)
 	if err != nil {
 		logger.Fatal("failed to connect to admin auth service: %v", err, nil)
 	}
@@ -104,7 +109,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	authInterceptor := middleware.NewAuthInterceptor(authzClient, authnClient)
+	authInterceptor := middleware.NewAuthInterceptor(authzClient, authnClient, cfg.AuthZService.LocalPermissionsTTL)
 
 	grpcServer := grpc.NewServer(
 		grpc.UnaryInterceptor(authInterceptor.Unary()),
@@ -2,10 +2,13 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/ashish19912009/zrms/services/account/internal/constants"
 	"github.com/ashish19912009/zrms/services/account/internal/logger"
 	"github.com/ashish19912009/zrms/services/account/internal/mapper"
 	"github.com/ashish19912009/zrms/services/account/internal/model"
@@ -17,16 +20,28 @@ import (
 
 type AuthNClient interface {
 	VerifyToken(ctx context.Context, access_token model.Token) (*model.AuthClaims, error)
+	VerifyAPIKey(ctx context.Context, apiKey string) (*model.AuthClaims, error)
+	RevokeFranchiseSessions(ctx context.Context, franchiseID, reason string) (int32, error)
 	Close() error
 }
 
+// serviceTokenRenewBefore is how long before it expires the service token is replaced
+const serviceTokenRenewBefore = time.Minute
+
 type authNClient struct {
-	client pb_authn.AuthServiceClient
-	conn   *grpc.ClientConn
-	err    error
+	client       pb_authn.AuthServiceClient
+	conn         *grpc.ClientConn
+	err          error
+	clientID     string
+	clientSecret string
+
+	mu           sync.Mutex
+	serviceToken string
+	tokenExpiry  time.Time
 }
 
-func NewAuthNServiceClient(host string, port string) (AuthNClient, error) {
+// NewAuthNServiceClient connects to authN, clientID and clientSecret get the service token RevokeFranchiseSessions requires
+func NewAuthNServiceClient(host, port, clientID, clientSecret string) (AuthNClient, error) {
 	env := os.Getenv("ENV")
 	if host == "" || port == "" {
 		logger.Fatal("host and port must be set", nil, nil)
@@ -53,8 +68,10 @@ func NewAuthNServiceClient(host string, port string) (AuthNClient, error) {
 	_, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 	return &authNClient{
-		client: client,
-		conn:   conn,
+		client:       client,
+		conn:         conn,
+		clientID:     clientID,
+		clientSecret: clientSecret,
 	}, nil
 }
 
@@ -73,6 +90,67 @@ func (authnClient *authNClient) VerifyToken(ctx context.Context, access_token mo
 	return aClaims, nil
 }
 
+// VerifyAPIKey checks an x-api-key header, the claims carry the key's franchise and scopes
+func (authnClient *authNClient) VerifyAPIKey(ctx context.Context, apiKey string) (*model.AuthClaims, error) {
+	authClaims, err := authnClient.client.VerifyAPIKey(ctx, &pb_authn.VerifyAPIKeyRequest{ApiKey: apiKey})
+	if err != nil {
+		logger.Error("something went wrong while verifying api key on authN services", err, nil)
+		return nil, err
+	}
+	aClaims, err := mapper.VerifyTokenFromPbToModel(authClaims)
+	if err != nil {
+		logger.Error("something went wrong while converting from PB to Model", err, nil)
+		return nil, err
+	}
+	return aClaims, nil
+}
+
+// RevokeFranchiseSessions logs every account of the franchise out, returns the number of sessions revoked
+func (authnClient *authNClient) RevokeFranchiseSessions(ctx context.Context, franchiseID, reason string) (int32, error) {
+	token, err := authnClient.token(ctx)
+	if err != nil {
+		return 0, err
+	}
+	res, err := authnClient.client.RevokeFranchiseSessions(ctx, &pb_authn.RevokeFranchiseSessionsRequest{
+		FranchiseId: franchiseID,
+		Reason:      reason,
+		AccessToken: token,
+	})
+	if err != nil {
+		logger.Error("something went wrong while revoking franchise sessions on authN services", err, nil)
+		return 0, err
+	}
+	return res.GetRevokedCount(), nil
+}
+
+// token returns the service token of the account service, a new one is requested shortly before it expires
+func (authnClient *authNClient) token(ctx context.Context) (string, error) {
+	authnClient.mu.Lock()
+	defer authnClient.mu.Unlock()
+
+	if authnClient.serviceToken != "" && time.Until(authnClient.tokenExpiry) > serviceTokenRenewBefore {
+		return authnClient.serviceToken, nil
+	}
+	if authnClient.clientID == "" || authnClient.clientSecret == "" {
+		logger.Error(constants.AuthNClientNotConfigured, nil, nil)
+		return "", errors.New(constants.AuthNClientNotConfigured)
+	}
+	res, err := authnClient.client.ClientCredentials(ctx, &pb_authn.ClientCredentialsRequest{
+		ClientId:     authnClient.clientID,
+		ClientSecret: authnClient.clientSecret,
+		Scopes:       []string{constants.ScopeRevokeFranchiseSessions},
+	})
+	if err != nil {
+		logger.Error(constants.FailedToGetServiceToken, err, map[string]interface{}{
+			"client_id": authnClient.clientID,
+		})
+		return "", err
+	}
+	authnClient.serviceToken = res.GetAccessToken()
+	authnClient.tokenExpiry = time.Now().Add(time.Duration(res.GetExpiresInSeconds()) * time.Second)
+	return authnClient.serviceToken, nil
+}
+
 func (authnClient *authNClient) Close() error {
 	return authnClient.conn.Close()
 }
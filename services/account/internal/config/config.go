@@ -3,25 +3,34 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
 	"gopkg.in/yaml.v3"
 )
 
 type AuthZServiceConfig struct {
 	Host string `yaml:"host_authz"`
 	Port string `yaml:"port_authz"`
+	// how long the version of an account's last CheckAccess answer vouches for permissions embedded in its tokens, 0 always asks AuthZ
+	LocalPermissionsTTL time.Duration `yaml:"local_permissions_ttl"`
 }
 
 type AuthNServiceConfig struct {
 	Host string `yaml:"host_authn"`
 	Port string `yaml:"port_authn"`
+	// client of the account service at authN's ClientCredentials RPC, the secret is read from AUTHN_CLIENT_SECRET
+	ClientID string `yaml:"client_id"`
 }
 
 type AppConfig struct {
-	Env          string             `yaml:"env"`
-	Port         string             `yaml:"port"`
-	AuthZService AuthZServiceConfig `yaml:"authz_service"`
-	AuthNService AuthNServiceConfig `yaml:"authn_service"`
+	Env            string                `yaml:"env"`
+	Port           string                `yaml:"port"`
+	AuthZService   AuthZServiceConfig    `yaml:"authz_service"`
+	AuthNService   AuthNServiceConfig    `yaml:"authn_service"`
+	PasswordHash   passwordhash.Config   `yaml:"password_hash"`   // must match authN's passwordHash
+	PasswordPolicy passwordpolicy.Policy `yaml:"password_policy"` // must match authN's passwordPolicy
 }
 
 // LoadConfig reads the YAML config file and unmarshals it into a Config struct
@@ -31,7 +40,7 @@ func LoadConfig(path string) (*AppConfig, error) {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	var cfg AppConfig
+	cfg := AppConfig{PasswordHash: passwordhash.Default(), PasswordPolicy: passwordpolicy.Default()}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("error parsing YAML config: %w", err)
 	}
@@ -161,6 +161,11 @@ const (
 	ErrInvalidPassword          = "invalid password"
 	WrongUsernamePassword       = "wrong username and password"
 	PasswordMissingFromServer   = "password missing from database"
+	FailedToHashPassword        = "failed to hash password"
+	FailedToRevokeFranchise     = "franchise updated but its sessions could not be revoked, authN cuts them off once it sees the status"
+	FranchiseStatusActive       = "active"
+	AuthNClientNotConfigured    = "authn client_id or AUTHN_CLIENT_SECRET is not set"
+	FailedToGetServiceToken     = "failed to get a service token from authN"
 	NoColumProvided             = "no columns provided"
 	UnauthorizedSchema          = "unauthorized schema: %s"
 	UnauthorizedTable           = "unauthorized table: %s"
@@ -184,6 +189,9 @@ const (
 	ErrInvalidToken            = "invalid token"
 	ErrUnexpectedSigningMethod = "unexpected signing method"
 
+	// scope authN requires on the service token of RevokeFranchiseSessions
+	ScopeRevokeFranchiseSessions = "authn:revoke_franchise_sessions"
+
 	// in memory DB Type
 	TypeKey       = ""
 	RedisType     = "redis"
@@ -27,13 +27,27 @@ func VerifyTokenFromPbToModel(authClaim *authn_pb.AuthClaims) (*model.AuthClaims
 				ExpiresAt: authClaim.RegisteredClaims.ExpiresAt,
 			}
 		}
+		var act *model.Actor
+		if authClaim.Act != nil {
+			act = &model.Actor{
+				Subject:   authClaim.Act.Subject,
+				SessionID: authClaim.Act.SessionId,
+			}
+		}
 		return &model.AuthClaims{
-			EmployeeID:       authClaim.EmployeeId,
-			FranchiseID:      authClaim.FranchiseId,
-			AccountType:      authClaim.AccountType,
-			Name:             authClaim.Name,
-			MobileNo:         authClaim.MobileNo,
-			RegisteredClaims: regClaims,
+			EmployeeID:         authClaim.EmployeeId,
+			FranchiseID:        authClaim.FranchiseId,
+			AccountType:        authClaim.AccountType,
+			Name:               authClaim.Name,
+			MobileNo:           authClaim.MobileNo,
+			RegisteredClaims:   regClaims,
+			SessionID:          authClaim.SessionId,
+			TokenType:          authClaim.TokenType,
+			Scopes:             authClaim.Scopes,
+			Permissions:        authClaim.Permissions,
+			PermissionsHash:    authClaim.PermissionsHash,
+			PermissionsVersion: authClaim.PermissionsVersion,
+			Act:                act,
 		}, nil
 	}
 	return nil, errors.New("Empty Auth Claims")
@@ -20,11 +20,14 @@ import (
 type AuthInterceptor struct {
 	authz_client client.AuthZClient
 	authn_client client.AuthNClient
+	policy       *policyVersion // nil when permissions embedded in tokens are ignored
 	//	validateToken func(token string) (map[string]interface{}, error)
 }
 
-func NewAuthInterceptor(authZ client.AuthZClient, authN client.AuthNClient) *AuthInterceptor {
-	return &AuthInterceptor{authz_client: authZ, authn_client: authN}
+// NewAuthInterceptor authorizes every call with AuthZ. With a positive localPermissionsTTL calls
+// covered by the permissions authN embedded in the token are allowed without asking AuthZ.
+func NewAuthInterceptor(authZ client.AuthZClient, authN client.AuthNClient, localPermissionsTTL time.Duration) *AuthInterceptor {
+	return &AuthInterceptor{authz_client: authZ, authn_client: authN, policy: newPolicyVersion(localPermissionsTTL)}
 }
 
 func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
@@ -42,10 +45,38 @@ func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 		ctx = context.WithValue(ctx, model.RequestContextKey, &model.RequestContext{
 			Claims: claims,
 		})
+		// Every call made with an impersonation token is flagged with the admin behind it
+		if claims.Act != nil {
+			logger.Warn("impersonated request", map[string]interface{}{
+				"layer":           "middleware",
+				"method":          "Unary",
+				"account_id":      claims.RegisteredClaims.Subject,
+				"impersonated_by": claims.Act.Subject,
+				"rpc":             info.FullMethod,
+			})
+		}
+		// Service tokens come from the ClientCredentials grant and API keys from partner integrations,
+		// both carry their permissions as scopes
+		if claims.TokenType == "service" || claims.TokenType == "api_key" {
+			err := authorizeServiceToken(claims, info.FullMethod)
+			if err == nil && claims.TokenType == "api_key" {
+				err = authorizeAPIKeyFranchise(claims, req)
+			}
+			if err != nil {
+				logger.Error("service token not authorized", err, map[string]interface{}{
+					"layer":     "middleware",
+					"method":    "Unary",
+					"client_id": claims.RegisteredClaims.Subject,
+					"rpc":       info.FullMethod,
+				})
+				return nil, status.Error(codes.PermissionDenied, "permission denied")
+			}
+			return handler(ctx, req)
+		}
 		if claims.AccountType == "superAdmin" || claims.AccountType == "superadmin" {
 			return handler(ctx, req)
 		}
-		internalCall, rPerm, err := a.extractAndValidatePermission(ctx)
+		rPerm, err := a.extractAndValidatePermission(ctx)
 		if err != nil || rPerm == nil {
 			logger.Error("no permission request", err, map[string]interface{}{
 				"layer":  "middleware",
@@ -54,11 +85,6 @@ func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 			return nil, status.Errorf(codes.PermissionDenied, "no permission requested")
 		}
 
-		// bypass Authz if service internal call
-		if internalCall != nil && *internalCall {
-			return handler(ctx, req)
-		}
-
 		perm, found := permissions.Get(info.FullMethod)
 		if !found {
 			return nil, fmt.Errorf("requested RPC not found")
@@ -66,21 +92,25 @@ func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 		if rPerm.Resource != perm.Resource || rPerm.Action != perm.Action {
 			return nil, fmt.Errorf("request RPC doesnot match, or permission or resource mismatch")
 		}
+		if claims.RegisteredClaims.Subject == "" || claims.AccountType == "" {
+			return nil, status.Error(codes.PermissionDenied, "account not verified")
+		}
+		if a.policy.allows(claims, rPerm) {
+			return handler(ctx, req)
+		}
 		ctx = context.Background()
 		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
 		isAuthorized, err := a.authz_client.CheckAccess(ctx, claims.RegisteredClaims.Subject, claims.FranchiseID, rPerm.Resource, rPerm.Action)
 		if err != nil {
 			return nil, err
 		}
+		a.policy.observe(claims.RegisteredClaims.Subject, isAuthorized.PolicyVersion)
 		if !isAuthorized.Allowed {
 			return nil, status.Error(codes.PermissionDenied, "permission denied")
 		}
 		if claims.RegisteredClaims.ExpiresAt.AsTime().Before(time.Now()) {
 			return nil, status.Error(codes.Unauthenticated, "token expired")
 		}
-		if claims.RegisteredClaims.Subject == "" || claims.AccountType == "" {
-			return nil, status.Error(codes.PermissionDenied, "account not verified")
-		}
 		return handler(ctx, req)
 	}
 }
@@ -93,6 +123,17 @@ func (a *AuthInterceptor) extractAndValidateToken(ctx context.Context) (*model.A
 
 	authHeader := helper.GetMetadataValue(md, "authorization", "Authorization")
 	if authHeader == "" {
+		// Partner integrations send an API key instead of a bearer token
+		if apiKey := helper.GetMetadataValue(md, "x-api-key", "X-Api-Key"); apiKey != "" {
+			authClaims, err := a.authn_client.VerifyAPIKey(ctx, apiKey)
+			if err != nil {
+				logger.Error("api key verification failed", err, map[string]interface{}{
+					"api_key": "[redacted]",
+				})
+				return nil, "", fmt.Errorf("failed to verify api key: %w", err)
+			}
+			return authClaims, "", nil
+		}
 		return nil, "", fmt.Errorf("authorization header is missing")
 	}
 
@@ -117,28 +158,50 @@ func (a *AuthInterceptor) extractAndValidateToken(ctx context.Context) (*model.A
 	return authClaims, token, nil
 }
 
-func (a *AuthInterceptor) extractAndValidatePermission(ctx context.Context) (*bool, *model.Permission, error) {
+// authorizeServiceToken allows the RPC when the token was granted its "resource:action" scope
+func authorizeServiceToken(claims *model.AuthClaims, fullMethod string) error {
+	perm, found := permissions.Get(fullMethod)
+	if !found {
+		return fmt.Errorf("requested RPC not found")
+	}
+	scope := perm.Resource + ":" + perm.Action
+	for _, granted := range claims.Scopes {
+		if granted == scope {
+			return nil
+		}
+	}
+	return fmt.Errorf("scope %q not granted", scope)
+}
+
+// authorizeAPIKeyFranchise keeps an API key inside its franchise. Keys only reach RPCs whose
+// request names the franchise, other RPCs could address records of any franchise by id.
+func authorizeAPIKeyFranchise(claims *model.AuthClaims, req interface{}) error {
+	scoped, ok := req.(interface{ GetFranchiseId() string })
+	if !ok {
+		return fmt.Errorf("rpc isn't scoped to a franchise")
+	}
+	if scoped.GetFranchiseId() != claims.FranchiseID {
+		return fmt.Errorf("api key belongs to another franchise")
+	}
+	return nil
+}
+
+func (a *AuthInterceptor) extractAndValidatePermission(ctx context.Context) (*model.Permission, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, nil, fmt.Errorf("missing metadata in context")
+		return nil, fmt.Errorf("missing metadata in context")
 	}
 
 	resource := helper.GetMetadataValue(md, "resource", "Resource")
 	if resource == "" {
-		return nil, nil, fmt.Errorf("missing resource in metadata")
+		return nil, fmt.Errorf("missing resource in metadata")
 	}
 
 	action := helper.GetMetadataValue(md, "action", "Action")
 	if action == "" {
-		return nil, nil, fmt.Errorf("missing action in metadata")
-	}
-	isInternal := helper.GetMetadataValue(md, "trusted", "Trusted")
-	if isInternal != "" && isInternal == "true" {
-		b := true
-		return &b, nil, nil
+		return nil, fmt.Errorf("missing action in metadata")
 	}
-	b := false
-	return &b, &model.Permission{
+	return &model.Permission{
 		Resource: resource,
 		Action:   action,
 	}, nil
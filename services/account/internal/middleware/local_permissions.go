@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/account/internal/model"
+)
+
+// maxTrackedAccounts bounds the versions kept, expired ones are dropped once it is reached
+const maxTrackedAccounts = 10000
+
+// policyVersion remembers per account the version of the last CheckAccess answer. AuthZ bumps
+// the version with every role or permission change of the account, so permissions embedded in
+// a token are only trusted while they carry the version AuthZ last answered with and that answer
+// was seen within ttl, after that the next call goes to AuthZ and refreshes it.
+type policyVersion struct {
+	mu       sync.RWMutex
+	accounts map[string]seenVersion
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+type seenVersion struct {
+	version string
+	seenAt  time.Time
+}
+
+func newPolicyVersion(ttl time.Duration) *policyVersion {
+	if ttl <= 0 {
+		return nil
+	}
+	return &policyVersion{accounts: map[string]seenVersion{}, ttl: ttl, now: time.Now}
+}
+
+func (p *policyVersion) observe(accountID, version string) {
+	if p == nil || accountID == "" || version == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if len(p.accounts) >= maxTrackedAccounts {
+		for id, seen := range p.accounts {
+			if now.Sub(seen.seenAt) > p.ttl {
+				delete(p.accounts, id)
+			}
+		}
+	}
+	p.accounts[accountID] = seenVersion{version: version, seenAt: now}
+}
+
+func (p *policyVersion) current(accountID string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	seen, ok := p.accounts[accountID]
+	if !ok || p.now().Sub(seen.seenAt) > p.ttl {
+		return "", false
+	}
+	return seen.version, true
+}
+
+// allows reports whether the permissions embedded at login grant perm under the current version of the account.
+// false only means AuthZ has to decide, it never denies on its own.
+func (p *policyVersion) allows(claims *model.AuthClaims, perm *model.Permission) bool {
+	version, ok := p.current(claims.RegisteredClaims.Subject)
+	if !ok || claims.PermissionsVersion != version {
+		return false
+	}
+	want := perm.Resource + ":" + perm.Action
+	for _, granted := range claims.Permissions {
+		if granted == want {
+			return true
+		}
+	}
+	return false
+}
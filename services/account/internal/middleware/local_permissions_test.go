@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/account/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var orderView = &model.Permission{Resource: "order", Action: "view"}
+
+func tokenClaims(accountID, version string, perms ...string) *model.AuthClaims {
+	return &model.AuthClaims{
+		RegisteredClaims:   &model.RegisteredClaims{Subject: accountID},
+		Permissions:        perms,
+		PermissionsVersion: version,
+	}
+}
+
+func TestPolicyVersionRevokedPermission(t *testing.T) {
+	p := newPolicyVersion(time.Minute)
+	claims := tokenClaims("acc-1", "v1.0.0+1", "order:view")
+
+	p.observe("acc-1", "v1.0.0+1")
+	assert.True(t, p.allows(claims, orderView), "the version AuthZ answered with vouches for the token")
+
+	// order:view is revoked, the next CheckAccess of the account answers with the bumped version
+	p.observe("acc-1", "v1.0.0+2")
+	assert.False(t, p.allows(claims, orderView), "the token from before the revocation goes to AuthZ")
+}
+
+func TestPolicyVersionAllows(t *testing.T) {
+	now := time.Now()
+	cases := map[string]struct {
+		observe func(p *policyVersion)
+		claims  *model.AuthClaims
+		want    bool
+	}{
+		"granted under the current version": {
+			observe: func(p *policyVersion) { p.observe("acc-1", "v1.0.0+1") },
+			claims:  tokenClaims("acc-1", "v1.0.0+1", "order:view"),
+			want:    true,
+		},
+		"not granted": {
+			observe: func(p *policyVersion) { p.observe("acc-1", "v1.0.0+1") },
+			claims:  tokenClaims("acc-1", "v1.0.0+1", "order:edit"),
+		},
+		"version of another account": {
+			observe: func(p *policyVersion) { p.observe("acc-2", "v1.0.0+1") },
+			claims:  tokenClaims("acc-1", "v1.0.0+1", "order:view"),
+		},
+		"version seen too long ago": {
+			observe: func(p *policyVersion) {
+				p.observe("acc-1", "v1.0.0+1")
+				p.now = func() time.Time { return now.Add(2 * time.Minute) }
+			},
+			claims: tokenClaims("acc-1", "v1.0.0+1", "order:view"),
+		},
+		"never asked AuthZ": {
+			observe: func(p *policyVersion) {},
+			claims:  tokenClaims("acc-1", "v1.0.0+1", "order:view"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := newPolicyVersion(time.Minute)
+			p.now = func() time.Time { return now }
+			tc.observe(p)
+			assert.Equal(t, tc.want, p.allows(tc.claims, orderView))
+		})
+	}
+}
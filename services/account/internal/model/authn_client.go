@@ -31,6 +31,20 @@ type AuthClaims struct {
 	Name             string            `json:"name" protobuf:"bytes,4,opt,name=name"`
 	MobileNo         string            `json:"mobile_no" protobuf:"bytes,5,opt,name=mobile_no"`
 	RegisteredClaims *RegisteredClaims `json:"registered_claims" protobuf:"bytes,6,opt,name=registered_claims"`
+	SessionID        string            `json:"session_id" protobuf:"bytes,7,opt,name=session_id"`
+	TokenType        string            `json:"token_type" protobuf:"bytes,8,opt,name=token_type"`
+	Scopes           []string          `json:"scopes" protobuf:"bytes,9,rep,name=scopes"`
+	// permissions authN embedded at login, only set when the feature is enabled there
+	Permissions        []string `json:"permissions" protobuf:"bytes,10,rep,name=permissions"`
+	PermissionsHash    string   `json:"permissions_hash" protobuf:"bytes,11,opt,name=permissions_hash"`
+	PermissionsVersion string   `json:"permissions_version" protobuf:"bytes,12,opt,name=permissions_version"`
+	// set on impersonation tokens, names the super admin acting as the subject
+	Act *Actor `json:"act,omitempty" protobuf:"bytes,13,opt,name=act"`
+}
+
+type Actor struct {
+	Subject   string `json:"sub" protobuf:"bytes,1,opt,name=subject"`
+	SessionID string `json:"sid,omitempty" protobuf:"bytes,2,opt,name=session_id"`
 }
 
 type RegisteredClaims struct {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ashish19912009/zrms/services/account/internal/client"
 	"github.com/ashish19912009/zrms/services/account/internal/constants"
+	"github.com/ashish19912009/zrms/services/account/internal/logger"
 	"github.com/ashish19912009/zrms/services/account/internal/model"
 	"github.com/ashish19912009/zrms/services/account/internal/repository"
 	"github.com/ashish19912009/zrms/services/account/internal/validations"
@@ -29,17 +30,19 @@ type adminService struct {
 	a_repo repository.AdminRepository // use interface, not pointer to struct+
 	repo   repository.Repository
 	client client.AuthZClient
+	authn  client.AuthNClient
 	// authorizer Authorizer (optionally inject this if using a real AuthZ client)
 }
 
-func NewAdminService(admin_repo repository.AdminRepository, repo repository.Repository, client client.AuthZClient) (AdminService, error) {
-	if admin_repo == nil || repo == nil || client == nil {
+func NewAdminService(admin_repo repository.AdminRepository, repo repository.Repository, client client.AuthZClient, authn client.AuthNClient) (AdminService, error) {
+	if admin_repo == nil || repo == nil || client == nil || authn == nil {
 		return nil, errors.New("fields required")
 	}
 	return &adminService{
 		a_repo: admin_repo,
 		repo:   repo,
 		client: client,
+		authn:  authn,
 	}, nil
 }
 
@@ -133,6 +136,10 @@ func (ad *adminService) UpdateFranchiseStatus(ctx context.Context, f_status *mod
 	if err != nil {
 		return nil, err
 	}
+	// authN refuses new logins on its own, existing sessions have to be revoked
+	if f_status.Status != constants.FranchiseStatusActive {
+		ad.revokeFranchiseSessions(ctx, f_status.ID, f_status.Status)
+	}
 	return updated_completed, nil
 }
 
@@ -152,9 +159,24 @@ func (ad *adminService) DeleteFranchise(ctx context.Context, f_del *model.Delete
 	if err != nil {
 		return nil, err
 	}
+	ad.revokeFranchiseSessions(ctx, f_del.ID, "deleted")
 	return rowsAffected, nil
 }
 
+// revokeFranchiseSessions cuts the sessions of the franchise off right away. The change is committed
+// by then and authN checks the franchise status when it verifies a token, so a failure only delays
+// the cut off and is logged instead of failing the request.
+func (ad *adminService) revokeFranchiseSessions(ctx context.Context, franchiseID, reason string) {
+	if _, err := ad.authn.RevokeFranchiseSessions(ctx, franchiseID, reason); err != nil {
+		logger.Error(constants.FailedToRevokeFranchise, err, map[string]interface{}{
+			"layer":        "service",
+			"method":       "revokeFranchiseSessions",
+			"franchise_id": franchiseID,
+			"reason":       reason,
+		})
+	}
+}
+
 func (ad *adminService) GetAllFranchises(ctx context.Context, page int32, limit int32) ([]model.FranchiseResponse, error) {
 	return ad.a_repo.GetAllFranchises(ctx, page, limit)
 }
@@ -3,12 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/ashish19912009/zrms/services/account/internal/client"
+	"github.com/ashish19912009/zrms/services/account/internal/constants"
 	"github.com/ashish19912009/zrms/services/account/internal/model"
 	"github.com/ashish19912009/zrms/services/account/internal/repository"
 	"github.com/ashish19912009/zrms/services/account/internal/validations"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
 )
 
 /*
@@ -58,13 +62,18 @@ type AccountService interface {
 type accountService struct {
 	repo   repository.Repository
 	client client.AuthZClient
+	hasher *passwordhash.Hasher
+	policy passwordpolicy.Policy
 }
 
-// NewAccountService initializes service with a repository
-func NewAccountService(repo repository.Repository, client client.AuthZClient) AccountService {
+// NewAccountService initializes service with a repository, passwords are checked against
+// the policy and stored with the hasher authN verifies them with
+func NewAccountService(repo repository.Repository, client client.AuthZClient, hasher *passwordhash.Hasher, policy passwordpolicy.Policy) AccountService {
 	return &accountService{
 		repo:   repo,
 		client: client,
+		hasher: hasher,
+		policy: policy,
 	}
 }
 
@@ -124,6 +133,9 @@ func (aS *accountService) CreateFranchiseAccount(ctx context.Context, account *m
 	if err := validations.ValidateFranchiseAccounts(account); err != nil {
 		return nil, err
 	}
+	if err := aS.hashPassword(account); err != nil {
+		return nil, err
+	}
 	f_account, err := aS.repo.CreateFranchiseAccount(ctx, account)
 	if err != nil {
 		return nil, err
@@ -139,12 +151,30 @@ func (aS *accountService) UpdateFranchiseAccount(ctx context.Context, id string,
 	if err := validations.ValidateFranchiseAccounts(account); err != nil {
 		return nil, err
 	}
+	if err := aS.hashPassword(account); err != nil {
+		return nil, err
+	}
 	f_owner, err := aS.repo.UpdateFranchiseAccount(ctx, id, account)
 	if err != nil {
 		return nil, err
 	}
 	return f_owner, nil
 }
+
+// hashPassword checks the plain password against the policy and replaces it, only the hash
+// reaches the database
+func (aS *accountService) hashPassword(account *model.FranchiseAccount) error {
+	if err := validations.ValidatePassword(account.Password, aS.policy); err != nil {
+		return err
+	}
+	hash, err := aS.hasher.Hash(account.Password)
+	if err != nil {
+		return fmt.Errorf("%s: %w", constants.FailedToHashPassword, err)
+	}
+	account.Password = hash
+	return nil
+}
+
 func (aS *accountService) GetFranchiseAccountByID(ctx context.Context, id string) (*model.FranchiseAccountResponse, error) {
 	// 💡 Run validations before calling repo
 	if err := validations.ValidateUUID(id); err != nil {
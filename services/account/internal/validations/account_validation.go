@@ -11,6 +11,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/ashish19912009/zrms/services/account/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
 )
 
 var (
@@ -55,14 +56,14 @@ var (
 	ErrInvalidURL               = errors.New("input must be a valid URL")
 	ErrNotLowercase             = errors.New("input must be all lowercase")
 	ErrNotUppercase             = errors.New("input must be all uppercase")
-	ErrPasswordEmpty            = errors.New("password is required")
-	ErrPasswordTooShort         = errors.New("password must be at least 8 characters long")
-	ErrPasswordTooLong          = errors.New("password must not exceed 64 characters")
-	ErrPasswordMissingUpper     = errors.New("password must contain at least one uppercase letter")
-	ErrPasswordMissingLower     = errors.New("password must contain at least one lowercase letter")
-	ErrPasswordMissingDigit     = errors.New("password must contain at least one digit")
-	ErrPasswordMissingSpecial   = errors.New("password must contain at least one special character")
-	ErrPasswordContainsSpace    = errors.New("password must not contain spaces")
+	ErrPasswordEmpty            = passwordpolicy.ErrEmpty
+	ErrPasswordTooShort         = passwordpolicy.ErrTooShort
+	ErrPasswordTooLong          = passwordpolicy.ErrTooLong
+	ErrPasswordMissingUpper     = passwordpolicy.ErrMissingUpper
+	ErrPasswordMissingLower     = passwordpolicy.ErrMissingLower
+	ErrPasswordMissingDigit     = passwordpolicy.ErrMissingDigit
+	ErrPasswordMissingSpecial   = passwordpolicy.ErrMissingSpecial
+	ErrPasswordContainsSpace    = passwordpolicy.ErrContainsSpace
 	ErrUUIDEmpty                = errors.New("UUID is required")
 	ErrInvalidUUID              = errors.New("invalid UUID format")
 	ErrEmailEmpty               = errors.New("email is required")
@@ -266,12 +267,10 @@ func ValidateFranchiseAccounts(acc *model.FranchiseAccount) error {
 		return err
 	}
 	// validate password
+	// the strength is checked by the service against the configured password policy
 	if err := ValidateNotEmpty(password); err != nil {
 		return err
 	}
-	if err := ValidatePassword(password); err != nil {
-		return err
-	}
 	// validate account type
 	if err := ValidateNotEmpty(account_type); err != nil {
 		return err
@@ -684,43 +683,10 @@ func RemoveNonAlphanumeric(str string) string {
 // str := "Hello, World!123"
 // cleaned := removeNonAlphanumeric(str) // "HelloWorld123"
 
-// ValidatePassword checks password strength based on common security rules
-func ValidatePassword(password string) error {
-	password = strings.TrimSpace(password)
-
-	if password == "" {
-		return ErrPasswordEmpty
-	}
-
-	if len(password) < 8 {
-		return ErrPasswordTooShort
-	}
-
-	if len(password) > 64 {
-		return ErrPasswordTooLong
-	}
-
-	if strings.Contains(password, " ") {
-		return ErrPasswordContainsSpace
-	}
-
-	if match, _ := regexp.MatchString(`[A-Z]`, password); !match {
-		return ErrPasswordMissingUpper
-	}
-
-	if match, _ := regexp.MatchString(`[a-z]`, password); !match {
-		return ErrPasswordMissingLower
-	}
-
-	if match, _ := regexp.MatchString(`[0-9]`, password); !match {
-		return ErrPasswordMissingDigit
-	}
-
-	if match, _ := regexp.MatchString(`[\W_]`, password); !match {
-		return ErrPasswordMissingSpecial
-	}
-
-	return nil
+// ValidatePassword checks password strength with the policy configured like the authN one,
+// so passwords set here are accepted by ChangePassword and the other way around
+func ValidatePassword(password string, policy passwordpolicy.Policy) error {
+	return policy.Validate(password)
 }
 
 // ValidateUUID checks if the input string is a valid UUID
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ashish19912009/zrms/services/account/internal/model"
 	"github.com/ashish19912009/zrms/services/account/internal/validations"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -355,8 +356,9 @@ func TestRemoveNonAlphanumeric(t *testing.T) {
 }
 
 func TestValidatePassword(t *testing.T) {
+	policy := passwordpolicy.Default()
 	valid := "Aa1@validpassword"
-	if err := validations.ValidatePassword(valid); err != nil {
+	if err := validations.ValidatePassword(valid, policy); err != nil {
 		t.Errorf("expected nil, got %v", err)
 	}
 
@@ -364,18 +366,24 @@ func TestValidatePassword(t *testing.T) {
 		"":                      ErrPasswordEmpty.Error(),
 		"short":                 ErrPasswordTooShort.Error(),
 		strings.Repeat("a", 65): ErrPasswordTooLong.Error(),
-		"no upper1@":            ErrPasswordMissingUpper.Error(),
+		"noupper1@":             ErrPasswordMissingUpper.Error(),
 		"NOLOWER1@":             ErrPasswordMissingLower.Error(),
 		"NoNumber@":             ErrPasswordMissingDigit.Error(),
 		"NoSpecial1":            ErrPasswordMissingSpecial.Error(),
 		"Contains space1@A":     ErrPasswordContainsSpace.Error(),
 	}
 	for input, expected := range tests {
-		err := validations.ValidatePassword(input)
+		err := validations.ValidatePassword(input, policy)
 		if err == nil || err.Error() != expected {
 			t.Errorf("expected %v, got %v for input %q", expected, err, input)
 		}
 	}
+
+	// the configured policy decides, not the defaults
+	policy.MinLength = 20
+	if err := validations.ValidatePassword(valid, policy); !errors.Is(err, passwordpolicy.ErrTooShort) {
+		t.Errorf("expected the configured minimum length to apply, got %v", err)
+	}
 }
 
 func TestValidateUUID(t *testing.T) {
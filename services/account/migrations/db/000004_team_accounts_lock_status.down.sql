@@ -0,0 +1,6 @@
+UPDATE outlet.team_accounts SET status = 'active' WHERE status = 'locked';
+
+ALTER TABLE outlet.team_accounts
+    DROP CONSTRAINT IF EXISTS team_accounts_status_check;
+
+COMMENT ON COLUMN outlet.team_accounts.status IS NULL;
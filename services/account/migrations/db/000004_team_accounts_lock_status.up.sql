@@ -0,0 +1,6 @@
+-- team_accounts.status can hold a permanent login lock set by authN after repeated lockouts
+ALTER TABLE outlet.team_accounts
+    ADD CONSTRAINT team_accounts_status_check
+    CHECK (status IN ('active', 'inactive', 'suspended', 'locked'));
+
+COMMENT ON COLUMN outlet.team_accounts.status IS 'active, inactive, suspended or locked (cleared by the authN UnlockAccount RPC)';
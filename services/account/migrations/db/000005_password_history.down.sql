@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS outlet.idx_password_history_account_id;
+DROP TABLE IF EXISTS outlet.password_history;
@@ -0,0 +1,9 @@
+-- Previous password hashes of a team account, authN rejects a new password that matches one of them
+CREATE TABLE IF NOT EXISTS outlet.password_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES outlet.team_accounts(id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_account_id ON outlet.password_history(account_id, created_at DESC);
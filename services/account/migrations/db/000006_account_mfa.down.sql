@@ -0,0 +1 @@
+DROP TABLE IF EXISTS outlet.account_mfa;
@@ -0,0 +1,10 @@
+-- TOTP enrollment of a team account, authN asks for a code on login once confirmed_at is set
+CREATE TABLE IF NOT EXISTS outlet.account_mfa (
+    account_id UUID PRIMARY KEY REFERENCES outlet.team_accounts(id) ON DELETE CASCADE,
+    totp_secret TEXT NOT NULL,
+    recovery_codes TEXT[] NOT NULL DEFAULT '{}', -- sha256 hashes, used codes are removed
+    last_used_step BIGINT NOT NULL DEFAULT 0,    -- replay protection for TOTP codes
+    confirmed_at TIMESTAMPTZ,                    -- NULL while the enrollment is pending
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
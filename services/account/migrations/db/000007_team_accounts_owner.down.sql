@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS outlet.idx_team_accounts_owner_id;
+
+ALTER TABLE outlet.team_accounts
+    DROP COLUMN IF EXISTS owner_id;
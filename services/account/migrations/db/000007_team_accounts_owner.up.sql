@@ -0,0 +1,8 @@
+-- Links the team accounts of one person across franchises, authN SwitchFranchise and
+-- ListMyFranchises only move between accounts that share an owner_id
+ALTER TABLE outlet.team_accounts
+    ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES outlet.owners(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_team_accounts_owner_id ON outlet.team_accounts(owner_id);
+
+COMMENT ON COLUMN outlet.team_accounts.owner_id IS 'person behind the account, set by super admins for owners and staff working in several franchises';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS outlet.api_keys;
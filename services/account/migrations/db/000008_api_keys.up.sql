@@ -0,0 +1,18 @@
+-- API keys of partner integrations (POS, aggregators), managed through authN.
+-- Only the sha256 of a key is stored, prefix is the part shown in listings.
+CREATE TABLE IF NOT EXISTS outlet.api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    franchise_id UUID NOT NULL REFERENCES outlet.franchises(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT UNIQUE NOT NULL,
+    key_hash TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- resource:action pairs
+    created_by UUID REFERENCES outlet.team_accounts(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ,              -- NULL for keys without expiry
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_franchise_id ON outlet.api_keys(franchise_id);
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS trg_team_accounts_role_version ON outlet.team_accounts;
+DROP TRIGGER IF EXISTS trg_direct_permissions_version ON outlet.direct_permissions;
+DROP TRIGGER IF EXISTS trg_role_permissions_version ON outlet.role_permissions;
+
+DROP FUNCTION IF EXISTS outlet.bump_account_role_version();
+DROP FUNCTION IF EXISTS outlet.bump_direct_permissions_version();
+DROP FUNCTION IF EXISTS outlet.bump_role_permissions_version();
+
+ALTER TABLE outlet.team_accounts
+    DROP COLUMN IF EXISTS permissions_version;
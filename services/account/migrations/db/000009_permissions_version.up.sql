@@ -0,0 +1,52 @@
+-- team_accounts.permissions_version goes up on every change to what the account may do.
+-- AuthZ hands it out with its decisions and authN embeds it in access tokens, so a token
+-- minted before a role or permission write no longer matches and services ask AuthZ again.
+-- The triggers cover every writer, not only the account service.
+ALTER TABLE outlet.team_accounts
+    ADD COLUMN IF NOT EXISTS permissions_version BIGINT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN outlet.team_accounts.permissions_version IS 'bumped on role, role permission and direct permission changes of the account';
+
+CREATE OR REPLACE FUNCTION outlet.bump_role_permissions_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE outlet.team_accounts SET permissions_version = permissions_version + 1 WHERE role_id = OLD.role_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE outlet.team_accounts SET permissions_version = permissions_version + 1 WHERE role_id = NEW.role_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_role_permissions_version
+    AFTER INSERT OR UPDATE OR DELETE ON outlet.role_permissions
+    FOR EACH ROW EXECUTE FUNCTION outlet.bump_role_permissions_version();
+
+CREATE OR REPLACE FUNCTION outlet.bump_direct_permissions_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE outlet.team_accounts SET permissions_version = permissions_version + 1 WHERE id = OLD.account_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE outlet.team_accounts SET permissions_version = permissions_version + 1 WHERE id = NEW.account_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_direct_permissions_version
+    AFTER INSERT OR UPDATE OR DELETE ON outlet.direct_permissions
+    FOR EACH ROW EXECUTE FUNCTION outlet.bump_direct_permissions_version();
+
+CREATE OR REPLACE FUNCTION outlet.bump_account_role_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.permissions_version := OLD.permissions_version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_team_accounts_role_version
+    BEFORE UPDATE OF role_id ON outlet.team_accounts
+    FOR EACH ROW WHEN (OLD.role_id IS DISTINCT FROM NEW.role_id)
+    EXECUTE FUNCTION outlet.bump_account_role_version();
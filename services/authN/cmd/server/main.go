@@ -1,23 +1,33 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/ashish19912009/zrms/services/authN/internal/client"
 	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/gateway"
 	"github.com/ashish19912009/zrms/services/authN/internal/handler"
+	"github.com/ashish19912009/zrms/services/authN/internal/helper"
 	"github.com/ashish19912009/zrms/services/authN/internal/jwk"
 	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/notifier"
+	"github.com/ashish19912009/zrms/services/authN/internal/oidc"
 	"github.com/ashish19912009/zrms/services/authN/internal/repository"
 	"github.com/ashish19912009/zrms/services/authN/internal/service"
 	"github.com/ashish19912009/zrms/services/authN/internal/store"
 	"github.com/ashish19912009/zrms/services/authN/internal/token"
 	pb "github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
@@ -109,29 +119,100 @@ func main() {
 
 	userRepo := repository.NewUserRepository(db)
 	tokenRepo := repository.NewTokenRepository(inMemoryStore)
-	tokenManger, err := token.NewjwtManager(cfg.JWTPrivateKeyPath, cfg.JWTPublicKeyPath, cfg.JWTHeader)
+	sessionRepo := repository.NewSessionRepository(inMemoryStore)
+	attemptRepo := repository.NewLoginAttemptRepository(inMemoryStore)
+	resetRepo := repository.NewPasswordResetRepository(inMemoryStore)
+	mfaRepo := repository.NewMFARepository(db)
+	challengeRepo := repository.NewMFAChallengeRepository(inMemoryStore)
+	otpRepo := repository.NewLoginOTPRepository(inMemoryStore)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	codeNotifier, err := notifier.New(cfg.Notifier)
+	if err != nil {
+		log.Fatalf("Failed to initialize notifier: %v", err)
+	}
+	hasher, err := passwordhash.New(cfg.PasswordHash)
+	if err != nil {
+		log.Fatalf("Failed to initialize password hasher: %v", err)
+	}
+	// AuthZ is only called at login when permissions are embedded in the access token
+	var authzClient client.AuthZClient
+	if cfg.TokenPermissions.Enabled {
+		authzClient, err = client.NewAuthZServiceClient(cfg.TokenPermissions.AuthZHost, cfg.TokenPermissions.AuthZPort)
+		if err != nil {
+			log.Fatalf("Failed to connect to AuthZ service: %v", err)
+		}
+		defer authzClient.Close()
+	}
+	tokenManger, err := token.NewjwtManager(cfg.JWTPrivateKeyPath, cfg.JWTPublicKeyPath, cfg.JWTKeyringDir, cfg.JWTHeader)
 	if err != nil {
 		log.Fatalf("failed to create JWT manager: %v", err)
 	}
 
-	// Initialize the JWK set using the manager's public key
-	if err := jwk.InitializeJWK(tokenManger.PublicKey(), cfg.JWTHeader.Kid, cfg.JWTHeader.Alg, cfg.JWTHeader.Use); err != nil {
+	// Initialize the JWK set with every key of the keyring, active and retired
+	if err := jwk.InitializeKeyringJWK(tokenManger.Keyring().PublicKeys(), cfg.JWTHeader.Use); err != nil {
 		log.Fatalf("Failed to initialize JWK: %v", err)
 	}
+
+	// Reload the keyring on SIGHUP after a new signing key has been promoted
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go func() {
+		for range reloadChan {
+			if err := tokenManger.Reload(); err != nil {
+				logger.Error("Failed to reload signing keyring", err, nil)
+				continue
+			}
+			if err := jwk.InitializeKeyringJWK(tokenManger.Keyring().PublicKeys(), cfg.JWTHeader.Use); err != nil {
+				logger.Error("Failed to refresh JWK set", err, nil)
+			}
+		}
+	}()
+
+	// only these peers may name the client in x-forwarded-for, everyone else is taken at their address
+	trustedProxies, err := helper.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid trustedProxies: %v", err)
+	}
+
 	// register grpc server
 	grpcServer := grpc.NewServer()
 
 	var authService service.AuthService
 	accessTTL := os.Getenv("ACCESS_TOKEN_TTL")
 	refreshTTL := os.Getenv("REFRESH_TOKEN_TTL")
-	authService = service.NewAuthService(tokenManger, tokenRepo, userRepo)
+	deps := service.Deps{
+		TokenManager:      tokenManger,
+		TokenRepo:         tokenRepo,
+		UserRepo:          userRepo,
+		SessionRepo:       sessionRepo,
+		AttemptRepo:       attemptRepo,
+		ResetRepo:         resetRepo,
+		MFARepo:           mfaRepo,
+		ChallengeRepo:     challengeRepo,
+		OTPRepo:           otpRepo,
+		APIKeyRepo:        apiKeyRepo,
+		Notifier:          codeNotifier,
+		Hasher:            hasher,
+		AuthZClient:       authzClient,
+		Lockout:           cfg.Lockout,
+		MFA:               cfg.MFA,
+		LoginOTP:          cfg.LoginOTP,
+		ClientCredentials: cfg.ClientCredentials,
+		TokenPermissions:  cfg.TokenPermissions,
+		Impersonation:     cfg.Impersonation,
+		APIKeys:           cfg.APIKeys,
+		IdleTimeout:       cfg.IdleTimeout,
+		PasswordPolicy:    cfg.PasswordPolicy,
+		TrustedProxies:    trustedProxies,
+	}
+	authService = service.NewAuthService(deps)
 	if accessTTL != "" && refreshTTL != "" {
 		attl, err1 := time.ParseDuration(accessTTL)
 		rttl, err2 := time.ParseDuration(refreshTTL)
 		if err1 != nil && err2 != nil {
 			log.Fatalf("Invalid access duration: %v or refresh duration: %v", err1, err2)
 		} else {
-			authService = service.NewAuthServiceWithTTL(tokenManger, tokenRepo, userRepo, attl, rttl)
+			authService = service.NewAuthServiceWithTTL(deps, attl, rttl)
 		}
 	}
 
@@ -146,20 +227,34 @@ func main() {
 	if cfg.Env != "production" {
 		reflection.Register(grpcServer)
 	}
+	httpPort := os.Getenv("JWK_HTTP_PORT")
+	if httpPort == "" {
+		httpPort = "8080" // default fallback
+	}
+	httpServer := &http.Server{Addr: ":" + httpPort}
 	httpErrChan := make(chan error)
 	go func() {
-		http.HandleFunc("/.well-known/jwks.json", jwk.Handler)
-
-		httpPort := os.Getenv("JWK_HTTP_PORT")
-		if httpPort == "" {
-			httpPort = "8080" // default fallback
+		oidcHandler := oidc.NewHandler(
+			os.Getenv(constants.EnvVariable.JWT_ISSUER),
+			os.Getenv(constants.EnvVariable.JWT_AUDIENCE),
+			func() []string { return tokenManger.Keyring().Algorithms() },
+			authService,
+			cfg.OIDC.IntrospectionClients,
+			trustedProxies,
+		)
+		http.HandleFunc(oidc.JWKSPath, jwk.Handler)
+		http.HandleFunc(oidc.DiscoveryPath, oidcHandler.Discovery)
+		http.HandleFunc(oidc.IntrospectionPath, oidcHandler.Introspect)
+		if cfg.Gateway.Enabled {
+			gatewayHandler := gateway.NewHandler(authService, cfg.Gateway, trustedProxies)
+			http.Handle(gatewayHandler.Pattern(), gatewayHandler)
 		}
 
 		logger.Info("Starting JWK HTTP server", map[string]interface{}{
 			"port": httpPort,
 		})
 
-		if err := http.ListenAndServe(":"+httpPort, nil); err != nil {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			httpErrChan <- err
 		}
 	}()
@@ -173,7 +268,10 @@ func main() {
 		}
 	}()
 
-	// Wait for either server to fail
+	stopChan := make(chan os.Signal, 1)
+	signal.Notify(stopChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Wait for either server to fail or a shutdown signal
 	select {
 	case err := <-httpErrChan:
 		logger.Error("HTTP server failed", err, nil)
@@ -181,5 +279,19 @@ func main() {
 	case err := <-grpcErrChan:
 		logger.Error("gRPC server failed", err, nil)
 		os.Exit(1)
+	case sig := <-stopChan:
+		logger.Info("Shutting down", map[string]interface{}{"signal": sig.String()})
+	}
+
+	// Finish in-flight requests before closing the store, so the LightningDB snapshot
+	// written by Close has every session and keeps users logged in across the restart
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Error("HTTP server shutdown failed", err, nil)
+	}
+	grpcServer.GracefulStop()
+	if err := inMemoryStore.Close(); err != nil {
+		logger.Error("Failed to close in-memory store", err, nil)
 	}
 }
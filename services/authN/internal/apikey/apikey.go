@@ -0,0 +1,58 @@
+// Package apikey generates and checks the API keys partner integrations use instead of tokens.
+// A key looks like zrk_<prefix>_<secret>, the prefix finds the stored key and is the only part
+// shown again, the key itself is only stored as its SHA-256.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	Scheme     = "zrk"
+	PrefixSize = 4  // random bytes, 8 hex characters
+	SecretSize = 32 // random bytes
+)
+
+// Generate returns a new key with its prefix and hash, the key is shown to the caller once
+func Generate() (key, prefix, hash string, err error) {
+	buf := make([]byte, PrefixSize+SecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(buf[:PrefixSize])
+	key = Scheme + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(buf[PrefixSize:])
+	return key, prefix, Hash(key), nil
+}
+
+// Prefix extracts the lookup prefix, ok is false when key isn't shaped like an API key
+func Prefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != Scheme || len(parts[1]) != 2*PrefixSize || parts[2] == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify compares key with a stored hash in constant time
+func Verify(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hash)) == 1
+}
+
+// ValidScope accepts the "resource:action" pairs the account service checks
+func ValidScope(scope string) bool {
+	resource, action, ok := strings.Cut(scope, ":")
+	return ok && resource != "" && action != "" && !strings.ContainsAny(scope, " \t\n")
+}
@@ -0,0 +1,63 @@
+package apikey_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/apikey"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !strings.HasPrefix(key, apikey.Scheme+"_"+prefix+"_") {
+		t.Fatalf("key %q doesn't start with its prefix %q", key, prefix)
+	}
+	if got, ok := apikey.Prefix(key); !ok || got != prefix {
+		t.Fatalf("expected prefix %q, got %q %v", prefix, got, ok)
+	}
+	if !apikey.Verify(key, hash) {
+		t.Fatal("a generated key must verify against its hash")
+	}
+	if apikey.Verify(key+"x", hash) {
+		t.Fatal("a modified key must not verify")
+	}
+
+	other, _, _, _ := apikey.Generate()
+	if other == key {
+		t.Fatal("two keys must differ")
+	}
+}
+
+func TestPrefix_Malformed(t *testing.T) {
+	for _, key := range []string{
+		"",
+		"Bearer abc",
+		"zrk_0123abcd",
+		"zrk_0123abcd_",
+		"xyz_0123abcd_secret",
+		"zrk_0123_secret",
+		"zrk_0123abcz_secret",
+	} {
+		if _, ok := apikey.Prefix(key); ok {
+			t.Errorf("expected %q to be rejected", key)
+		}
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	valid := []string{"order:view", "franchiseAccount:create"}
+	invalid := []string{"", "order", ":view", "order:", "order: view"}
+	for _, s := range valid {
+		if !apikey.ValidScope(s) {
+			t.Errorf("expected %q to be valid", s)
+		}
+	}
+	for _, s := range invalid {
+		if apikey.ValidScope(s) {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
@@ -86,6 +86,7 @@ func (authzClient *authZClient) BatchCheckAccess(ctx context.Context, accountID,
 			"layer":  "client",
 			"method": "BatchCheckAccess",
 		})
+		return nil, err
 	}
 	res, err := authzClient.client.BatchCheckAccess(ctx, aM)
 	if err != nil {
@@ -93,9 +94,9 @@ func (authzClient *authZClient) BatchCheckAccess(ctx context.Context, accountID,
 			"layer":  "client",
 			"method": "BatchCheckAccess",
 		})
+		return nil, err
 	}
-	response, err := mapper.BatchCheckAccessFromPbToModel(res)
-	return response, nil
+	return mapper.BatchCheckAccessFromPbToModel(res)
 }
 
 func (authzClient *authZClient) Close() error {
@@ -1,9 +1,17 @@
 package config
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,11 +23,357 @@ type JWTHeaderConfig struct {
 }
 
 type AppConfig struct {
-	Env               string          `yaml:"env"`
-	Port              string          `yaml:"port"`
-	JWTPrivateKeyPath string          `yaml:"jwtPrivateKeyPath"`
-	JWTPublicKeyPath  string          `yaml:"jwtPublicKeyPath"`
-	JWTHeader         JWTHeaderConfig `yaml:"jwtHeader"`
+	Env               string                  `yaml:"env"`
+	Port              string                  `yaml:"port"`
+	TrustedProxies    []string                `yaml:"trustedProxies"` // CIDRs or IPs of the proxies that set x-forwarded-for
+	JWTPrivateKeyPath string                  `yaml:"jwtPrivateKeyPath"`
+	JWTPublicKeyPath  string                  `yaml:"jwtPublicKeyPath"`
+	JWTKeyringDir     string                  `yaml:"jwtKeyringDir"` // optional, enables key rotation
+	JWTHeader         JWTHeaderConfig         `yaml:"jwtHeader"`
+	Lockout           LockoutConfig           `yaml:"lockout"`
+	MFA               MFAConfig               `yaml:"mfa"`
+	LoginOTP          LoginOTPConfig          `yaml:"loginOTP"`
+	OIDC              OIDCConfig              `yaml:"oidc"`
+	ClientCredentials ClientCredentialsConfig `yaml:"clientCredentials"`
+	TokenPermissions  TokenPermissionsConfig  `yaml:"tokenPermissions"`
+	Impersonation     ImpersonationConfig     `yaml:"impersonation"`
+	APIKeys           APIKeysConfig           `yaml:"apiKeys"`
+	IdleTimeout       IdleTimeoutConfig       `yaml:"idleTimeout"`
+	Gateway           GatewayConfig           `yaml:"gateway"`
+	Notifier          NotifierConfig          `yaml:"notifier"`
+	PasswordPolicy    passwordpolicy.Policy   `yaml:"passwordPolicy"`
+	PasswordHash      passwordhash.Config     `yaml:"passwordHash"`
+}
+
+// NotifierConfig selects how one-time codes reach the user
+type NotifierConfig struct {
+	Type     string    `yaml:"type"`     // "log" (default) writes messages to the log or FilePath, for local development only
+	FilePath string    `yaml:"filePath"` // optional, the log notifier appends one JSON line per message
+	SMS      SMSConfig `yaml:"sms"`
+}
+
+// SMSConfig selects the SMS provider, without one SMS messages go to the notifier above
+type SMSConfig struct {
+	Provider string `yaml:"provider"` // "console" or "file", development stubs until a gateway is added
+	FilePath string `yaml:"filePath"` // required by the file provider
+}
+
+// OIDCConfig configures the OpenID Connect discovery and RFC 7662 introspection endpoints
+type OIDCConfig struct {
+	IntrospectionClients []OAuthClientConfig `yaml:"introspectionClients"` // clients allowed to call /introspect
+}
+
+// OAuthClientConfig is a client authenticating with client_id and client_secret.
+// Only the SHA-256 of the secret is configured, generate it with `printf %s "$SECRET" | sha256sum`.
+type OAuthClientConfig struct {
+	ClientID     string   `yaml:"clientID"`
+	SecretSHA256 string   `yaml:"secretSHA256"`
+	Scopes       []string `yaml:"scopes"` // "resource:action" pairs a service client may request, unused for introspection
+}
+
+// VerifySecret compares the SHA-256 of secret with the configured one in constant time
+func (c OAuthClientConfig) VerifySecret(secret string) bool {
+	sum := sha256.Sum256([]byte(secret))
+	expected := strings.ToLower(c.SecretSHA256)
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(expected)) == 1
+}
+
+// TokenPermissionsConfig embeds the effective permissions of an account in its access token.
+// It is off by default, authN then never calls AuthZ during login.
+type TokenPermissionsConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	AuthZHost   string        `yaml:"authzHost"`
+	AuthZPort   string        `yaml:"authzPort"`
+	Timeout     time.Duration `yaml:"timeout"`     // a slower AuthZ gets a token without permissions instead of a failed login
+	MaxEmbedded int           `yaml:"maxEmbedded"` // larger sets are embedded as hash and version only
+	ClientID    string        `yaml:"clientID"`    // subject of the service token authN presents to AuthZ
+}
+
+func (c TokenPermissionsConfig) WithDefaults() TokenPermissionsConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = 2 * time.Second
+	}
+	if c.MaxEmbedded <= 0 {
+		c.MaxEmbedded = 64
+	}
+	if c.ClientID == "" {
+		c.ClientID = "authn-service"
+	}
+	return c
+}
+
+// ImpersonationConfig limits the access tokens super admins get through the Impersonate RPC
+type ImpersonationConfig struct {
+	TTL time.Duration `yaml:"ttl"` // never longer than the admin's own token
+}
+
+func (c ImpersonationConfig) WithDefaults() ImpersonationConfig {
+	if c.TTL <= 0 {
+		c.TTL = 15 * time.Minute
+	}
+	return c
+}
+
+// GatewayConfig exposes Login, RefreshToken, Logout and VerifyToken as JSON on the HTTP server
+type GatewayConfig struct {
+	Enabled       bool                `yaml:"enabled"`
+	BasePath      string              `yaml:"basePath"` // routes are <basePath>/login, /refresh, /logout and /verify
+	RefreshCookie RefreshCookieConfig `yaml:"refreshCookie"`
+	CORS          CORSConfig          `yaml:"cors"`
+}
+
+// RefreshCookieConfig moves the refresh token out of the JSON body into an HttpOnly cookie
+type RefreshCookieConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Name     string        `yaml:"name"`
+	Path     string        `yaml:"path"` // defaults to the gateway base path so the cookie isn't sent anywhere else
+	Domain   string        `yaml:"domain"`
+	Secure   bool          `yaml:"secure"`   // keep true outside local development
+	SameSite string        `yaml:"sameSite"` // strict (default), lax or none, none requires secure
+	MaxAge   time.Duration `yaml:"maxAge"`   // should match REFRESH_TOKEN_TTL
+}
+
+// CORSConfig lists the browser origins allowed to call the gateway, "*" allows any origin but
+// can't be combined with allowCredentials
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins"`
+	AllowCredentials bool          `yaml:"allowCredentials"` // needed for the refresh cookie on cross-origin calls
+	MaxAge           time.Duration `yaml:"maxAge"`           // how long browsers cache a preflight
+}
+
+func (c GatewayConfig) WithDefaults() GatewayConfig {
+	if c.BasePath == "" {
+		c.BasePath = "/auth"
+	}
+	c.BasePath = "/" + strings.Trim(c.BasePath, "/")
+	if c.RefreshCookie.Name == "" {
+		c.RefreshCookie.Name = "zrms_refresh"
+	}
+	if c.RefreshCookie.Path == "" {
+		c.RefreshCookie.Path = c.BasePath
+	}
+	if c.RefreshCookie.SameSite == "" {
+		c.RefreshCookie.SameSite = "strict"
+	}
+	if c.RefreshCookie.MaxAge <= 0 {
+		c.RefreshCookie.MaxAge = 7 * 24 * time.Hour
+	}
+	if c.CORS.MaxAge <= 0 {
+		c.CORS.MaxAge = 10 * time.Minute
+	}
+	return c
+}
+
+// Validate refuses settings browsers would either reject or turn into a hole: credentialed
+// CORS for any origin lets every site read the responses of a logged in user
+func (c GatewayConfig) Validate() error {
+	if c.CORS.AllowCredentials {
+		for _, origin := range c.CORS.AllowedOrigins {
+			if strings.TrimSpace(origin) == "*" {
+				return errors.New(`gateway.cors: allowedOrigins "*" can't be combined with allowCredentials, list the origins`)
+			}
+		}
+	}
+	return nil
+}
+
+// IdleTimeoutConfig ends sessions nobody used for a while, even when their tokens are still valid
+type IdleTimeoutConfig struct {
+	Default       time.Duration            `yaml:"default"`       // 0 disables the timeout for account types not listed
+	AccountTypes  map[string]time.Duration `yaml:"accountTypes"`  // account_type -> timeout, 0 disables it for that type
+	TouchInterval time.Duration            `yaml:"touchInterval"` // last activity is written at most this often
+}
+
+func (c IdleTimeoutConfig) WithDefaults() IdleTimeoutConfig {
+	if c.TouchInterval <= 0 {
+		c.TouchInterval = time.Minute
+	}
+	return c
+}
+
+// For returns the idle timeout of accountType, 0 when sessions of that type never go idle
+func (c IdleTimeoutConfig) For(accountType string) time.Duration {
+	if timeout, ok := c.AccountTypes[accountType]; ok {
+		return timeout
+	}
+	return c.Default
+}
+
+// APIKeysConfig decides who manages the API keys of a franchise and how long keys may live
+type APIKeysConfig struct {
+	ManagerAccountTypes []string      `yaml:"managerAccountTypes"` // super admins manage keys of every franchise, the others only of their own
+	MaxTTL              time.Duration `yaml:"maxTTL"`              // 0 allows keys without expiry
+}
+
+func (c APIKeysConfig) WithDefaults() APIKeysConfig {
+	if c.ManagerAccountTypes == nil {
+		c.ManagerAccountTypes = []string{"super_admin", "owner"}
+	}
+	return c
+}
+
+// Manager reports whether accounts of accountType may create, rotate and revoke keys
+func (c APIKeysConfig) Manager(accountType string) bool {
+	for _, t := range c.ManagerAccountTypes {
+		if t == accountType {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientCredentialsConfig registers the services that get tokens through the ClientCredentials RPC
+type ClientCredentialsConfig struct {
+	Clients []OAuthClientConfig `yaml:"clients"`
+	TTL     time.Duration       `yaml:"ttl"` // lifetime of a service token, there is no refresh token
+}
+
+func (c ClientCredentialsConfig) WithDefaults() ClientCredentialsConfig {
+	if c.TTL <= 0 {
+		c.TTL = 15 * time.Minute
+	}
+	return c
+}
+
+// Client returns the registered client with clientID
+func (c ClientCredentialsConfig) Client(clientID string) (OAuthClientConfig, bool) {
+	for _, client := range c.Clients {
+		if client.ClientID == clientID {
+			return client, true
+		}
+	}
+	return OAuthClientConfig{}, false
+}
+
+// LoginOTPConfig controls passwordless login with a code sent to team_accounts.mobile_no
+type LoginOTPConfig struct {
+	AccountTypes      []string      `yaml:"accountTypes"`      // account types allowed to log in with an OTP
+	Digits            int           `yaml:"digits"`            // length of the code
+	TTL               time.Duration `yaml:"ttl"`               // how long a code is valid
+	MaxAttempts       int           `yaml:"maxAttempts"`       // wrong codes before the code is thrown away
+	ResendAfter       time.Duration `yaml:"resendAfter"`       // minimum time between two codes for a mobile number
+	MaxSendsPerMobile int           `yaml:"maxSendsPerMobile"` // codes per mobile number inside SendWindow
+	MaxSendsPerIP     int           `yaml:"maxSendsPerIP"`     // codes per client IP inside SendWindow
+	SendWindow        time.Duration `yaml:"sendWindow"`
+}
+
+// WithDefaults fills every unset field, delivery partners and packers can use OTP login by default
+func (c LoginOTPConfig) WithDefaults() LoginOTPConfig {
+	if c.AccountTypes == nil {
+		c.AccountTypes = []string{"delivery_partner", "food_packer"}
+	}
+	if c.Digits <= 0 {
+		c.Digits = 6
+	}
+	if c.TTL <= 0 {
+		c.TTL = 5 * time.Minute
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.ResendAfter <= 0 {
+		c.ResendAfter = 30 * time.Second
+	}
+	if c.MaxSendsPerMobile <= 0 {
+		c.MaxSendsPerMobile = 5
+	}
+	if c.MaxSendsPerIP <= 0 {
+		c.MaxSendsPerIP = 20
+	}
+	if c.SendWindow <= 0 {
+		c.SendWindow = time.Hour
+	}
+	return c
+}
+
+// Allowed reports whether accounts of accountType may log in with an OTP
+func (c LoginOTPConfig) Allowed(accountType string) bool {
+	for _, t := range c.AccountTypes {
+		if t == accountType {
+			return true
+		}
+	}
+	return false
+}
+
+// LockoutConfig controls brute-force protection on Login.
+// Failures are counted per login_id and per client IP inside Window.
+type LockoutConfig struct {
+	MaxAttempts        int           `yaml:"maxAttempts"`        // failures per login_id before a temporary lockout
+	MaxAttemptsPerIP   int           `yaml:"maxAttemptsPerIP"`   // failures per client IP before a temporary lockout
+	BackoffAfter       int           `yaml:"backoffAfter"`       // failures before every retry has to wait
+	BaseDelay          time.Duration `yaml:"baseDelay"`          // first backoff delay, doubled on every further failure
+	MaxDelay           time.Duration `yaml:"maxDelay"`           // upper bound of the backoff delay
+	LockoutDuration    time.Duration `yaml:"lockoutDuration"`    // how long a temporary lockout lasts
+	Window             time.Duration `yaml:"window"`             // counters are forgotten after this long without a failure
+	PermanentLockAfter int           `yaml:"permanentLockAfter"` // temporary lockouts before team_accounts.status is set to locked, 0 disables
+}
+
+// WithDefaults fills every unset field, so a config without a lockout section is still protected
+func (c LockoutConfig) WithDefaults() LockoutConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.MaxAttemptsPerIP <= 0 {
+		c.MaxAttemptsPerIP = 20
+	}
+	if c.BackoffAfter <= 0 {
+		c.BackoffAfter = 3
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = time.Second
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = 30 * time.Second
+	}
+	if c.LockoutDuration <= 0 {
+		c.LockoutDuration = 15 * time.Minute
+	}
+	if c.Window <= 0 {
+		c.Window = time.Hour
+	}
+	return c
+}
+
+// MFAConfig decides which accounts need a TOTP code on login.
+// Accounts that enrolled on their own are always asked for a code.
+type MFAConfig struct {
+	Issuer               string              `yaml:"issuer"`               // shown in authenticator apps
+	ChallengeTTL         time.Duration       `yaml:"challengeTTL"`         // how long the mfa_token returned by Login is valid
+	MaxAttempts          int                 `yaml:"maxAttempts"`          // wrong codes before the mfa_token is dropped
+	RequiredAccountTypes []string            `yaml:"requiredAccountTypes"` // account types that must use MFA in every franchise
+	Franchises           map[string][]string `yaml:"franchises"`           // franchise_id -> account types, replaces requiredAccountTypes for that franchise
+}
+
+// WithDefaults fills every unset field, super admins and owners need MFA unless configured otherwise
+func (c MFAConfig) WithDefaults() MFAConfig {
+	if c.Issuer == "" {
+		c.Issuer = "ZRMS"
+	}
+	if c.ChallengeTTL <= 0 {
+		c.ChallengeTTL = 5 * time.Minute
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.RequiredAccountTypes == nil {
+		c.RequiredAccountTypes = []string{"super_admin", "owner"}
+	}
+	return c
+}
+
+// Required reports whether accounts of accountType in franchiseID must use MFA
+func (c MFAConfig) Required(franchiseID, accountType string) bool {
+	accountTypes, ok := c.Franchises[franchiseID]
+	if !ok {
+		accountTypes = c.RequiredAccountTypes
+	}
+	for _, t := range accountTypes {
+		if t == accountType {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadConfig reads the YAML config file and unmarshals it into a Config struct
@@ -29,10 +383,14 @@ func LoadConfig(path string) (*AppConfig, error) {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	var cfg AppConfig
+	// rules missing from the file keep the policy shared with the account service
+	cfg := AppConfig{PasswordPolicy: passwordpolicy.Default(), PasswordHash: passwordhash.Default()}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("error parsing YAML config: %w", err)
 	}
+	if err := cfg.Gateway.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestGatewayConfigValidate(t *testing.T) {
+	cases := map[string]struct {
+		cors    CORSConfig
+		wantErr bool
+	}{
+		"listed origins with credentials": {cors: CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}},
+		"any origin without credentials":  {cors: CORSConfig{AllowedOrigins: []string{"*"}}},
+		"any origin with credentials":     {cors: CORSConfig{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}, wantErr: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := GatewayConfig{CORS: tc.cors}.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
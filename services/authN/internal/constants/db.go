@@ -8,6 +8,11 @@ var DB = struct {
 	Table_Role               string
 
 	Table_Franchise_Accounts string
+	Table_Franchises         string
+	Table_Franchise_Owners   string
+	Table_Password_History   string
+	Table_Account_MFA        string
+	Table_API_Keys           string
 
 	Table_Roles            string
 	Table_Document_Types   string
@@ -19,6 +24,11 @@ var DB = struct {
 	Table_Permissions:        "permissions",
 
 	Table_Franchise_Accounts: "team_accounts",
+	Table_Franchises:         "franchises",
+	Table_Franchise_Owners:   "franchise_owners",
+	Table_Password_History:   "password_history",
+	Table_Account_MFA:        "account_mfa",
+	Table_API_Keys:           "api_keys",
 
 	Table_Roles:            "roles",
 	Table_Document_Types:   "document_types",
@@ -5,10 +5,14 @@ var EnvVariable = struct {
 	IN_MEMORY_STORE_TYPE string
 	ACCESS_TOKEN_TTL     string
 	REFRESH_TOKEN_TTL    string
+	JWT_ISSUER           string
+	JWT_AUDIENCE         string
 }{
 	IN_MEMORY_STORE_TYPE: "type",
 	ACCESS_TOKEN_TTL:     "ACCESS_TOKEN_TTL",
 	REFRESH_TOKEN_TTL:    "REFRESH_TOKEN_TTL",
+	JWT_ISSUER:           "JWT_ISSUER",
+	JWT_AUDIENCE:         "JWT_AUDIENCE",
 }
 
 // List of Methods
@@ -29,6 +33,71 @@ var Methods = struct {
 	Validate                    string
 	GetUser                     string
 	GetFranchiseRolePermissions string
+	CreateSession               string
+	GetSession                  string
+	ListSessions                string
+	DeleteSession               string
+	RevokeSession               string
+	RevokeAllSessions           string
+	StoreRefreshRecord          string
+	GetRefreshRecord            string
+	ClaimRefreshRecord          string
+	RevokeTokenFamily           string
+	DenyToken                   string
+	IsTokenDenied               string
+	RevokeAccountTokens         string
+	GetLoginAttempts            string
+	SaveLoginAttempts           string
+	ResetLoginAttempts          string
+	UpdateAccountStatus         string
+	UnlockAccount               string
+	UpdatePassword              string
+	SaveResetCode               string
+	GetResetCode                string
+	DeleteResetCode             string
+	RequestPasswordReset        string
+	ConfirmPasswordReset        string
+	ChangePassword              string
+	GetUserByID                 string
+	AddPasswordHistory          string
+	GetPasswordHistory          string
+	UpgradePasswordHash         string
+	GetMFA                      string
+	SaveMFA                     string
+	SaveMFAChallenge            string
+	GetMFAChallenge             string
+	DeleteMFAChallenge          string
+	EnrollMFA                   string
+	ConfirmMFA                  string
+	VerifyMFA                   string
+	SaveLoginOTP                string
+	GetLoginOTP                 string
+	DeleteLoginOTP              string
+	RequestLoginOTP             string
+	VerifyLoginOTP              string
+	Discovery                   string
+	Introspect                  string
+	ClientCredentials           string
+	GenerateServiceToken        string
+	BatchCheckAccess            string
+	GetFranchiseStatus          string
+	ListFranchiseAccountIDs     string
+	RevokeFranchiseSessions     string
+	Impersonate                 string
+	GenerateImpersonationToken  string
+	ListMyFranchises            string
+	SwitchFranchise             string
+	ListFranchiseMemberships    string
+	TouchSession                string
+	CreateAPIKey                string
+	ListAPIKeys                 string
+	RotateAPIKey                string
+	RevokeAPIKey                string
+	VerifyAPIKey                string
+	GetAPIKey                   string
+	GetAPIKeyByPrefix           string
+	UpdateAPIKeySecret          string
+	TouchAPIKey                 string
 }{
 	Login:                       "Login",
 	Logout:                      "Logout",
@@ -46,6 +115,71 @@ var Methods = struct {
 	LoadConfig:                  "LoadConfig",
 	GetUser:                     "GetUser",
 	GetFranchiseRolePermissions: "GetFranchiseRolePermissions",
+	CreateSession:               "CreateSession",
+	GetSession:                  "GetSession",
+	ListSessions:                "ListSessions",
+	DeleteSession:               "DeleteSession",
+	RevokeSession:               "RevokeSession",
+	RevokeAllSessions:           "RevokeAllSessions",
+	StoreRefreshRecord:          "StoreRefreshRecord",
+	GetRefreshRecord:            "GetRefreshRecord",
+	ClaimRefreshRecord:          "ClaimRefreshRecord",
+	RevokeTokenFamily:           "RevokeTokenFamily",
+	DenyToken:                   "DenyToken",
+	IsTokenDenied:               "IsTokenDenied",
+	RevokeAccountTokens:         "RevokeAccountTokens",
+	GetLoginAttempts:            "GetLoginAttempts",
+	SaveLoginAttempts:           "SaveLoginAttempts",
+	ResetLoginAttempts:          "ResetLoginAttempts",
+	UpdateAccountStatus:         "UpdateAccountStatus",
+	UnlockAccount:               "UnlockAccount",
+	UpdatePassword:              "UpdatePassword",
+	SaveResetCode:               "SaveResetCode",
+	GetResetCode:                "GetResetCode",
+	DeleteResetCode:             "DeleteResetCode",
+	RequestPasswordReset:        "RequestPasswordReset",
+	ConfirmPasswordReset:        "ConfirmPasswordReset",
+	ChangePassword:              "ChangePassword",
+	GetUserByID:                 "GetUserByID",
+	AddPasswordHistory:          "AddPasswordHistory",
+	GetPasswordHistory:          "GetPasswordHistory",
+	UpgradePasswordHash:         "UpgradePasswordHash",
+	GetMFA:                      "GetMFA",
+	SaveMFA:                     "SaveMFA",
+	SaveMFAChallenge:            "SaveMFAChallenge",
+	GetMFAChallenge:             "GetMFAChallenge",
+	DeleteMFAChallenge:          "DeleteMFAChallenge",
+	EnrollMFA:                   "EnrollMFA",
+	ConfirmMFA:                  "ConfirmMFA",
+	VerifyMFA:                   "VerifyMFA",
+	SaveLoginOTP:                "SaveLoginOTP",
+	GetLoginOTP:                 "GetLoginOTP",
+	DeleteLoginOTP:              "DeleteLoginOTP",
+	RequestLoginOTP:             "RequestLoginOTP",
+	VerifyLoginOTP:              "VerifyLoginOTP",
+	Discovery:                   "Discovery",
+	Introspect:                  "Introspect",
+	ClientCredentials:           "ClientCredentials",
+	GenerateServiceToken:        "GenerateServiceToken",
+	BatchCheckAccess:            "BatchCheckAccess",
+	GetFranchiseStatus:          "GetFranchiseStatus",
+	ListFranchiseAccountIDs:     "ListFranchiseAccountIDs",
+	RevokeFranchiseSessions:     "RevokeFranchiseSessions",
+	Impersonate:                 "Impersonate",
+	GenerateImpersonationToken:  "GenerateImpersonationToken",
+	ListMyFranchises:            "ListMyFranchises",
+	SwitchFranchise:             "SwitchFranchise",
+	ListFranchiseMemberships:    "ListFranchiseMemberships",
+	TouchSession:                "TouchSession",
+	CreateAPIKey:                "CreateAPIKey",
+	ListAPIKeys:                 "ListAPIKeys",
+	RotateAPIKey:                "RotateAPIKey",
+	RevokeAPIKey:                "RevokeAPIKey",
+	VerifyAPIKey:                "VerifyAPIKey",
+	GetAPIKey:                   "GetAPIKey",
+	GetAPIKeyByPrefix:           "GetAPIKeyByPrefix",
+	UpdateAPIKeySecret:          "UpdateAPIKeySecret",
+	TouchAPIKey:                 "TouchAPIKey",
 }
 
 const (
@@ -84,6 +218,225 @@ const (
 	GenerateRefreshToken      = "generate refresh token"
 	Repository                = "Repository"
 
+	// Session Messages
+	SessionCreated        = "session created successfully"
+	SessionNotFound       = "session not found"
+	SessionRevoked        = "session revoked successfully"
+	AllSessionsRevoked    = "all sessions revoked successfully"
+	FailedToStoreSession  = "failed to store session in in_memory_DB"
+	FailedToFetchSession  = "failed to fetch session from in_memory_DB"
+	FailedToDeleteSession = "failed to delete session from in_memory_DB"
+	FailedToTouchSession  = "failed to record session activity"
+	SessionIdleExpired    = "session expired after inactivity"
+	EventSessionIdle      = "session_idle_expired"
+	FailedToDecodeSession = "failed to decode session"
+	AccountIDRequired     = "account id required"
+	SessionIDRequired     = "session id required"
+	SessionTokenMismatch  = "refresh token does not belong to an active session"
+	SessionAccessDenied   = "only the account itself or a super admin can manage its sessions"
+	EventSessionDenied    = "session_access_denied"
+
+	// Refresh Token Family Messages
+	RefreshTokenReuseDetected = "refresh token reuse detected, token family revoked"
+	RefreshRecordNotFound     = "refresh token not found in its family"
+	RefreshRecordClaimed      = "refresh token already used"
+	FailedToStoreRshRecord    = "failed to store refresh token record in in_memory_DB"
+	FailedToRevokeFamily      = "failed to revoke refresh token family"
+	TokenFamilyRevoked        = "refresh token family revoked"
+	SecurityEvent             = "security_event"
+	EventRefreshTokenReuse    = "refresh_token_reuse"
+
+	// Token Revocation Messages
+	AuthTokenRevoked      = "token has been revoked"
+	TokenDenied           = "token added to denylist"
+	FailedToDenyToken     = "failed to add token to denylist"
+	FailedToCheckDenylist = "failed to check token denylist"
+	AccountTokensRevoked  = "all tokens of account revoked"
+	FailedToRevokeAccount = "failed to revoke tokens of account"
+	EventAccountRevoked   = "account_tokens_revoked"
+	AdminAccessDenied     = "requires a super admin access token or a service token with the required scope"
+	ServiceAccessDenied   = "requires a service token with the required scope"
+	EventAdminDenied      = "admin_access_denied"
+
+	// Lockout Messages
+	LoginThrottled         = "too many failed login attempts, retry after %s"
+	LoginTemporarilyLocked = "account temporarily locked after repeated failed logins, retry after %s"
+	AccountLocked          = "account is locked, contact an administrator"
+	AccountLockedPermanent = "account locked after repeated lockouts"
+	AccountUnlocked        = "account unlocked"
+	LoginIDRequired        = "login id required"
+	AccountTypeRequired    = "account type required"
+	FailedToFetchAttempts  = "failed to fetch login attempts from in_memory_DB"
+	FailedToStoreAttempts  = "failed to store login attempts in in_memory_DB"
+	FailedToUnlockAccount  = "failed to unlock account"
+	FailedToUpdateStatus   = "failed to update account status"
+	EventLoginLockout      = "login_lockout"
+	EventAccountLocked     = "account_locked"
+	EventAccountUnlocked   = "account_unlocked"
+
+	// Password Reset Messages
+	ResetCodeNotFound        = "password reset code not found"
+	ResetCodeInvalid         = "invalid or expired password reset code"
+	ResetCodeRequired        = "password reset code required"
+	ResetCodeSubject         = "Your ZRMS password reset code"
+	ResetCodeBody            = "Your password reset code is %s. It expires in %d minutes. If you did not ask for it, contact your manager."
+	ResetCodeIssued          = "password reset code issued"
+	ResetCodeResendTooSoon   = "password reset code requested again too soon, not resent"
+	PasswordResetSuccessful  = "password reset successfully"
+	NewPasswordRequired      = "new password required"
+	FailedToStoreResetCode   = "failed to store password reset code in in_memory_DB"
+	FailedToFetchResetCode   = "failed to fetch password reset code from in_memory_DB"
+	FailedToSendResetCode    = "failed to send password reset code"
+	FailedToResetPassword    = "failed to reset password"
+	FailedToHashPassword     = "failed to hash password"
+	EventPasswordResetIssued = "password_reset_requested"
+	EventPasswordReset       = "password_reset"
+
+	// Change Password Messages
+	OldPasswordRequired     = "old password required"
+	OldPasswordIncorrect    = "old password is incorrect"
+	PasswordChanged         = "password changed successfully"
+	FailedToChangePassword  = "failed to change password"
+	FailedToFetchPwdHistory = "failed to fetch password history"
+	FailedToStorePwdHistory = "failed to store password history"
+	EventPasswordChanged    = "password_changed"
+
+	// Password Hash Messages
+	InvalidPasswordHash  = "stored password hash can't be read"
+	FailedToUpgradeHash  = "failed to store upgraded password hash"
+	PasswordHashUpgraded = "password hash upgraded to the configured algorithm"
+
+	// MFA Messages
+	MFANotEnrolled         = "mfa is not enrolled"
+	MFAAlreadyEnrolled     = "mfa is already enrolled"
+	MFAEnrollmentRequired  = "mfa enrollment required before login"
+	MFAChallengeIssued     = "mfa required, challenge issued"
+	MFAChallengeInvalid    = "invalid or expired mfa token"
+	MFACodeRequired        = "mfa code required"
+	MFATokenRequired       = "mfa token required"
+	MFACodeInvalid         = "invalid mfa code"
+	MFAEnrolled            = "mfa enrollment started"
+	MFAConfirmed           = "mfa enrollment confirmed"
+	MFAVerified            = "mfa verified, login completed"
+	MFARecoveryCodeUsed    = "mfa recovery code used"
+	MFANotAllowed          = "only account access tokens can enroll mfa"
+	FailedToFetchMFA       = "failed to fetch mfa enrollment"
+	FailedToStoreMFA       = "failed to store mfa enrollment"
+	FailedToStoreChallenge = "failed to store mfa challenge in in_memory_DB"
+	FailedToFetchChallenge = "failed to fetch mfa challenge from in_memory_DB"
+	FailedToEnrollMFA      = "failed to enroll mfa"
+	EventMFAEnrolled       = "mfa_enrolled"
+	EventMFAFailed         = "mfa_failed"
+	EventRecoveryCodeUsed  = "mfa_recovery_code_used"
+
+	// Login OTP Messages
+	LoginOTPNotFound      = "login otp not found"
+	LoginOTPInvalid       = "invalid or expired login otp"
+	LoginOTPRequired      = "login otp required"
+	LoginOTPBody          = "Your ZRMS login code is %s. It expires in %d minutes. Never share it with anyone."
+	LoginOTPSent          = "login otp sent"
+	LoginOTPNotAllowed    = "otp login is not enabled for this account type"
+	LoginOTPResendTooSoon = "login otp requested again too soon, retry after %s"
+	LoginOTPRateLimited   = "too many login otps requested, retry after %s"
+	LoginOTPVerified      = "login otp verified"
+	FailedToSendLoginOTP  = "failed to send login otp"
+	FailedToStoreLoginOTP = "failed to store login otp in in_memory_DB"
+	FailedToFetchLoginOTP = "failed to fetch login otp from in_memory_DB"
+	EventLoginOTPFailed   = "login_otp_failed"
+
+	// OIDC Messages
+	IntrospectionUnauthorized = "introspection client authentication failed"
+	IntrospectionTokenMissing = "token parameter required"
+	IntrospectionMethod       = "introspection requires POST"
+	TokenIntrospected         = "token introspected"
+	EventIntrospectionDenied  = "introspection_client_denied"
+
+	// Gateway Messages
+	GatewayMethodNotAllowed = "method not allowed"
+	GatewayBodyTooLarge     = "request body too large"
+	GatewayInvalidJSON      = "request body is not valid JSON"
+	GatewayOriginDenied     = "origin not allowed"
+
+	// Client Credentials Messages
+	ClientIDRequired          = "client_id required"
+	ClientSecretRequired      = "client_secret required"
+	InvalidClientCredentials  = "invalid client credentials"
+	ScopeNotGranted           = "scope %q not granted to client"
+	ServiceTokenIssued        = "service token issued"
+	FailedToIssueServiceToken = "failed to issue service token"
+	WrongTokenType            = "token type %q not accepted here"
+	EventClientAuthFailed     = "client_auth_failed"
+
+	// Token Permissions Messages
+	PermissionsNotEmbedded = "effective permissions not embedded in access token"
+
+	// Account and Franchise Lifecycle Messages
+	AccountInactive           = "account is inactive"
+	AccountSuspended          = "account is suspended"
+	AccountDeleted            = "account has been deleted"
+	FranchiseInactive         = "franchise is inactive"
+	FranchiseSuspended        = "franchise is suspended"
+	FranchiseDeleted          = "franchise has been deleted"
+	FranchiseNotFound         = "franchise not found"
+	FranchiseIDRequired       = "franchise_id required"
+	FailedToFetchFranchise    = "failed to fetch franchise status"
+	FailedToRevokeFranchise   = "failed to revoke franchise sessions"
+	FranchiseSessionsRevoked  = "all sessions of the franchise revoked"
+	LoginBlocked              = "login blocked by account or franchise status"
+	EventFranchiseRevoked     = "franchise_sessions_revoked"
+	EventLifecycleLoginDenied = "lifecycle_login_denied"
+
+	// Impersonation Messages
+	ImpersonationReasonRequired = "impersonation reason required"
+	TargetAccountIDRequired     = "target_account_id required"
+	ImpersonationNotAllowed     = "only super admins can impersonate"
+	ImpersonationNested         = "an impersonation token can't start another impersonation"
+	ImpersonationOfSuperAdmin   = "super admin accounts can't be impersonated"
+	ImpersonationStarted        = "impersonation started"
+	ImpersonatedRequest         = "request made with an impersonation token"
+	FailedToImpersonate         = "failed to issue impersonation token"
+	RefreshNotAllowed           = "token can't be used to refresh"
+	EventImpersonationStarted   = "impersonation_started"
+	EventImpersonationDenied    = "impersonation_denied"
+
+	// Franchise Switching Messages
+	FranchiseSwitched          = "switched franchise"
+	NotAFranchiseMember        = "no account of the caller in the franchise"
+	AlreadyInFranchise         = "token is already scoped to the franchise"
+	SwitchNotAllowed           = "only account access tokens can switch franchise"
+	FailedToListFranchises     = "failed to list franchises"
+	EventFranchiseSwitchDenied = "franchise_switch_denied"
+
+	// API Key Messages
+	APIKeyRequired          = "api_key required"
+	APIKeyIDRequired        = "key_id required"
+	APIKeyNameRequired      = "api key name required"
+	APIKeyScopesRequired    = "at least one scope required"
+	APIKeyInvalidScope      = "scope %q isn't a resource:action pair"
+	APIKeyInvalid           = "api key is invalid, expired or revoked"
+	APIKeyNotFound          = "api key not found"
+	APIKeyManageNotAllowed  = "account type can't manage api keys"
+	APIKeyFranchiseMismatch = "api key belongs to another franchise"
+	APIKeyExpiryTooLong     = "api key expiry exceeds the allowed maximum"
+	APIKeyRevokedAlready    = "api key already revoked"
+	APIKeyCreated           = "api key created"
+	APIKeyRotated           = "api key rotated"
+	APIKeyRevoked           = "api key revoked"
+	FailedToCreateAPIKey    = "failed to create api key"
+	FailedToFetchAPIKey     = "failed to fetch api key"
+	FailedToUpdateAPIKey    = "failed to update api key"
+	EventAPIKeyCreated      = "api_key_created"
+	EventAPIKeyRotated      = "api_key_rotated"
+	EventAPIKeyRevoked      = "api_key_revoked"
+	EventAPIKeyRejected     = "api_key_rejected"
+
+	// Notifier Messages
+	NotificationSent         = "notification sent"
+	FailedToSendNotification = "failed to send notification"
+	UnsupportedNotifier      = "unsupported notifier type: %s"
+	UnsupportedSMSProvider   = "unsupported sms provider: %s"
+	SMSFilePathRequired      = "sms.filePath is required by the file sms provider"
+
 	// Config error handling messages
 	ConfigOverride          = "overriding config type with environment variable: %s"
 	FailedToParse           = "failed to parse YAML config"
@@ -94,6 +447,15 @@ const (
 	InvalidConfig                 = "invalid store configuration"
 	FallbackLightning             = "falling back to LightningDB due to missing config"
 	FallbackLightningDueToFailure = "falling back to LightningDB due to store initialization failure"
+	CapacityReached               = "cache capacity reached"
+	ValueExceedsMaxBytes          = "value larger than lightning max_bytes"
+	UnsupportedEvictionPolicy     = "unsupported lightning eviction policy, expected none, lru, lfu or ttl"
+	SnapshotCorrupt               = "lightning snapshot is corrupt"
+	SnapshotVersionUnsupported    = "unsupported lightning snapshot version"
+	SnapshotFailed                = "failed to write lightning snapshot"
+	SnapshotLoadFailed            = "failed to load lightning snapshot, starting empty"
+	SnapshotWritten               = "lightning snapshot written"
+	SnapshotLoaded                = "lightning snapshot loaded"
 
 	// Logger Info
 	LoginAttempt  = "login attempt"
@@ -118,7 +480,9 @@ const (
 	DBRecordNotFound            = "record not found"
 	ErrUnsupportedDatabase      = "unsupported database"
 	ErrKeyNotFound              = "key not found"
+	ErrKeyAlreadyExists         = "key already exists"
 	ErrInvalidConfig            = "invalid config"
+	ErrKeysNotSupported         = "key pattern matching not supported"
 	DBConnectionNil             = "database connection is nil"
 	FailedToRetrv               = "failed to retrive data from last query performed"
 	BuildInsertQuery            = "something went wrong inside query insert builder function"
@@ -162,16 +526,78 @@ const (
 	// Token layer
 	ErrInvalidToken            = "invalid token"
 	ErrUnexpectedSigningMethod = "unexpected signing method"
+	ErrUnknownKeyID            = "unknown signing key id: %s"
+	ErrSigningAlgMismatch      = "signing key %s is %s but jwtHeader.alg is %s"
+	KeyringLoaded              = "signing keyring loaded"
 
 	// in memory DB Type
-	TypeKey       = ""
-	RedisType     = "redis"
-	MemcachedType = "memcached"
-	DragonflyType = "dragonfly"
-	BadgerType    = "badger"
-	LightningType = "lightning"
-	Access_token  = "access_token"
-	Refresh_token = "refresh_token"
+	TypeKey          = ""
+	RedisType        = "redis"
+	MemcachedType    = "memcached"
+	DragonflyType    = "dragonfly"
+	BadgerType       = "badger"
+	LightningType    = "lightning"
+	EvictionNone     = "none"
+	EvictionLRU      = "lru"
+	EvictionLFU      = "lfu"
+	EvictionTTL      = "ttl"
+	Access_token     = "access_token"
+	Refresh_token    = "refresh_token"
+	Session_key      = "session"
+	Family_key       = "refresh_family"
+	Claim_key        = "refresh_claim"
+	Denylist_key     = "denylist"
+	Revoked_key      = "revoked_before"
+	Attempts_key     = "login_attempts"
+	Reset_key        = "password_reset"
+	MFAChallenge_key = "mfa_challenge"
+	LoginOTP_key     = "login_otp"
+
+	// notifier types and channels
+	LogNotifierType = "log"
+	ChannelSMS      = "sms"
+	ChannelEmail    = "email"
+
+	// sms providers
+	ConsoleSMSProvider = "console"
+	FileSMSProvider    = "file"
+
+	// token_type claim values
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeService = "service"
+	TokenTypeAPIKey  = "api_key"
+
+	// scope of the service token authN uses to call AuthZ
+	ScopeAuthZCheck = "authz:check"
+
+	// scopes of the service tokens that may call RevokeAccountTokens and RevokeFranchiseSessions
+	ScopeRevokeAccountTokens     = "authn:revoke_account_tokens"
+	ScopeRevokeFranchiseSessions = "authn:revoke_franchise_sessions"
+
+	// login attempt scopes
+	AttemptScopeLogin = "login_id"
+	AttemptScopeIP    = "ip"
+	// OTP send counters
+	AttemptScopeOTPMobile = "otp_mobile"
+	AttemptScopeOTPIP     = "otp_ip"
+
+	// team_accounts.status values
+	AccountStatusActive    = "active"
+	AccountStatusLocked    = "locked"
+	AccountStatusInactive  = "inactive"
+	AccountStatusSuspended = "suspended"
+
+	// team_accounts.account_type values with special handling
+	AccountTypeSuperAdmin = "super_admin"
+
+	// franchises.status values
+	FranchiseStatusActive    = "active"
+	FranchiseStatusInactive  = "inactive"
+	FranchiseStatusSuspended = "suspended"
+
+	// revoked_before marker shared by every account of a franchise
+	FranchiseRevocationPrefix = "franchise"
 
 	InvalidRedisConfig     = "invalid redis config"
 	InvalidMemcachedConfig = "invalid memcached config"
@@ -0,0 +1,380 @@
+// Package gateway serves Login, RefreshToken, Logout and VerifyToken as JSON over HTTP for
+// browsers and mobile apps. Every call goes through the same service methods as gRPC.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/helper"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const maxBodyBytes = 64 << 10
+
+// AuthService is the part of service.AuthService the gateway exposes
+type AuthService interface {
+	Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error)
+	RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginResponse, error)
+	Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error)
+	VerifyAccessToken(ctx context.Context, req *pb.VerifyTokenRequest) (*pb.AuthClaims, error)
+}
+
+type Handler struct {
+	service AuthService
+	cfg     config.GatewayConfig
+	proxies helper.TrustedProxies
+	mux     *http.ServeMux
+}
+
+func NewHandler(service AuthService, cfg config.GatewayConfig, proxies helper.TrustedProxies) *Handler {
+	h := &Handler{
+		service: service,
+		cfg:     cfg.WithDefaults(),
+		proxies: proxies,
+		mux:     http.NewServeMux(),
+	}
+	h.mux.HandleFunc(h.cfg.BasePath+"/login", h.Login)
+	h.mux.HandleFunc(h.cfg.BasePath+"/refresh", h.Refresh)
+	h.mux.HandleFunc(h.cfg.BasePath+"/logout", h.Logout)
+	h.mux.HandleFunc(h.cfg.BasePath+"/verify", h.Verify)
+	return h
+}
+
+// Pattern is what the handler has to be mounted on
+func (h *Handler) Pattern() string {
+	return h.cfg.BasePath + "/"
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.cors(w, r) {
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// Login answers like the Login RPC, in cookie mode the refresh token is only set as a cookie
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	req := &pb.LoginRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.GetLoginId() == "" || req.GetPassword() == "" || req.GetAccountType() == "" {
+		writeError(w, status.Error(codes.InvalidArgument, constants.ValidationMissingCredentials), http.StatusBadRequest)
+		return
+	}
+	res, err := h.service.Login(h.clientContext(r), req)
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+	h.writeTokens(w, res)
+}
+
+// Refresh takes the refresh token from the body or, in cookie mode, from the cookie
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	req := &pb.RefreshTokenRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.GetRefreshToken() == "" {
+		req.RefreshToken = h.refreshCookie(r)
+	}
+	if req.GetRefreshToken() == "" {
+		writeError(w, status.Error(codes.InvalidArgument, constants.AuthRefreshRequired), http.StatusBadRequest)
+		return
+	}
+	res, err := h.service.RefreshToken(h.clientContext(r), req)
+	if err != nil {
+		// a refresh token that stopped working is of no use in the browser either, one that
+		// only met a failing store or a timeout still is
+		switch status.Code(err) {
+		case codes.Unauthenticated, codes.PermissionDenied:
+			h.clearRefreshCookie(w)
+		}
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	h.writeTokens(w, res)
+}
+
+// Logout accepts the access token as a bearer token and always clears the refresh cookie
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	req := &pb.LogoutRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.GetRefreshToken() == "" {
+		req.RefreshToken = h.refreshCookie(r)
+	}
+	if req.GetAccessToken() == "" {
+		req.AccessToken = bearerToken(r)
+	}
+	h.clearRefreshCookie(w)
+	if req.GetRefreshToken() == "" {
+		writeError(w, status.Error(codes.InvalidArgument, constants.AuthRefreshRequired), http.StatusBadRequest)
+		return
+	}
+	res, err := h.service.Logout(h.clientContext(r), req)
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+	writeProto(w, http.StatusOK, res)
+}
+
+// Verify returns the claims of the bearer token, or of access_token in a POST body
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	req := &pb.VerifyTokenRequest{}
+	if r.Method == http.MethodPost && !decode(w, r, req) {
+		return
+	}
+	if req.GetAccessToken() == "" {
+		req.AccessToken = bearerToken(r)
+	}
+	if req.GetAccessToken() == "" {
+		writeError(w, status.Error(codes.InvalidArgument, constants.AuthAccessRequired), http.StatusBadRequest)
+		return
+	}
+	claims, err := h.service.VerifyAccessToken(h.clientContext(r), req)
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+	writeProto(w, http.StatusOK, claims)
+}
+
+func (h *Handler) writeTokens(w http.ResponseWriter, res *pb.LoginResponse) {
+	if h.cfg.RefreshCookie.Enabled && res.GetRefreshToken() != "" {
+		h.setRefreshCookie(w, res.GetRefreshToken())
+		res = proto.Clone(res).(*pb.LoginResponse)
+		res.RefreshToken = ""
+	}
+	writeProto(w, http.StatusOK, res)
+}
+
+// clientContext hands the service the same client details a gRPC call carries, the client the
+// trusted proxies name is the peer, lockout, rate limits and sessions see it exactly like a gRPC one
+func (h *Handler) clientContext(r *http.Request) context.Context {
+	md := metadata.Pairs("user-agent", r.UserAgent())
+	if label := r.Header.Get("X-Device-Label"); label != "" {
+		md.Set("x-device-label", label)
+	}
+	client := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(helper.RemoteIP(r, h.proxies))}}
+	return peer.NewContext(metadata.NewIncomingContext(r.Context(), md), client)
+}
+
+func bearerToken(r *http.Request) string {
+	parts := strings.Fields(r.Header.Get("Authorization"))
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return ""
+	}
+	return parts[1]
+}
+
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, errorBody{Error: "method_not_allowed", Message: constants.GatewayMethodNotAllowed})
+	return false
+}
+
+// decode reads a JSON body into req, field names are the proto names (login_id) or their camelCase form
+func decode(w http.ResponseWriter, r *http.Request, req proto.Message) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorBody{Error: "invalid_request", Message: constants.GatewayBodyTooLarge})
+		return false
+	}
+	if len(body) == 0 {
+		return true
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "invalid_request", Message: constants.GatewayInvalidJSON})
+		return false
+	}
+	return true
+}
+
+// cors answers preflight requests itself and reports whether the request is done
+func (h *Handler) cors(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if origin == "" {
+		return false
+	}
+	w.Header().Add("Vary", "Origin")
+	if !h.originAllowed(origin) {
+		if preflight {
+			writeJSON(w, http.StatusForbidden, errorBody{Error: "origin_not_allowed", Message: constants.GatewayOriginDenied})
+			return true
+		}
+		// without the CORS headers the browser keeps the response from the page
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if h.cfg.CORS.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Device-Label")
+	w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(h.cfg.CORS.MaxAge.Seconds())))
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func (h *Handler) originAllowed(origin string) bool {
+	for _, allowed := range h.cfg.CORS.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) refreshCookie(r *http.Request) string {
+	if !h.cfg.RefreshCookie.Enabled {
+		return ""
+	}
+	cookie, err := r.Cookie(h.cfg.RefreshCookie.Name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func (h *Handler) setRefreshCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, h.newCookie(token, int(h.cfg.RefreshCookie.MaxAge.Seconds())))
+}
+
+func (h *Handler) clearRefreshCookie(w http.ResponseWriter) {
+	if h.cfg.RefreshCookie.Enabled {
+		http.SetCookie(w, h.newCookie("", -1))
+	}
+}
+
+func (h *Handler) newCookie(value string, maxAge int) *http.Cookie {
+	c := h.cfg.RefreshCookie
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   maxAge,
+		Secure:   c.Secure,
+		HttpOnly: true,
+		SameSite: sameSite(c.SameSite),
+	}
+}
+
+func sameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+type errorBody struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// writeError answers with the HTTP status grpc-gateway uses for the gRPC code. Errors without
+// a gRPC status are answered with fallback, the service only returns those for bad credentials.
+func writeError(w http.ResponseWriter, err error, fallback int) {
+	st, ok := status.FromError(err)
+	if !ok {
+		writeJSON(w, fallback, errorBody{Error: strings.ToLower(http.StatusText(fallback)), Message: err.Error()})
+		return
+	}
+	code := httpStatus(st.Code())
+	if code == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "60")
+	}
+	writeJSON(w, code, errorBody{Error: strings.ToLower(st.Code().String()), Message: st.Message()})
+}
+
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeProto(w http.ResponseWriter, code int, msg proto.Message) {
+	body, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		writeError(w, status.Error(codes.Internal, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
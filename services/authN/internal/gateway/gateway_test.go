@@ -0,0 +1,212 @@
+package gateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/gateway"
+	"github.com/ashish19912009/zrms/services/authN/internal/helper"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeService struct {
+	lastIP      string
+	lastRefresh string
+}
+
+func (f *fakeService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	f.lastIP = helper.GetClientInfo(ctx, nil).IPAddress
+	if req.GetPassword() != "secret" {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return &pb.LoginResponse{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+func (f *fakeService) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginResponse, error) {
+	f.lastRefresh = req.GetRefreshToken()
+	if req.GetRefreshToken() == "store-down" {
+		return nil, status.Error(codes.Internal, "session store unavailable")
+	}
+	if req.GetRefreshToken() != "refresh" {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	return &pb.LoginResponse{AccessToken: "access2", RefreshToken: "refresh2"}, nil
+}
+
+func (f *fakeService) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	f.lastRefresh = req.GetRefreshToken()
+	return &pb.LogoutResponse{Success: true}, nil
+}
+
+func (f *fakeService) VerifyAccessToken(ctx context.Context, req *pb.VerifyTokenRequest) (*pb.AuthClaims, error) {
+	if req.GetAccessToken() != "access" {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return &pb.AuthClaims{AccountType: "owner", FranchiseId: "F1"}, nil
+}
+
+// requests of httptest come from 192.0.2.1, the handler trusts it as a proxy
+func newHandler(svc *fakeService, cookie bool) *gateway.Handler {
+	proxies, _ := helper.ParseTrustedProxies([]string{"192.0.2.1"})
+	return gateway.NewHandler(svc, config.GatewayConfig{
+		RefreshCookie: config.RefreshCookieConfig{Enabled: cookie, Secure: true},
+		CORS:          config.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true},
+	}, proxies)
+}
+
+func do(t *testing.T, h http.Handler, req *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return rec, body
+}
+
+func TestLogin(t *testing.T) {
+	svc := &fakeService{}
+	h := newHandler(svc, false)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"login_id":"u1","password":"secret","account_type":"owner"}`))
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	rec, body := do(t, h, req)
+	if rec.Code != http.StatusOK || body["access_token"] != "access" || body["refresh_token"] != "refresh" {
+		t.Fatalf("unexpected response %d %v", rec.Code, body)
+	}
+	if svc.lastIP != "203.0.113.7" {
+		t.Fatalf("client ip not passed to the service, got %q", svc.lastIP)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"login_id":"u1","password":"wrong","account_type":"owner"}`))
+	if rec, body = do(t, h, req); rec.Code != http.StatusUnauthorized || body["error"] != "unauthenticated" {
+		t.Fatalf("expected 401, got %d %v", rec.Code, body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"login_id":"u1"}`))
+	if rec, _ = do(t, h, req); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing fields, got %d", rec.Code)
+	}
+
+	if rec, _ = do(t, h, httptest.NewRequest(http.MethodGet, "/auth/login", nil)); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestLoginIgnoresForwardedForOfUntrustedClients(t *testing.T) {
+	svc := &fakeService{}
+	h := gateway.NewHandler(svc, config.GatewayConfig{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"login_id":"u1","password":"secret","account_type":"owner"}`))
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Real-IP", "203.0.113.8")
+	if rec, body := do(t, h, req); rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %v", rec.Code, body)
+	}
+	if svc.lastIP != "192.0.2.1" {
+		t.Fatalf("expected the connection address, got %q", svc.lastIP)
+	}
+}
+
+func TestRefreshCookieMode(t *testing.T) {
+	svc := &fakeService{}
+	h := newHandler(svc, true)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"loginId":"u1","password":"secret","accountType":"owner"}`))
+	rec, body := do(t, h, req)
+	if rec.Code != http.StatusOK || body["refresh_token"] != nil {
+		t.Fatalf("refresh token must not be in the body in cookie mode: %d %v", rec.Code, body)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != "refresh" || !cookies[0].HttpOnly || !cookies[0].Secure ||
+		cookies[0].SameSite != http.SameSiteStrictMode || cookies[0].Path != "/auth" {
+		t.Fatalf("unexpected cookie %+v", cookies)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+	req.AddCookie(cookies[0])
+	if rec, body = do(t, h, req); rec.Code != http.StatusOK || body["access_token"] != "access2" || svc.lastRefresh != "refresh" {
+		t.Fatalf("refresh from cookie failed: %d %v", rec.Code, body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "zrms_refresh", Value: "refresh2"})
+	rec, _ = do(t, h, req)
+	cleared := rec.Result().Cookies()
+	if rec.Code != http.StatusOK || svc.lastRefresh != "refresh2" || len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Fatalf("logout should revoke and clear the cookie: %d %+v", rec.Code, cleared)
+	}
+}
+
+func TestRefreshCookieClearedOnlyForRejectedTokens(t *testing.T) {
+	h := newHandler(&fakeService{}, true)
+	cases := map[string]struct {
+		token       string
+		wantCode    int
+		wantCleared bool
+	}{
+		"rejected token": {token: "revoked", wantCode: http.StatusUnauthorized, wantCleared: true},
+		"failing store":  {token: "store-down", wantCode: http.StatusInternalServerError},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: "zrms_refresh", Value: tc.token})
+			rec, _ := do(t, h, req)
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected %d, got %d", tc.wantCode, rec.Code)
+			}
+			if cleared := len(rec.Result().Cookies()) == 1; cleared != tc.wantCleared {
+				t.Fatalf("cookie cleared = %v, want %v", cleared, tc.wantCleared)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	h := newHandler(&fakeService{}, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/verify", nil)
+	req.Header.Set("Authorization", "Bearer access")
+	rec, body := do(t, h, req)
+	if rec.Code != http.StatusOK || body["franchise_id"] != "F1" {
+		t.Fatalf("unexpected response %d %v", rec.Code, body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/auth/verify", nil)
+	req.Header.Set("Authorization", "Bearer expired")
+	if rec, _ = do(t, h, req); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	h := newHandler(&fakeService{}, true)
+
+	req := httptest.NewRequest(http.MethodOptions, "/auth/login", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec, _ := do(t, h, req)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("unexpected preflight response %d %v", rec.Code, rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/auth/login", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	if rec, _ = do(t, h, req); rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected 403 for an unknown origin, got %d", rec.Code)
+	}
+}
@@ -91,3 +91,243 @@ func (h *GRPCHandler) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Lo
 		Success: true,
 	}, nil
 }
+
+func (h *GRPCHandler) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	if req.GetAccountId() == "" {
+		logger.Error(constants.AccountIDRequired, nil, map[string]interface{}{
+			"method": constants.Methods.ListSessions,
+		})
+		return nil, status.Error(codes.InvalidArgument, constants.AccountIDRequired)
+	}
+	return h.authService.ListSessions(ctx, req)
+}
+
+func (h *GRPCHandler) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	if req.GetAccountId() == "" || req.GetSessionId() == "" {
+		logger.Error(constants.SessionIDRequired, nil, map[string]interface{}{
+			"method":     constants.Methods.RevokeSession,
+			"account_id": req.GetAccountId(),
+		})
+		return nil, status.Error(codes.InvalidArgument, constants.SessionIDRequired)
+	}
+	return h.authService.RevokeSession(ctx, req)
+}
+
+func (h *GRPCHandler) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeSessionResponse, error) {
+	if req.GetAccountId() == "" {
+		logger.Error(constants.AccountIDRequired, nil, map[string]interface{}{
+			"method": constants.Methods.RevokeAllSessions,
+		})
+		return nil, status.Error(codes.InvalidArgument, constants.AccountIDRequired)
+	}
+	return h.authService.RevokeAllSessions(ctx, req)
+}
+
+func (h *GRPCHandler) RevokeAccountTokens(ctx context.Context, req *pb.RevokeAccountTokensRequest) (*pb.RevokeSessionResponse, error) {
+	if req.GetAccountId() == "" {
+		logger.Error(constants.AccountIDRequired, nil, map[string]interface{}{
+			"method": constants.Methods.RevokeAccountTokens,
+		})
+		return nil, status.Error(codes.InvalidArgument, constants.AccountIDRequired)
+	}
+	return h.authService.RevokeAccountTokens(ctx, req)
+}
+
+func (h *GRPCHandler) UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.UnlockAccountResponse, error) {
+	if req.GetLoginId() == "" {
+		logger.Error(constants.LoginIDRequired, nil, map[string]interface{}{
+			"method": constants.Methods.UnlockAccount,
+		})
+		return nil, status.Error(codes.InvalidArgument, constants.LoginIDRequired)
+	}
+	if req.GetAccountType() == "" {
+		logger.Error(constants.AccountTypeRequired, nil, map[string]interface{}{
+			"method": constants.Methods.UnlockAccount,
+		})
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	return h.authService.UnlockAccount(ctx, req)
+}
+
+func (h *GRPCHandler) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.PasswordResetResponse, error) {
+	if req.GetLoginId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginIDRequired)
+	}
+	if req.GetAccountType() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	return h.authService.RequestPasswordReset(ctx, req)
+}
+
+func (h *GRPCHandler) ConfirmPasswordReset(ctx context.Context, req *pb.ConfirmPasswordResetRequest) (*pb.PasswordResetResponse, error) {
+	if req.GetLoginId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginIDRequired)
+	}
+	if req.GetAccountType() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.ResetCodeRequired)
+	}
+	if req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.NewPasswordRequired)
+	}
+	return h.authService.ConfirmPasswordReset(ctx, req)
+}
+
+func (h *GRPCHandler) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	if req.GetOldPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.OldPasswordRequired)
+	}
+	if req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.NewPasswordRequired)
+	}
+	return h.authService.ChangePassword(ctx, req)
+}
+
+func (h *GRPCHandler) EnrollMFA(ctx context.Context, req *pb.EnrollMFARequest) (*pb.EnrollMFAResponse, error) {
+	if req.GetAccessToken() == "" && req.GetMfaToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	return h.authService.EnrollMFA(ctx, req)
+}
+
+func (h *GRPCHandler) ConfirmMFA(ctx context.Context, req *pb.ConfirmMFARequest) (*pb.ConfirmMFAResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MFACodeRequired)
+	}
+	return h.authService.ConfirmMFA(ctx, req)
+}
+
+func (h *GRPCHandler) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.LoginResponse, error) {
+	if req.GetMfaToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MFATokenRequired)
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MFACodeRequired)
+	}
+	return h.authService.VerifyMFA(ctx, req)
+}
+
+func (h *GRPCHandler) RequestLoginOTP(ctx context.Context, req *pb.RequestLoginOTPRequest) (*pb.RequestLoginOTPResponse, error) {
+	if req.GetMobileNo() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MobileNoRequired)
+	}
+	if req.GetAccountType() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	return h.authService.RequestLoginOTP(ctx, req)
+}
+
+func (h *GRPCHandler) VerifyLoginOTP(ctx context.Context, req *pb.VerifyLoginOTPRequest) (*pb.LoginResponse, error) {
+	if req.GetMobileNo() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MobileNoRequired)
+	}
+	if req.GetAccountType() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginOTPRequired)
+	}
+	return h.authService.VerifyLoginOTP(ctx, req)
+}
+
+func (h *GRPCHandler) ClientCredentials(ctx context.Context, req *pb.ClientCredentialsRequest) (*pb.ClientCredentialsResponse, error) {
+	if req.GetClientId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.ClientIDRequired)
+	}
+	if req.GetClientSecret() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.ClientSecretRequired)
+	}
+	return h.authService.ClientCredentials(ctx, req)
+}
+
+func (h *GRPCHandler) RevokeFranchiseSessions(ctx context.Context, req *pb.RevokeFranchiseSessionsRequest) (*pb.RevokeSessionResponse, error) {
+	if req.GetFranchiseId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.FranchiseIDRequired)
+	}
+	return h.authService.RevokeFranchiseSessions(ctx, req)
+}
+
+func (h *GRPCHandler) Impersonate(ctx context.Context, req *pb.ImpersonateRequest) (*pb.ImpersonateResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	if req.GetTargetAccountId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.TargetAccountIDRequired)
+	}
+	if req.GetReason() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.ImpersonationReasonRequired)
+	}
+	return h.authService.Impersonate(ctx, req)
+}
+
+func (h *GRPCHandler) ListMyFranchises(ctx context.Context, req *pb.ListMyFranchisesRequest) (*pb.ListMyFranchisesResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	return h.authService.ListMyFranchises(ctx, req)
+}
+
+func (h *GRPCHandler) SwitchFranchise(ctx context.Context, req *pb.SwitchFranchiseRequest) (*pb.LoginResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	if req.GetFranchiseId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.FranchiseIDRequired)
+	}
+	return h.authService.SwitchFranchise(ctx, req)
+}
+
+func (h *GRPCHandler) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyNameRequired)
+	}
+	if len(req.GetScopes()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyScopesRequired)
+	}
+	return h.authService.CreateAPIKey(ctx, req)
+}
+
+func (h *GRPCHandler) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	return h.authService.ListAPIKeys(ctx, req)
+}
+
+func (h *GRPCHandler) RotateAPIKey(ctx context.Context, req *pb.RotateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	if req.GetKeyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyIDRequired)
+	}
+	return h.authService.RotateAPIKey(ctx, req)
+}
+
+func (h *GRPCHandler) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.APIKey, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+	if req.GetKeyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyIDRequired)
+	}
+	return h.authService.RevokeAPIKey(ctx, req)
+}
+
+func (h *GRPCHandler) VerifyAPIKey(ctx context.Context, req *pb.VerifyAPIKeyRequest) (*pb.AuthClaims, error) {
+	if req.GetApiKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyRequired)
+	}
+	return h.authService.VerifyAPIKey(ctx, req)
+}
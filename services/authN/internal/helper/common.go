@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func GetMetadataValue(md metadata.MD, keys ...string) string {
+	for _, key := range keys {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// TrustedProxies are the addresses of the load balancers and proxies in front of the service,
+// only they are believed when they name the client in x-forwarded-for or x-real-ip
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies reads CIDR ranges, a plain IP stands for itself
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains reports whether ip is one of the trusted proxies
+func (p TrustedProxies) Contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP picks the client address of a connection from remoteIP. Only a trusted proxy can name
+// another client: x-forwarded-for is read from the right, skipping the trusted hops, so entries a
+// client put in the header itself are never reached. x-real-ip is used when there is no x-forwarded-for.
+func (p TrustedProxies) ClientIP(remoteIP, forwardedFor, realIP string) string {
+	if !p.Contains(remoteIP) {
+		return remoteIP
+	}
+	if forwardedFor == "" {
+		if ip := strings.TrimSpace(realIP); net.ParseIP(ip) != nil {
+			return ip
+		}
+		return remoteIP
+	}
+	client := remoteIP
+	hops := strings.Split(forwardedFor, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		client = hop
+		if !p.Contains(hop) {
+			break
+		}
+	}
+	return client
+}
+
+// GetClientInfo reads device label, client IP and user agent of a gRPC call. The IP is the peer
+// address unless the peer is one of proxies, see ClientIP.
+func GetClientInfo(ctx context.Context, proxies TrustedProxies) *model.ClientInfo {
+	info := &model.ClientInfo{}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		info.IPAddress = host
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		info.DeviceLabel = GetMetadataValue(md, "x-device-label", "device-label")
+		info.UserAgent = GetMetadataValue(md, "x-user-agent", "user-agent")
+		info.IPAddress = proxies.ClientIP(info.IPAddress, strings.Join(md.Get("x-forwarded-for"), ","), GetMetadataValue(md, "x-real-ip"))
+	}
+	return info
+}
+
+// RemoteIP returns the client address of a plain HTTP request the same way GetClientInfo does
+func RemoteIP(r *http.Request, proxies TrustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return proxies.ClientIP(host, strings.Join(r.Header.Values("X-Forwarded-For"), ","), r.Header.Get("X-Real-IP"))
+}
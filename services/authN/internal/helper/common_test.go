@@ -0,0 +1,91 @@
+package helper
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":        true,
+		"192.0.2.1":       true,
+		"192.0.2.2":       false,
+		"2001:db8::1":     true,
+		"203.0.113.7":     false,
+		"not-an-ip":       false,
+		"":                false,
+		"::ffff:10.0.0.1": true,
+	} {
+		if got := proxies.Contains(ip); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", ip, got, want)
+		}
+	}
+
+	for _, entry := range []string{"10.0.0.0/33", "proxy.internal"} {
+		if _, err := ParseTrustedProxies([]string{entry}); err == nil {
+			t.Errorf("expected %q to be rejected", entry)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, _ := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	cases := map[string]struct {
+		remote, forwardedFor, realIP string
+		want                         string
+	}{
+		"direct client":                 {remote: "203.0.113.7", want: "203.0.113.7"},
+		"untrusted client forges":       {remote: "203.0.113.7", forwardedFor: "198.51.100.1", realIP: "198.51.100.2", want: "203.0.113.7"},
+		"trusted proxy":                 {remote: "10.0.0.1", forwardedFor: "203.0.113.7", want: "203.0.113.7"},
+		"chain of trusted proxies":      {remote: "10.0.0.1", forwardedFor: "203.0.113.7, 10.0.0.2", want: "203.0.113.7"},
+		"client prepends a forged hop":  {remote: "10.0.0.1", forwardedFor: "198.51.100.1, 203.0.113.7", want: "203.0.113.7"},
+		"garbage before the proxy hop":  {remote: "10.0.0.1", forwardedFor: "junk, 10.0.0.2", want: "10.0.0.2"},
+		"x-real-ip of a trusted proxy":  {remote: "10.0.0.1", realIP: "203.0.113.7", want: "203.0.113.7"},
+		"trusted proxy without headers": {remote: "10.0.0.1", want: "10.0.0.1"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := proxies.ClientIP(tc.remote, tc.forwardedFor, tc.realIP); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetClientInfo(t *testing.T) {
+	proxies, _ := ParseTrustedProxies([]string{"10.0.0.1"})
+	md := metadata.Pairs("x-forwarded-for", "203.0.113.7", "user-agent", "test-agent")
+	call := func(peerIP string) context.Context {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(peerIP), Port: 443}})
+	}
+
+	if info := GetClientInfo(call("198.51.100.1"), proxies); info.IPAddress != "198.51.100.1" || info.UserAgent != "test-agent" {
+		t.Fatalf("untrusted peer: got %+v", info)
+	}
+	if info := GetClientInfo(call("10.0.0.1"), proxies); info.IPAddress != "203.0.113.7" {
+		t.Fatalf("trusted peer: got %+v", info)
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	proxies, _ := ParseTrustedProxies([]string{"192.0.2.1"})
+	r := httptest.NewRequest("GET", "/", nil) // RemoteAddr 192.0.2.1:1234
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	if got := RemoteIP(r, proxies); got != "203.0.113.7" {
+		t.Fatalf("trusted proxy: got %q", got)
+	}
+	if got := RemoteIP(r, nil); got != "192.0.2.1" {
+		t.Fatalf("no trusted proxies: got %q", got)
+	}
+}
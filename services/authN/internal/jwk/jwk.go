@@ -1,56 +1,85 @@
 package jwk
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 
+	"github.com/ashish19912009/zrms/services/authN/internal/jwtkeys"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
-var jwkSet jwk.Set
+var (
+	jwkSet jwk.Set
+	mu     sync.RWMutex
+)
 
-// InitializeJWK initializes the JWK Set from a given RSA public key
-func InitializeJWK(pubKey *rsa.PublicKey, keyID, alg, use string) error {
+// InitializeJWK initializes the JWK Set from a given RSA, ECDSA P-256 or Ed25519 public key
+func InitializeJWK(pubKey crypto.PublicKey, keyID, use string) error {
 	if pubKey == nil {
 		return fmt.Errorf("nil public key provided")
 	}
-
-	key, err := jwk.FromRaw(pubKey)
+	alg, err := jwtkeys.KeyAlgorithm(pubKey)
 	if err != nil {
 		return err
 	}
+	return InitializeKeyringJWK([]*jwtkeys.SigningKey{{Kid: keyID, Alg: alg, PublicKey: pubKey}}, use)
+}
 
-	if err := key.Set(jwk.KeyIDKey, keyID); err != nil {
-		return err
+// InitializeKeyringJWK publishes every key of the keyring, so tokens signed with a
+// retired key can still be verified by other services after a rotation.
+// kty and crv come from the key type (RSA, EC/P-256, OKP/Ed25519), alg from the key itself.
+func InitializeKeyringJWK(keys []*jwtkeys.SigningKey, use string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("no public keys provided")
 	}
-	if err := key.Set(jwk.AlgorithmKey, alg); err != nil { // 👈 Set algorithm
-		return err
-	}
-	if err := key.Set(jwk.KeyUsageKey, use); err != nil { // 👈 Set key usage
-		return err
+
+	set := jwk.NewSet()
+	for _, k := range keys {
+		key, err := jwk.FromRaw(k.PublicKey)
+		if err != nil {
+			return err
+		}
+
+		if err := key.Set(jwk.KeyIDKey, k.Kid); err != nil {
+			return err
+		}
+		if err := key.Set(jwk.AlgorithmKey, k.Alg); err != nil { // 👈 Set algorithm
+			return err
+		}
+		if err := key.Set(jwk.KeyUsageKey, use); err != nil { // 👈 Set key usage
+			return err
+		}
+		if err := set.AddKey(key); err != nil {
+			return err
+		}
 	}
 
-	jwkSet = jwk.NewSet()
-	jwkSet.AddKey(key)
+	mu.Lock()
+	jwkSet = set
+	mu.Unlock()
 
 	return nil
 }
 
 // GetJWKSet returns the initialized JWK Set
 func GetJWKSet() jwk.Set {
+	mu.RLock()
+	defer mu.RUnlock()
 	return jwkSet
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
-	if jwkSet == nil {
+	set := GetJWKSet()
+	if set == nil {
 		http.Error(w, "JWK set not initialized", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(jwkSet); err != nil {
+	if err := json.NewEncoder(w).Encode(set); err != nil {
 		http.Error(w, "Failed to encode JWK set", http.StatusInternalServerError)
 	}
 }
@@ -1,6 +1,10 @@
 package jwtkeys
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -11,16 +15,25 @@ import (
 	"github.com/ashish19912009/zrms/services/authN/internal/logger"
 )
 
+// Supported signing algorithms, as written in the JWT "alg" header
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// KeyPair holds an RSA, ECDSA P-256 or Ed25519 key pair
 type KeyPair struct {
-	PrivateKey *rsa.PrivateKey
-	PublicKey  *rsa.PublicKey
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	Alg        string
 }
 
-// LoadKeys loads RSA private and public keys from files.
+// LoadKeys loads private and public keys from files and checks that both halves use the same algorithm.
 func LoadKeys(privateKeyPath, publicKeyPath string) (*KeyPair, error) {
 	privateKey, err := loadPrivateKey(privateKeyPath)
 	if err != nil {
-		logger.Error("Failed to load RSA private key", err, map[string]interface{}{
+		logger.Error("Failed to load private key", err, map[string]interface{}{
 			"path": privateKeyPath,
 		})
 		return nil, err
@@ -28,35 +41,115 @@ func LoadKeys(privateKeyPath, publicKeyPath string) (*KeyPair, error) {
 
 	publicKey, err := loadPublicKey(publicKeyPath)
 	if err != nil {
-		logger.Error("Failed to load RSA public key", err, map[string]interface{}{
+		logger.Error("Failed to load public key", err, map[string]interface{}{
 			"path": publicKeyPath,
 		})
 		return nil, err
 	}
 
+	alg, err := KeyAlgorithm(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if privateAlg, _ := KeyAlgorithm(privateKey.Public()); privateAlg != alg {
+		return nil, fmt.Errorf("private key (%s) and public key (%s) use different algorithms", privateAlg, alg)
+	}
+
 	return &KeyPair{
 		PrivateKey: privateKey,
 		PublicKey:  publicKey,
+		Alg:        alg,
 	}, nil
 }
 
-func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+// KeyAlgorithm returns the JWT algorithm a public key signs with
+func KeyAlgorithm(publicKey crypto.PublicKey) (string, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return AlgRS256, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported ECDSA curve %s, only P-256 is supported", key.Curve.Params().Name)
+		}
+		return AlgES256, nil
+	case ed25519.PublicKey:
+		return AlgEdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// MarshalPrivateKey encodes a private key in the PEM format loadPrivateKey expects
+func MarshalPrivateKey(privateKey crypto.Signer) ([]byte, error) {
+	var block *pem.Block
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	case ed25519.PrivateKey:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// MarshalPublicKey encodes a public key as a PKIX PEM block
+func MarshalPublicKey(publicKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func loadPrivateKey(path string) (crypto.Signer, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read private key file: %w", err)
 	}
 	block, _ := pem.Decode(data)
-	if block == nil || block.Type != "RSA PRIVATE KEY" {
+	if block == nil {
 		return nil, errors.New("invalid or missing PEM block for private key")
 	}
-	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("parse RSA private key: %w", err)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse RSA private key: %w", err)
+		}
+		return key, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse EC private key: %w", err)
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse PKCS8 private key: %w", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("PKCS8 key cannot sign")
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q for private key", block.Type)
 	}
-	return key, nil
 }
 
-func loadPublicKey(path string) (*rsa.PublicKey, error) {
+func loadPublicKey(path string) (crypto.PublicKey, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read public key file: %w", err)
@@ -69,9 +162,8 @@ func loadPublicKey(path string) (*rsa.PublicKey, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse public key: %w", err)
 	}
-	rsaPub, ok := pub.(*rsa.PublicKey)
-	if !ok {
-		return nil, errors.New("not an RSA public key")
+	if _, err := KeyAlgorithm(pub); err != nil {
+		return nil, err
 	}
-	return rsaPub, nil
+	return pub, nil
 }
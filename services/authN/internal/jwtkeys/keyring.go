@@ -0,0 +1,188 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+)
+
+// ManifestFile is the file inside the keyring directory that lists every key and marks the active one
+const ManifestFile = "keyring.json"
+
+// SigningKey is a single key of the keyring. Retired keys only need the public half.
+type SigningKey struct {
+	Kid        string
+	Alg        string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	CreatedAt  time.Time
+	RetiredAt  *time.Time
+}
+
+// Keyring holds the active signing key plus the retired keys still used to verify older tokens
+type Keyring struct {
+	Active string
+	Keys   map[string]*SigningKey
+}
+
+// Manifest is the on-disk description of a keyring directory
+type Manifest struct {
+	Active string          `json:"active"`
+	Keys   []ManifestEntry `json:"keys"`
+}
+
+type ManifestEntry struct {
+	Kid       string     `json:"kid"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// PrivateKeyFile and PublicKeyFile return the PEM file names of a key inside the keyring directory
+func PrivateKeyFile(dir, kid string) string {
+	return filepath.Join(dir, kid+"_private_key.pem")
+}
+
+func PublicKeyFile(dir, kid string) string {
+	return filepath.Join(dir, kid+"_public_key.pem")
+}
+
+// NewSingleKeyring wraps a single key pair, used when no keyring directory is configured
+func NewSingleKeyring(kid string, pair *KeyPair) *Keyring {
+	return &Keyring{
+		Active: kid,
+		Keys: map[string]*SigningKey{
+			kid: {
+				Kid:        kid,
+				Alg:        pair.Alg,
+				PrivateKey: pair.PrivateKey,
+				PublicKey:  pair.PublicKey,
+			},
+		},
+	}
+}
+
+// LoadKeyring reads the manifest of dir and loads every key listed in it
+func LoadKeyring(dir string) (*Keyring, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		logger.Error("Failed to read keyring manifest", err, map[string]interface{}{
+			"dir": dir,
+		})
+		return nil, err
+	}
+
+	ring := &Keyring{
+		Active: manifest.Active,
+		Keys:   make(map[string]*SigningKey, len(manifest.Keys)),
+	}
+	for _, entry := range manifest.Keys {
+		publicKey, err := loadPublicKey(PublicKeyFile(dir, entry.Kid))
+		if err != nil {
+			logger.Error("Failed to load public key", err, map[string]interface{}{
+				"kid": entry.Kid,
+			})
+			return nil, err
+		}
+		alg, err := KeyAlgorithm(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		key := &SigningKey{
+			Kid:       entry.Kid,
+			Alg:       alg,
+			PublicKey: publicKey,
+			CreatedAt: entry.CreatedAt,
+			RetiredAt: entry.RetiredAt,
+		}
+		if entry.Kid == manifest.Active {
+			key.PrivateKey, err = loadPrivateKey(PrivateKeyFile(dir, entry.Kid))
+			if err != nil {
+				logger.Error("Failed to load private key", err, map[string]interface{}{
+					"kid": entry.Kid,
+				})
+				return nil, err
+			}
+		}
+		ring.Keys[entry.Kid] = key
+	}
+
+	if ring.ActiveKey() == nil {
+		return nil, fmt.Errorf("active key %q missing from keyring", manifest.Active)
+	}
+	return ring, nil
+}
+
+// ActiveKey returns the key new tokens are signed with
+func (k *Keyring) ActiveKey() *SigningKey {
+	key, ok := k.Keys[k.Active]
+	if !ok || key.PrivateKey == nil {
+		return nil
+	}
+	return key
+}
+
+// VerificationKey returns the key for a kid, active or retired
+func (k *Keyring) VerificationKey(kid string) (*SigningKey, bool) {
+	key, ok := k.Keys[kid]
+	return key, ok
+}
+
+// PublicKeys returns every key of the ring, newest first
+func (k *Keyring) PublicKeys() []*SigningKey {
+	keys := make([]*SigningKey, 0, len(k.Keys))
+	for _, key := range k.Keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// Algorithms lists the signing algorithms of the published keys, newest key first
+func (k *Keyring) Algorithms() []string {
+	var algs []string
+	seen := map[string]bool{}
+	for _, key := range k.PublicKeys() {
+		if !seen[key.Alg] {
+			seen[key.Alg] = true
+			algs = append(algs, key.Alg)
+		}
+	}
+	return algs
+}
+
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read keyring manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse keyring manifest: %w", err)
+	}
+	if manifest.Active == "" {
+		return nil, errors.New("keyring manifest has no active key")
+	}
+	return &manifest, nil
+}
+
+// WriteManifest replaces the manifest atomically so a running server never reads a half written file
+func WriteManifest(dir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ManifestFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write keyring manifest: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(dir, ManifestFile))
+}
@@ -35,10 +35,10 @@ func BatchCheckAccessFromPbToModel(cA *pb.BatchCheckAccessResponse) (*model.Batc
 		// Create a new ResourceActionResult for each response
 		result := &model.ResourceActionResult{}
 		result.ResAct = &model.ResourceAction{
-			Resource: res.ResAct.Resource,
-			Action:   res.ResAct.Action,
+			Resource: res.GetResAct().GetResource(),
+			Action:   res.GetResAct().GetAction(),
 		}
-		if res != nil {
+		if res.GetDecision() != nil {
 			result.Decision = &model.CheckAccessResponse{
 				Allowed:       res.Decision.Allowed,
 				Reason:        res.Decision.Reason,
@@ -1,6 +1,8 @@
 package mapper
 
 import (
+	"time"
+
 	"github.com/ashish19912009/zrms/services/authN/internal/model"
 	"github.com/ashish19912009/zrms/services/authN/pb"
 )
@@ -23,6 +25,7 @@ func RefreshTokenRequest(req *pb.RefreshTokenRequest) *model.RefreshTokenInput {
 func LogoutRequestInput(req *pb.LogoutRequest) *model.LogoutInput {
 	return &model.LogoutInput{
 		RefreshToken: req.RefreshToken,
+		AccessToken:  req.AccessToken,
 	}
 }
 
@@ -31,3 +34,177 @@ func VerifyTokenRequest(req *pb.VerifyTokenRequest) *model.ValidateTokenInput {
 		AccessToken: req.AccessToken,
 	}
 }
+
+func ListSessionsRequest(req *pb.ListSessionsRequest) *model.ListSessionsInput {
+	return &model.ListSessionsInput{
+		AccountID:   req.AccountId,
+		AccessToken: req.AccessToken,
+	}
+}
+
+func RevokeSessionRequest(req *pb.RevokeSessionRequest) *model.RevokeSessionInput {
+	return &model.RevokeSessionInput{
+		AccountID:   req.AccountId,
+		SessionID:   req.SessionId,
+		AccessToken: req.AccessToken,
+	}
+}
+
+func RevokeAllSessionsRequest(req *pb.RevokeAllSessionsRequest) *model.ListSessionsInput {
+	return &model.ListSessionsInput{
+		AccountID:   req.AccountId,
+		AccessToken: req.AccessToken,
+	}
+}
+
+func RevokeFranchiseSessionsRequest(req *pb.RevokeFranchiseSessionsRequest) *model.RevokeFranchiseSessionsInput {
+	return &model.RevokeFranchiseSessionsInput{
+		FranchiseID: req.FranchiseId,
+		Reason:      req.Reason,
+		AccessToken: req.AccessToken,
+	}
+}
+
+func RevokeAccountTokensRequest(req *pb.RevokeAccountTokensRequest) *model.RevokeAccountTokensInput {
+	return &model.RevokeAccountTokensInput{
+		AccountID:   req.AccountId,
+		Reason:      req.Reason,
+		AccessToken: req.AccessToken,
+	}
+}
+
+func UnlockAccountRequest(req *pb.UnlockAccountRequest) *model.UnlockAccountInput {
+	return &model.UnlockAccountInput{
+		LoginID:     req.LoginId,
+		AccountType: req.AccountType,
+		Reason:      req.Reason,
+		AccessToken: req.AccessToken,
+	}
+}
+
+func RequestPasswordResetRequest(req *pb.RequestPasswordResetRequest) *model.RequestPasswordResetInput {
+	return &model.RequestPasswordResetInput{
+		LoginID:     req.LoginId,
+		AccountType: req.AccountType,
+		Channel:     req.Channel,
+	}
+}
+
+func ConfirmPasswordResetRequest(req *pb.ConfirmPasswordResetRequest) *model.ConfirmPasswordResetInput {
+	return &model.ConfirmPasswordResetInput{
+		LoginID:     req.LoginId,
+		AccountType: req.AccountType,
+		Code:        req.Code,
+		NewPassword: req.NewPassword,
+	}
+}
+
+func ChangePasswordRequest(req *pb.ChangePasswordRequest) *model.ChangePasswordInput {
+	return &model.ChangePasswordInput{
+		AccessToken: req.AccessToken,
+		OldPassword: req.OldPassword,
+		NewPassword: req.NewPassword,
+	}
+}
+
+func EnrollMFARequest(req *pb.EnrollMFARequest) *model.EnrollMFAInput {
+	return &model.EnrollMFAInput{
+		AccessToken: req.AccessToken,
+		MFAToken:    req.MfaToken,
+	}
+}
+
+func ConfirmMFARequest(req *pb.ConfirmMFARequest) *model.ConfirmMFAInput {
+	return &model.ConfirmMFAInput{
+		AccessToken: req.AccessToken,
+		Code:        req.Code,
+	}
+}
+
+func VerifyMFARequest(req *pb.VerifyMFARequest) *model.VerifyMFAInput {
+	return &model.VerifyMFAInput{
+		MFAToken: req.MfaToken,
+		Code:     req.Code,
+	}
+}
+
+func RequestLoginOTPRequest(req *pb.RequestLoginOTPRequest) *model.RequestLoginOTPInput {
+	return &model.RequestLoginOTPInput{
+		MobileNo:    req.MobileNo,
+		AccountType: req.AccountType,
+	}
+}
+
+func VerifyLoginOTPRequest(req *pb.VerifyLoginOTPRequest) *model.VerifyLoginOTPInput {
+	return &model.VerifyLoginOTPInput{
+		MobileNo:    req.MobileNo,
+		AccountType: req.AccountType,
+		Code:        req.Code,
+	}
+}
+
+func ClientCredentialsRequest(req *pb.ClientCredentialsRequest) *model.ClientCredentialsInput {
+	return &model.ClientCredentialsInput{
+		ClientID:     req.ClientId,
+		ClientSecret: req.ClientSecret,
+		Scopes:       req.Scopes,
+	}
+}
+
+func ImpersonateRequest(req *pb.ImpersonateRequest) *model.ImpersonateInput {
+	return &model.ImpersonateInput{
+		AccessToken:     req.AccessToken,
+		TargetAccountID: req.TargetAccountId,
+		Reason:          req.Reason,
+	}
+}
+
+func ListMyFranchisesRequest(req *pb.ListMyFranchisesRequest) *model.ListMyFranchisesInput {
+	return &model.ListMyFranchisesInput{
+		AccessToken: req.AccessToken,
+	}
+}
+
+func SwitchFranchiseRequest(req *pb.SwitchFranchiseRequest) *model.SwitchFranchiseInput {
+	return &model.SwitchFranchiseInput{
+		AccessToken: req.AccessToken,
+		FranchiseID: req.FranchiseId,
+	}
+}
+
+func CreateAPIKeyRequest(req *pb.CreateAPIKeyRequest) *model.CreateAPIKeyInput {
+	return &model.CreateAPIKeyInput{
+		AccessToken: req.AccessToken,
+		FranchiseID: req.FranchiseId,
+		Name:        req.Name,
+		Scopes:      req.Scopes,
+		ExpiresIn:   time.Duration(req.ExpiresInSeconds) * time.Second,
+	}
+}
+
+func ListAPIKeysRequest(req *pb.ListAPIKeysRequest) *model.ListAPIKeysInput {
+	return &model.ListAPIKeysInput{
+		AccessToken: req.AccessToken,
+		FranchiseID: req.FranchiseId,
+	}
+}
+
+func RotateAPIKeyRequest(req *pb.RotateAPIKeyRequest) *model.APIKeyInput {
+	return &model.APIKeyInput{
+		AccessToken: req.AccessToken,
+		KeyID:       req.KeyId,
+	}
+}
+
+func RevokeAPIKeyRequest(req *pb.RevokeAPIKeyRequest) *model.APIKeyInput {
+	return &model.APIKeyInput{
+		AccessToken: req.AccessToken,
+		KeyID:       req.KeyId,
+	}
+}
+
+func VerifyAPIKeyRequest(req *pb.VerifyAPIKeyRequest) *model.VerifyAPIKeyInput {
+	return &model.VerifyAPIKeyInput{
+		APIKey: req.ApiKey,
+	}
+}
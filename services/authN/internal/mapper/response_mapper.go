@@ -1,12 +1,15 @@
 package mapper
 
 import (
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
 	"github.com/ashish19912009/zrms/services/authN/internal/model"
 	"github.com/ashish19912009/zrms/services/authN/pb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-func LoginResponse(user *model.User, accessToken, refreshToken string) *pb.LoginResponse {
+func LoginResponse(user *model.User, accessToken, refreshToken, sessionID string) *pb.LoginResponse {
 	return &pb.LoginResponse{
 		AccountId:    user.AccountID,
 		FranchiseId:  user.FranchiseID,
@@ -17,6 +20,18 @@ func LoginResponse(user *model.User, accessToken, refreshToken string) *pb.Login
 		Email:        user.Email,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		SessionId:    sessionID,
+	}
+}
+
+// MFAChallengeResponse answers Login without tokens, they are issued by VerifyMFA
+func MFAChallengeResponse(user *model.User, mfaToken string, enrollmentRequired bool) *pb.LoginResponse {
+	return &pb.LoginResponse{
+		AccountId:             user.AccountID,
+		AccountType:           user.AccountType,
+		MfaRequired:           true,
+		MfaToken:              mfaToken,
+		MfaEnrollmentRequired: enrollmentRequired,
 	}
 }
 
@@ -30,12 +45,38 @@ func VerifyTokenResponse(usrClaims *model.AuthClaims) *pb.AuthClaims {
 		ExpiresAt: timestamppb.New(usrClaims.RegisteredClaims.ExpiresAt.Time),
 	}
 	return &pb.AuthClaims{
-		EmployeeId:       usrClaims.EmployeeID,
-		FranchiseId:      usrClaims.FranchiseID,
-		AccountType:      usrClaims.AccountType,
-		Name:             usrClaims.Name,
-		MobileNo:         usrClaims.MobileNo,
-		RegisteredClaims: rClaims,
+		EmployeeId:         usrClaims.EmployeeID,
+		FranchiseId:        usrClaims.FranchiseID,
+		AccountType:        usrClaims.AccountType,
+		Name:               usrClaims.Name,
+		MobileNo:           usrClaims.MobileNo,
+		RegisteredClaims:   rClaims,
+		SessionId:          usrClaims.SessionID,
+		TokenType:          usrClaims.TokenType,
+		Scopes:             usrClaims.Scopes,
+		Permissions:        usrClaims.Permissions,
+		PermissionsHash:    usrClaims.PermissionsHash,
+		PermissionsVersion: usrClaims.PermissionsVersion,
+		Act:                actorResponse(usrClaims.Act),
+	}
+}
+
+func actorResponse(act *model.Actor) *pb.Actor {
+	if act == nil {
+		return nil
+	}
+	return &pb.Actor{
+		Subject:   act.Subject,
+		SessionId: act.SessionID,
+	}
+}
+
+func ImpersonateResponse(accessToken string, claims *model.AuthClaims, expiresIn time.Duration) *pb.ImpersonateResponse {
+	return &pb.ImpersonateResponse{
+		AccessToken:      accessToken,
+		ExpiresInSeconds: int32(expiresIn.Seconds()),
+		AccountId:        claims.RegisteredClaims.Subject,
+		FranchiseId:      claims.FranchiseID,
 	}
 }
 
@@ -44,3 +85,154 @@ func LogoutResponse(success bool) *pb.LogoutResponse {
 		Success: success,
 	}
 }
+
+func SessionResponse(session *model.Session) *pb.Session {
+	return &pb.Session{
+		SessionId:      session.ID,
+		AccountId:      session.AccountID,
+		AccountType:    session.AccountType,
+		DeviceLabel:    session.DeviceLabel,
+		IpAddress:      session.IPAddress,
+		UserAgent:      session.UserAgent,
+		CreatedAt:      timestamppb.New(session.CreatedAt),
+		ExpiresAt:      timestamppb.New(session.ExpiresAt),
+		LastActivityAt: timestamppb.New(session.LastActive()),
+	}
+}
+
+func ListSessionsResponse(sessions []*model.Session) *pb.ListSessionsResponse {
+	res := &pb.ListSessionsResponse{
+		Sessions: make([]*pb.Session, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, SessionResponse(session))
+	}
+	return res
+}
+
+func RevokeSessionResponse(success bool, revoked int) *pb.RevokeSessionResponse {
+	return &pb.RevokeSessionResponse{
+		Success:      success,
+		RevokedCount: int32(revoked),
+	}
+}
+
+func UnlockAccountResponse(success, wasLocked bool) *pb.UnlockAccountResponse {
+	return &pb.UnlockAccountResponse{
+		Success:   success,
+		WasLocked: wasLocked,
+	}
+}
+
+func PasswordResetResponse(success bool) *pb.PasswordResetResponse {
+	return &pb.PasswordResetResponse{
+		Success: success,
+	}
+}
+
+func ChangePasswordResponse(success bool, revoked int) *pb.ChangePasswordResponse {
+	return &pb.ChangePasswordResponse{
+		Success:      success,
+		RevokedCount: int32(revoked),
+	}
+}
+
+func EnrollMFAResponse(setup *model.MFASetup) *pb.EnrollMFAResponse {
+	return &pb.EnrollMFAResponse{
+		Secret:        setup.Secret,
+		OtpauthUri:    setup.URI,
+		RecoveryCodes: setup.RecoveryCodes,
+	}
+}
+
+func ConfirmMFAResponse(success bool) *pb.ConfirmMFAResponse {
+	return &pb.ConfirmMFAResponse{
+		Success: success,
+	}
+}
+
+func RequestLoginOTPResponse(success bool, expiresIn, resendAfter time.Duration) *pb.RequestLoginOTPResponse {
+	return &pb.RequestLoginOTPResponse{
+		Success:            success,
+		ExpiresInSeconds:   int32(expiresIn.Seconds()),
+		ResendAfterSeconds: int32(resendAfter.Seconds()),
+	}
+}
+
+func ClientCredentialsResponse(accessToken string, expiresIn time.Duration, scopes []string) *pb.ClientCredentialsResponse {
+	return &pb.ClientCredentialsResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresInSeconds: int32(expiresIn.Seconds()),
+		Scopes:           scopes,
+	}
+}
+
+func ListMyFranchisesResponse(memberships []*model.FranchiseMembership, currentFranchiseID string) *pb.ListMyFranchisesResponse {
+	franchises := make([]*pb.FranchiseMembership, 0, len(memberships))
+	for _, m := range memberships {
+		franchises = append(franchises, &pb.FranchiseMembership{
+			FranchiseId:  m.FranchiseID,
+			BusinessName: m.BusinessName,
+			Status:       m.FranchiseStatus,
+			AccountId:    m.AccountID,
+			AccountType:  m.AccountType,
+			OwnerRole:    m.OwnerRole,
+			Current:      m.FranchiseID == currentFranchiseID,
+		})
+	}
+	return &pb.ListMyFranchisesResponse{Franchises: franchises}
+}
+
+func APIKeyResponse(key *model.APIKey) *pb.APIKey {
+	return &pb.APIKey{
+		Id:          key.ID,
+		FranchiseId: key.FranchiseID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Scopes:      key.Scopes,
+		CreatedBy:   key.CreatedBy,
+		CreatedAt:   timestamppb.New(key.CreatedAt),
+		ExpiresAt:   optionalTimestamp(key.ExpiresAt),
+		LastUsedAt:  optionalTimestamp(key.LastUsedAt),
+		RevokedAt:   optionalTimestamp(key.RevokedAt),
+	}
+}
+
+// CreateAPIKeyResponse is the only response that carries the key itself
+func CreateAPIKeyResponse(key *model.APIKey, secret string) *pb.CreateAPIKeyResponse {
+	return &pb.CreateAPIKeyResponse{
+		ApiKey: APIKeyResponse(key),
+		Key:    secret,
+	}
+}
+
+func ListAPIKeysResponse(keys []*model.APIKey) *pb.ListAPIKeysResponse {
+	apiKeys := make([]*pb.APIKey, 0, len(keys))
+	for _, key := range keys {
+		apiKeys = append(apiKeys, APIKeyResponse(key))
+	}
+	return &pb.ListAPIKeysResponse{ApiKeys: apiKeys}
+}
+
+// APIKeyClaims describes a verified key like a token, the key id is the subject
+func APIKeyClaims(key *model.APIKey) *pb.AuthClaims {
+	return &pb.AuthClaims{
+		FranchiseId: key.FranchiseID,
+		TokenType:   constants.TokenTypeAPIKey,
+		Scopes:      key.Scopes,
+		RegisteredClaims: &pb.RegisteredClaims{
+			Id:        key.Prefix,
+			Subject:   key.ID,
+			IssuedAt:  timestamppb.New(key.CreatedAt),
+			ExpiresAt: optionalTimestamp(key.ExpiresAt),
+		},
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,72 @@
+package mfa_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/mfa"
+)
+
+// RFC 6238 appendix B test secret, the SHA1 vectors truncated to 6 digits
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		got, err := mfa.Code(rfcSecret, mfa.Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		if got != want {
+			t.Errorf("at %d expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := mfa.Code(rfcSecret, mfa.Step(now))
+
+	step, ok := mfa.Validate(rfcSecret, code, now, 0)
+	if !ok || step != mfa.Step(now) {
+		t.Fatalf("expected the current code to validate, got %v %d", ok, step)
+	}
+	if _, ok := mfa.Validate(rfcSecret, code, now, step); ok {
+		t.Fatal("expected a used step to be refused")
+	}
+	if _, ok := mfa.Validate(rfcSecret, code, now.Add(mfa.Period), 0); !ok {
+		t.Fatal("expected the previous step to be accepted for clock drift")
+	}
+	if _, ok := mfa.Validate(rfcSecret, code, now.Add(3*mfa.Period), 0); ok {
+		t.Fatal("expected an old code to be refused")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+	if len(codes) != mfa.RecoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", mfa.RecoveryCodeCount, len(codes))
+	}
+	if mfa.HashRecoveryCode(codes[0]) != mfa.HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))) {
+		t.Fatal("expected the hash to ignore case and the dash")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := mfa.ProvisioningURI("ZRMS", "ops.superadmin", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/ZRMS:ops.superadmin?") || !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=ZRMS") {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// APIKey is a long lived credential of a partner integration, bound to one franchise and a set of scopes.
+// Only the hash of the key is stored, Prefix is the part shown in listings.
+type APIKey struct {
+	ID          string
+	FranchiseID string
+	Name        string
+	Prefix      string
+	KeyHash     string
+	Scopes      []string // resource:action pairs
+	CreatedBy   string   // account id of the manager who created the key
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+// Active reports whether the key can still authenticate at now
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+type CreateAPIKeyInput struct {
+	AccessToken string
+	FranchiseID string // super admins only, other managers get keys of their own franchise
+	Name        string
+	Scopes      []string
+	ExpiresIn   time.Duration // 0 for a key without expiry
+}
+
+type ListAPIKeysInput struct {
+	AccessToken string
+	FranchiseID string
+}
+
+// APIKeyInput identifies one key for RotateAPIKey and RevokeAPIKey
+type APIKeyInput struct {
+	AccessToken string
+	KeyID       string
+}
+
+type VerifyAPIKeyInput struct {
+	APIKey string
+}
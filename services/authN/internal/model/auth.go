@@ -16,8 +16,31 @@ type LoginResponse struct {
 
 type LogoutInput struct {
 	RefreshToken string
+	AccessToken  string
+}
+
+type RevokeAccountTokensInput struct {
+	AccountID   string
+	Reason      string
+	AccessToken string
 }
 
 type LogoutResponse struct {
 	Success bool
 }
+
+// LoginAttempts counts the failed logins of one login_id or client IP inside the lockout window
+type LoginAttempts struct {
+	Failures      int       `json:"failures"`
+	Lockouts      int       `json:"lockouts"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LockedUntil   time.Time `json:"locked_until"`
+}
+
+type UnlockAccountInput struct {
+	LoginID     string
+	AccountType string
+	Reason      string
+	AccessToken string
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// LoginOTP is a pending passwordless login code, only the hash of the code is kept
+type LoginOTP struct {
+	AccountID string    `json:"account_id"`
+	CodeHash  string    `json:"code_hash"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RequestLoginOTPInput struct {
+	MobileNo    string
+	AccountType string
+}
+
+type VerifyLoginOTPInput struct {
+	MobileNo    string
+	AccountType string
+	Code        string
+}
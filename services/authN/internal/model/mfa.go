@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// MFAEnrollment is the TOTP setup of an account, it only protects logins once ConfirmedAt is set
+type MFAEnrollment struct {
+	AccountID     string
+	Secret        string
+	RecoveryCodes []string // sha256 hashes, a used code is removed
+	LastUsedStep  int64    // TOTP step of the last accepted code, older codes are refused
+	ConfirmedAt   *time.Time
+	CreatedAt     time.Time
+}
+
+// MFAChallenge is handed out by Login instead of tokens when a second factor is needed
+type MFAChallenge struct {
+	AccountID  string    `json:"account_id"`
+	LoginID    string    `json:"login_id"`
+	Enrollment bool      `json:"enrollment"` // MFA is required but the account still has to enroll
+	Attempts   int       `json:"attempts"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// set when SwitchFranchise asked for the challenge, the session switched from ends with it
+	SwitchFromAccountID string `json:"switch_from_account_id,omitempty"`
+	SwitchFromSessionID string `json:"switch_from_session_id,omitempty"`
+}
+
+type EnrollMFAInput struct {
+	AccessToken string
+	MFAToken    string
+}
+
+type ConfirmMFAInput struct {
+	AccessToken string
+	Code        string
+}
+
+type VerifyMFAInput struct {
+	MFAToken string
+	Code     string
+}
+
+// MFASetup is returned once on enrollment, the recovery codes are never shown again
+type MFASetup struct {
+	Secret        string
+	URI           string
+	RecoveryCodes []string
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Notification is a message delivered to a user through a notifier, e.g. a one-time code
+type Notification struct {
+	Channel   string    `json:"channel"`
+	To        string    `json:"to"`
+	AccountID string    `json:"account_id"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// PasswordResetCode is a pending reset, only the hash of the code is kept
+type PasswordResetCode struct {
+	AccountID string    `json:"account_id"`
+	CodeHash  string    `json:"code_hash"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RequestPasswordResetInput struct {
+	LoginID     string
+	AccountType string
+	Channel     string
+}
+
+type ChangePasswordInput struct {
+	AccessToken string
+	OldPassword string
+	NewPassword string
+}
+
+type ConfirmPasswordResetInput struct {
+	LoginID     string
+	AccountType string
+	Code        string
+	NewPassword string
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Session represents a single logged-in device of an account
+type Session struct {
+	ID          string    `json:"id"`
+	AccountID   string    `json:"account_id"`
+	AccountType string    `json:"account_type"`
+	DeviceLabel string    `json:"device_label"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// LastActivityAt is bumped by token verification and refresh, the idle timeout counts from it
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+// LastActive falls back to CreatedAt for sessions stored before activity was tracked
+func (s *Session) LastActive() time.Time {
+	if s.LastActivityAt.IsZero() {
+		return s.CreatedAt
+	}
+	return s.LastActivityAt
+}
+
+// ClientInfo holds the device details sent by the client in gRPC metadata
+type ClientInfo struct {
+	DeviceLabel string
+	IPAddress   string
+	UserAgent   string
+}
+
+type ListSessionsInput struct {
+	AccountID   string
+	AccessToken string
+}
+
+type RevokeSessionInput struct {
+	AccountID   string
+	SessionID   string
+	AccessToken string
+}
@@ -14,6 +14,15 @@ type AuthClaims struct {
 	MobileNo    string `json:"mobile_no"`
 	Role        string `json:"role"`
 	TokenType   string `json:"token_type"`
+	SessionID   string `json:"sid,omitempty"`
+	// Scopes are the "resource:action" pairs a service token was granted, user tokens have none
+	Scopes []string `json:"scopes,omitempty"`
+	// Effective permissions embedded at login, see TokenPermissions
+	Permissions        []string `json:"perms,omitempty"`
+	PermissionsHash    string   `json:"perms_hash,omitempty"`
+	PermissionsVersion string   `json:"perms_ver,omitempty"`
+	// Act names the super admin behind an impersonation token (RFC 8693)
+	Act *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -24,6 +33,18 @@ type TokenDetails struct {
 	RefreshExpiry time.Time `json:"refresh_exp"`
 }
 
+// RefreshTokenRecord tracks a refresh token inside its rotation family.
+// A family starts at login and shares the session ID; every rotation links the new jti to its parent.
+type RefreshTokenRecord struct {
+	JTI       string    `json:"jti"`
+	ParentJTI string    `json:"parent_jti,omitempty"`
+	FamilyID  string    `json:"family_id"`
+	AccountID string    `json:"account_id"`
+	RotatedTo string    `json:"rotated_to,omitempty"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type RefreshTokenInput struct {
 	AccountID    string `json:"account_id"`
 	RefreshToken string `json:"refresh_token"`
@@ -32,3 +53,29 @@ type RefreshTokenInput struct {
 type ValidateTokenInput struct {
 	AccessToken string `json:"access_token"`
 }
+
+type ClientCredentialsInput struct {
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// TokenPermissions is the permission set AuthZ granted an account at login.
+// Permissions is empty when the set was too large and only its hash is embedded.
+type TokenPermissions struct {
+	Permissions []string // allowed "resource:action" pairs, sorted
+	Hash        string   // SHA-256 of the sorted pairs
+	Version     string   // AuthZ version of the policy and the account's permissions the set was computed with
+}
+
+// Actor is the RFC 8693 act claim, SessionID is the admin's session the impersonation is bound to
+type Actor struct {
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid,omitempty"`
+}
+
+type ImpersonateInput struct {
+	AccessToken     string
+	TargetAccountID string
+	Reason          string
+}
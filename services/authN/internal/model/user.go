@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // "id",
 // "franchise_id",
 // "employee_id",
@@ -14,6 +16,7 @@ package model
 
 type User struct {
 	AccountID   string `json:"account_id"`
+	LoginID     string `json:"login_id"`
 	FranchiseID string `json:"franchise_id"`
 	EmployeeID  string `json:"employee_id"`
 	AccountType string `json:"account_type"`
@@ -23,6 +26,42 @@ type User struct {
 	Email       string `json:"email"`
 	Password    string `json:"password"`
 	Status      string `json:"status"`
+	// OwnerID links the accounts of one person across franchises, empty for most staff
+	OwnerID string `json:"owner_id,omitempty"`
+	// DeletedAt is set once the account was soft deleted in the account service
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// FranchiseStatus is the lifecycle of the franchise an account belongs to
+type FranchiseStatus struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// FranchiseMembership is a franchise a person can switch to and their team account there
+type FranchiseMembership struct {
+	FranchiseID     string `json:"franchise_id"`
+	BusinessName    string `json:"business_name"`
+	FranchiseStatus string `json:"franchise_status"`
+	AccountID       string `json:"account_id"`
+	AccountType     string `json:"account_type"`
+	OwnerRole       string `json:"owner_role,omitempty"`
+}
+
+type ListMyFranchisesInput struct {
+	AccessToken string
+}
+
+type SwitchFranchiseInput struct {
+	AccessToken string
+	FranchiseID string
+}
+
+type RevokeFranchiseSessionsInput struct {
+	FranchiseID string
+	Reason      string
+	AccessToken string
 }
 
 // type UserAdminPermission struct {
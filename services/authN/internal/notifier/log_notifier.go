@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+)
+
+// LogNotifier is the local development sink, messages are appended to a file
+// as JSON lines, or written to the service log when no file is configured
+type LogNotifier struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+func NewLogNotifier(filePath string) *LogNotifier {
+	return &LogNotifier{
+		filePath: filePath,
+	}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg *model.Notification) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if n.filePath == "" {
+		logger.Info(constants.NotificationSent, map[string]interface{}{
+			"channel":    msg.Channel,
+			"to":         msg.To,
+			"account_id": msg.AccountID,
+			"subject":    msg.Subject,
+			"body":       msg.Body,
+		})
+		return nil
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		logger.Error(constants.FailedToSendNotification, err, map[string]interface{}{
+			"file_path": n.filePath,
+		})
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		logger.Error(constants.FailedToSendNotification, err, map[string]interface{}{
+			"file_path": n.filePath,
+		})
+		return err
+	}
+	return nil
+}
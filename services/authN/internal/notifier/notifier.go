@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+)
+
+// Notifier delivers one-time codes and other account messages to a user.
+// SMS and email providers implement it next to the log sink.
+type Notifier interface {
+	Send(ctx context.Context, msg *model.Notification) error
+}
+
+// New returns the notifier selected in config, the log sink is the default.
+// When an SMS provider is configured SMS messages go through it instead.
+func New(cfg config.NotifierConfig) (Notifier, error) {
+	var base Notifier
+	switch cfg.Type {
+	case "", constants.LogNotifierType:
+		base = NewLogNotifier(cfg.FilePath)
+	default:
+		return nil, fmt.Errorf(constants.UnsupportedNotifier, cfg.Type)
+	}
+
+	switch cfg.SMS.Provider {
+	case "":
+		return base, nil
+	case constants.ConsoleSMSProvider:
+		return NewSMSNotifier(NewConsoleSMSProvider(), base), nil
+	case constants.FileSMSProvider:
+		if cfg.SMS.FilePath == "" {
+			return nil, fmt.Errorf(constants.SMSFilePathRequired)
+		}
+		return NewSMSNotifier(NewFileSMSProvider(cfg.SMS.FilePath), base), nil
+	default:
+		return nil, fmt.Errorf(constants.UnsupportedSMSProvider, cfg.SMS.Provider)
+	}
+}
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+)
+
+// SMSProvider sends a text message to a mobile number, SMS gateways implement it
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// SMSNotifier hands SMS notifications to a provider, other channels go to next
+type SMSNotifier struct {
+	provider SMSProvider
+	next     Notifier
+}
+
+func NewSMSNotifier(provider SMSProvider, next Notifier) *SMSNotifier {
+	return &SMSNotifier{
+		provider: provider,
+		next:     next,
+	}
+}
+
+func (n *SMSNotifier) Send(ctx context.Context, msg *model.Notification) error {
+	if msg.Channel != constants.ChannelSMS {
+		return n.next.Send(ctx, msg)
+	}
+	if err := n.provider.SendSMS(ctx, msg.To, msg.Body); err != nil {
+		logger.Error(constants.FailedToSendNotification, err, map[string]interface{}{
+			"channel":    msg.Channel,
+			"account_id": msg.AccountID,
+		})
+		return err
+	}
+	return nil
+}
+
+// ConsoleSMSProvider prints messages to stdout, for local development only
+type ConsoleSMSProvider struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func NewConsoleSMSProvider() *ConsoleSMSProvider {
+	return &ConsoleSMSProvider{
+		out: os.Stdout,
+	}
+}
+
+func (p *ConsoleSMSProvider) SendSMS(ctx context.Context, to, body string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := fmt.Fprintf(p.out, "[sms] to=%s %s\n", to, body)
+	return err
+}
+
+// FileSMSProvider appends messages to a file as JSON lines, for local development and tests
+type FileSMSProvider struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+func NewFileSMSProvider(filePath string) *FileSMSProvider {
+	return &FileSMSProvider{
+		filePath: filePath,
+	}
+}
+
+func (p *FileSMSProvider) SendSMS(ctx context.Context, to, body string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"to":      to,
+		"body":    body,
+		"sent_at": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	file, err := os.OpenFile(p.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,193 @@
+// Package oidc serves the OpenID Connect discovery document and the RFC 7662
+// token introspection endpoint next to the JWK set
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/helper"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+)
+
+const (
+	DiscoveryPath     = "/.well-known/openid-configuration"
+	JWKSPath          = "/.well-known/jwks.json"
+	IntrospectionPath = "/introspect"
+)
+
+// TokenVerifier is the part of service.AuthService introspection relies on,
+// it checks the signature, the denylist and that the session still exists
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, req *pb.VerifyTokenRequest) (*pb.AuthClaims, error)
+}
+
+type Handler struct {
+	issuer   string
+	audience string
+	algs     func() []string // signing algorithms of the published keys, they change on key rotation
+	verifier TokenVerifier
+	clients  map[string]config.OAuthClientConfig
+	proxies  helper.TrustedProxies
+}
+
+func NewHandler(issuer, audience string, algs func() []string, verifier TokenVerifier, clients []config.OAuthClientConfig, proxies helper.TrustedProxies) *Handler {
+	registered := make(map[string]config.OAuthClientConfig, len(clients))
+	for _, c := range clients {
+		registered[c.ClientID] = c
+	}
+	return &Handler{
+		issuer:   issuer,
+		audience: audience,
+		algs:     algs,
+		verifier: verifier,
+		clients:  registered,
+		proxies:  proxies,
+	}
+}
+
+// Discovery serves /.well-known/openid-configuration. Endpoints are absolute URLs under the
+// issuer when JWT_ISSUER is a URL, otherwise under the host the request was sent to.
+func (h *Handler) Discovery(w http.ResponseWriter, r *http.Request) {
+	base := h.baseURL(r)
+	doc := map[string]interface{}{
+		"issuer":                 h.issuer,
+		"jwks_uri":               base + JWKSPath,
+		"introspection_endpoint": base + IntrospectionPath,
+		"introspection_endpoint_auth_methods_supported":    []string{"client_secret_basic", "client_secret_post"},
+		"response_types_supported":                         []string{"token"},
+		"subject_types_supported":                          []string{"public"},
+		"id_token_signing_alg_values_supported":            h.algs(),
+		"token_endpoint_auth_signing_alg_values_supported": h.algs(),
+		"claims_supported": []string{
+			"sub", "iss", "aud", "exp", "iat", "jti",
+			"employee_id", "franchise_id", "account_type", "name", "mobile_no", "session_id", "act",
+		},
+	}
+	if h.audience != "" {
+		doc["audience"] = h.audience
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// Introspect implements RFC 7662 for access tokens. Callers authenticate with client_secret_basic
+// or client_secret_post, an invalid, expired or revoked token is reported as {"active": false}.
+func (h *Handler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, constants.IntrospectionMethod, http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clientID, ok := h.authenticateClient(r)
+	if !ok {
+		logger.Warn(constants.IntrospectionUnauthorized, map[string]interface{}{
+			constants.SecurityEvent: constants.EventIntrospectionDenied,
+			"method":                constants.Methods.Introspect,
+			"client_id":             clientID,
+			"ip_address":            helper.RemoteIP(r, h.proxies),
+		})
+		w.Header().Set("WWW-Authenticate", `Basic realm="introspection"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "invalid_request",
+			"error_description": constants.IntrospectionTokenMissing,
+		})
+		return
+	}
+
+	// VerifyAccessToken refuses refresh tokens, so they are reported inactive like any unknown token
+	claims, err := h.verifier.VerifyAccessToken(r.Context(), &pb.VerifyTokenRequest{AccessToken: token})
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]bool{"active": false})
+		return
+	}
+
+	logger.Info(constants.TokenIntrospected, map[string]interface{}{
+		"method":    constants.Methods.Introspect,
+		"client_id": clientID,
+		"jti":       claims.GetRegisteredClaims().GetId(),
+	})
+	writeJSON(w, http.StatusOK, introspectionResponse(claims))
+}
+
+func introspectionResponse(claims *pb.AuthClaims) map[string]interface{} {
+	rc := claims.GetRegisteredClaims()
+	resp := map[string]interface{}{
+		"active":       true,
+		"token_type":   "Bearer",
+		"sub":          rc.GetSubject(),
+		"iss":          rc.GetIssuer(),
+		"jti":          rc.GetId(),
+		"employee_id":  claims.GetEmployeeId(),
+		"franchise_id": claims.GetFranchiseId(),
+		"account_type": claims.GetAccountType(),
+		"session_id":   claims.GetSessionId(),
+	}
+	if len(claims.GetScopes()) > 0 {
+		resp["scope"] = strings.Join(claims.GetScopes(), " ")
+	}
+	if claims.GetTokenType() == constants.TokenTypeService {
+		resp["client_id"] = rc.GetSubject()
+	}
+	if act := claims.GetAct(); act != nil {
+		resp["act"] = map[string]string{"sub": act.GetSubject()}
+	}
+	if len(rc.GetAudience()) > 0 {
+		resp["aud"] = rc.GetAudience()
+	}
+	if rc.GetIssuedAt() != nil {
+		resp["iat"] = rc.GetIssuedAt().AsTime().Unix()
+	}
+	if rc.GetExpiresAt() != nil {
+		resp["exp"] = rc.GetExpiresAt().AsTime().Unix()
+	}
+	return resp
+}
+
+// authenticateClient checks client_secret_basic first, then client_secret_post.
+// The client id is returned for logging even when the secret is wrong.
+func (h *Handler) authenticateClient(r *http.Request) (string, bool) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		return clientID, false
+	}
+
+	client, known := h.clients[clientID]
+	return clientID, known && client.VerifySecret(secret)
+}
+
+func (h *Handler) baseURL(r *http.Request) string {
+	if strings.HasPrefix(h.issuer, "https://") || strings.HasPrefix(h.issuer, "http://") {
+		return strings.TrimSuffix(h.issuer, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
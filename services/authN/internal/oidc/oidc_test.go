@@ -0,0 +1,86 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/oidc"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+)
+
+type fakeVerifier struct{}
+
+func (fakeVerifier) VerifyAccessToken(ctx context.Context, req *pb.VerifyTokenRequest) (*pb.AuthClaims, error) {
+	if req.GetAccessToken() != "good" {
+		return nil, errors.New("invalid token")
+	}
+	return &pb.AuthClaims{
+		EmployeeId:       "E1",
+		FranchiseId:      "F1",
+		RegisteredClaims: &pb.RegisteredClaims{Id: "jti-1", Subject: "A1", Issuer: "zrms"},
+	}, nil
+}
+
+func newHandler() *oidc.Handler {
+	sum := sha256.Sum256([]byte("s3cret"))
+	clients := []config.OAuthClientConfig{{ClientID: "authz", SecretSHA256: hex.EncodeToString(sum[:])}}
+	return oidc.NewHandler("https://auth.example.com/", "zrms", func() []string { return []string{"RS256"} }, fakeVerifier{}, clients, nil)
+}
+
+func introspect(t *testing.T, h *oidc.Handler, user, pass, token string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, oidc.IntrospectionPath, strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(user, pass)
+	rec := httptest.NewRecorder()
+	h.Introspect(rec, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestDiscovery(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newHandler().Discovery(rec, httptest.NewRequest(http.MethodGet, oidc.DiscoveryPath, nil))
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	if doc["jwks_uri"] != "https://auth.example.com/.well-known/jwks.json" {
+		t.Fatalf("unexpected jwks_uri: %v", doc["jwks_uri"])
+	}
+	if doc["introspection_endpoint"] != "https://auth.example.com/introspect" {
+		t.Fatalf("unexpected introspection_endpoint: %v", doc["introspection_endpoint"])
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	h := newHandler()
+
+	if code, _ := introspect(t, h, "authz", "wrong", "good"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong secret, got %d", code)
+	}
+
+	code, body := introspect(t, h, "authz", "s3cret", "good")
+	if code != http.StatusOK || body["active"] != true || body["sub"] != "A1" || body["franchise_id"] != "F1" {
+		t.Fatalf("unexpected response %d %v", code, body)
+	}
+
+	code, body = introspect(t, h, "authz", "s3cret", "revoked")
+	if code != http.StatusOK || body["active"] != false || len(body) != 1 {
+		t.Fatalf("expected only active=false, got %d %v", code, body)
+	}
+}
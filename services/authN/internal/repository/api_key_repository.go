@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/dbutils"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/lib/pq"
+)
+
+var ErrAPIKeyNotFound = errors.New(constants.APIKeyNotFound)
+
+// APIKeyRepository keeps the API keys of partner integrations in outlet.api_keys
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	GetAPIKey(ctx context.Context, keyID string) (*model.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context, franchiseID string) ([]*model.APIKey, error)
+	UpdateAPIKeySecret(ctx context.Context, keyID, prefix, keyHash string) error
+	RevokeAPIKey(ctx context.Context, keyID string, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+var apiKeyColumns = []string{
+	"id",
+	"franchise_id",
+	"name",
+	"prefix",
+	"key_hash",
+	"scopes",
+	"created_by",
+	"expires_at",
+	"last_used_at",
+	"revoked_at",
+	"created_at",
+}
+
+func apiKeyOptions() *dbutils.QueryBuilderOptions {
+	return &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{constants.DB.Table_API_Keys},
+			Columns: append(append([]string{}, apiKeyColumns...), "updated_at"),
+		},
+	}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	var method = constants.Methods.CreateAPIKey
+	var table = constants.DB.Table_API_Keys
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return err
+	}
+
+	query, err := dbutils.BuildInsertQuery(method, schema_outlet, table, apiKeyColumns, apiKeyOptions())
+	if err != nil {
+		return err
+	}
+
+	var createdBy sql.NullString
+	if key.CreatedBy != "" {
+		createdBy = sql.NullString{String: key.CreatedBy, Valid: true}
+	}
+	_, err = r.db.ExecContext(ctx, query,
+		key.ID,
+		key.FranchiseID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+		createdBy,
+		nullTime(key.ExpiresAt),
+		nullTime(key.LastUsedAt),
+		nullTime(key.RevokedAt),
+		key.CreatedAt)
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) GetAPIKey(ctx context.Context, keyID string) (*model.APIKey, error) {
+	return r.getAPIKey(ctx, constants.Methods.GetAPIKey, map[string]any{"id": keyID})
+}
+
+// GetAPIKeyByPrefix finds the key a caller presented, the hash is compared by the service
+func (r *apiKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	return r.getAPIKey(ctx, constants.Methods.GetAPIKeyByPrefix, map[string]any{"prefix": prefix})
+}
+
+func (r *apiKeyRepository) getAPIKey(ctx context.Context, method string, conditions map[string]any) (*model.APIKey, error) {
+	keys, err := r.selectAPIKeys(ctx, method, conditions)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+	return keys[0], nil
+}
+
+// ListAPIKeys returns every key of the franchise, revoked and expired ones included
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context, franchiseID string) ([]*model.APIKey, error) {
+	return r.selectAPIKeys(ctx, constants.Methods.ListAPIKeys, map[string]any{"franchise_id": franchiseID})
+}
+
+func (r *apiKeyRepository) selectAPIKeys(ctx context.Context, method string, conditions map[string]any) ([]*model.APIKey, error) {
+	var table = constants.DB.Table_API_Keys
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return nil, err
+	}
+
+	query, args, err := dbutils.BuildSelectQuery(method, schema_outlet, table, apiKeyColumns, conditions, apiKeyOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*model.APIKey
+	for rows.Next() {
+		var key model.APIKey
+		var createdBy sql.NullString
+		var expiresAt, lastUsedAt, revokedAt sql.NullTime
+		if err := rows.Scan(
+			&key.ID,
+			&key.FranchiseID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			pq.Array(&key.Scopes),
+			&createdBy,
+			&expiresAt,
+			&lastUsedAt,
+			&revokedAt,
+			&key.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		key.CreatedBy = createdBy.String
+		key.ExpiresAt = timePtr(expiresAt)
+		key.LastUsedAt = timePtr(lastUsedAt)
+		key.RevokedAt = timePtr(revokedAt)
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+// UpdateAPIKeySecret replaces the key of a rotation, the old key stops working right away
+func (r *apiKeyRepository) UpdateAPIKeySecret(ctx context.Context, keyID, prefix, keyHash string) error {
+	return r.updateAPIKey(ctx, constants.Methods.UpdateAPIKeySecret, keyID, []string{"prefix", "key_hash"}, prefix, keyHash)
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, keyID string, revokedAt time.Time) error {
+	return r.updateAPIKey(ctx, constants.Methods.RevokeAPIKey, keyID, []string{"revoked_at"}, revokedAt)
+}
+
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	return r.updateAPIKey(ctx, constants.Methods.TouchAPIKey, keyID, []string{"last_used_at"}, usedAt)
+}
+
+// updateAPIKey sets the given columns of one key and bumps updated_at
+func (r *apiKeyRepository) updateAPIKey(ctx context.Context, method, keyID string, columns []string, values ...any) error {
+	var table = constants.DB.Table_API_Keys
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return err
+	}
+
+	columns = append(columns, "updated_at")
+	values = append(values, time.Now())
+
+	conditions := map[string]any{
+		"id": keyID,
+	}
+
+	query, args, err := dbutils.BuildUpdateQuery(method, schema_outlet, table, columns, conditions, apiKeyOptions())
+	if err != nil {
+		return err
+	}
+	copy(args, values)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+// LoginAttemptRepository keeps failed login counters, scope is either constants.AttemptScopeLogin or constants.AttemptScopeIP
+type LoginAttemptRepository interface {
+	GetAttempts(ctx context.Context, scope, key string) (*model.LoginAttempts, error)
+	SaveAttempts(ctx context.Context, scope, key string, attempts *model.LoginAttempts, expiry time.Duration) error
+	ResetAttempts(ctx context.Context, scope, key string) error
+}
+
+type loginAttemptRepository struct {
+	store store.InMemoryStore
+}
+
+func NewLoginAttemptRepository(s store.InMemoryStore) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		store: s,
+	}
+}
+
+// GetAttempts returns an empty record when nothing failed yet
+func (r *loginAttemptRepository) GetAttempts(ctx context.Context, scope, key string) (*model.LoginAttempts, error) {
+	val, err := r.store.Get(r.attemptKey(scope, key))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return &model.LoginAttempts{}, nil
+		}
+		logger.Error(constants.FailedToFetchAttempts, err, map[string]interface{}{
+			"method": constants.Methods.GetLoginAttempts,
+			"scope":  scope,
+		})
+		return nil, err
+	}
+
+	var raw []byte
+	switch v := val.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, errors.New(constants.FailedToFetchAttempts)
+	}
+
+	var attempts model.LoginAttempts
+	if err := json.Unmarshal(raw, &attempts); err != nil {
+		return nil, fmt.Errorf("%s: %w", constants.FailedToFetchAttempts, err)
+	}
+	return &attempts, nil
+}
+
+func (r *loginAttemptRepository) SaveAttempts(ctx context.Context, scope, key string, attempts *model.LoginAttempts, expiry time.Duration) error {
+	data, err := json.Marshal(attempts)
+	if err != nil {
+		return err
+	}
+
+	if expiry > 0 {
+		err = r.store.SetWithTTL(r.attemptKey(scope, key), string(data), expiry)
+	} else {
+		err = r.store.Set(r.attemptKey(scope, key), string(data))
+	}
+	if err != nil {
+		logger.Error(constants.FailedToStoreAttempts, err, map[string]interface{}{
+			"method": constants.Methods.SaveLoginAttempts,
+			"scope":  scope,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) ResetAttempts(ctx context.Context, scope, key string) error {
+	err := r.store.Delete(r.attemptKey(scope, key))
+	if err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+		logger.Error(constants.FailedToStoreAttempts, err, map[string]interface{}{
+			"method": constants.Methods.ResetLoginAttempts,
+			"scope":  scope,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) attemptKey(scope, key string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.Attempts_key, scope, key)
+}
@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+func setupLoginAttemptRepo() repository.LoginAttemptRepository {
+	return repository.NewLoginAttemptRepository(store.NewLightningDB(nil))
+}
+
+func TestGetAttempts_Empty(t *testing.T) {
+	repo := setupLoginAttemptRepo()
+
+	attempts, err := repo.GetAttempts(context.Background(), constants.AttemptScopeLogin, "ops.manager")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if attempts.Failures != 0 || !attempts.LockedUntil.IsZero() {
+		t.Fatalf("expected an empty record, got: %+v", attempts)
+	}
+}
+
+func TestSaveAttempts_ScopesAreSeparate(t *testing.T) {
+	repo := setupLoginAttemptRepo()
+	ctx := context.Background()
+
+	lockedUntil := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	err := repo.SaveAttempts(ctx, constants.AttemptScopeLogin, "ops.manager", &model.LoginAttempts{Failures: 3, LockedUntil: lockedUntil}, time.Hour)
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	got, err := repo.GetAttempts(ctx, constants.AttemptScopeLogin, "ops.manager")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got.Failures != 3 || !got.LockedUntil.Equal(lockedUntil) {
+		t.Fatalf("unexpected record returned: %+v", got)
+	}
+
+	other, _ := repo.GetAttempts(ctx, constants.AttemptScopeIP, "ops.manager")
+	if other.Failures != 0 {
+		t.Fatalf("ip scope should not see login_id failures, got: %+v", other)
+	}
+}
+
+func TestResetAttempts(t *testing.T) {
+	repo := setupLoginAttemptRepo()
+	ctx := context.Background()
+
+	_ = repo.SaveAttempts(ctx, constants.AttemptScopeIP, "10.0.0.1", &model.LoginAttempts{Failures: 5}, time.Hour)
+	if err := repo.ResetAttempts(ctx, constants.AttemptScopeIP, "10.0.0.1"); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if err := repo.ResetAttempts(ctx, constants.AttemptScopeIP, "10.0.0.1"); err != nil {
+		t.Fatalf("reset of a missing record should not fail, got: %v", err)
+	}
+
+	got, _ := repo.GetAttempts(ctx, constants.AttemptScopeIP, "10.0.0.1")
+	if got.Failures != 0 {
+		t.Fatalf("expected counters to be cleared, got: %+v", got)
+	}
+}
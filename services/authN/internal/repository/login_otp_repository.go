@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+var ErrLoginOTPNotFound = errors.New(constants.LoginOTPNotFound)
+
+// LoginOTPRepository keeps at most one pending login code per account
+type LoginOTPRepository interface {
+	SaveLoginOTP(ctx context.Context, record *model.LoginOTP) error
+	GetLoginOTP(ctx context.Context, accountID string) (*model.LoginOTP, error)
+	DeleteLoginOTP(ctx context.Context, accountID string) error
+}
+
+type loginOTPRepository struct {
+	store store.InMemoryStore
+}
+
+func NewLoginOTPRepository(s store.InMemoryStore) LoginOTPRepository {
+	return &loginOTPRepository{
+		store: s,
+	}
+}
+
+// SaveLoginOTP stores the record until it expires, saving again replaces the previous code
+func (r *loginOTPRepository) SaveLoginOTP(ctx context.Context, record *model.LoginOTP) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return ErrLoginOTPNotFound
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	err = r.store.SetWithTTL(r.otpKey(record.AccountID), string(data), ttl)
+	if err != nil {
+		logger.Error(constants.FailedToStoreLoginOTP, err, map[string]interface{}{
+			"method":     constants.Methods.SaveLoginOTP,
+			"account_id": record.AccountID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *loginOTPRepository) GetLoginOTP(ctx context.Context, accountID string) (*model.LoginOTP, error) {
+	val, err := r.store.Get(r.otpKey(accountID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return nil, ErrLoginOTPNotFound
+		}
+		logger.Error(constants.FailedToFetchLoginOTP, err, map[string]interface{}{
+			"method":     constants.Methods.GetLoginOTP,
+			"account_id": accountID,
+		})
+		return nil, err
+	}
+
+	var raw []byte
+	switch v := val.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, errors.New(constants.FailedToFetchLoginOTP)
+	}
+
+	var record model.LoginOTP
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", constants.FailedToFetchLoginOTP, err)
+	}
+	return &record, nil
+}
+
+func (r *loginOTPRepository) DeleteLoginOTP(ctx context.Context, accountID string) error {
+	err := r.store.Delete(r.otpKey(accountID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return ErrLoginOTPNotFound
+		}
+		logger.Error(constants.FailedToStoreLoginOTP, err, map[string]interface{}{
+			"method":     constants.Methods.DeleteLoginOTP,
+			"account_id": accountID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *loginOTPRepository) otpKey(accountID string) string {
+	return fmt.Sprintf("%s:%s", constants.LoginOTP_key, accountID)
+}
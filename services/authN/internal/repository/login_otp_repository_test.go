@@ -0,0 +1,42 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+func setupLoginOTPRepo() repository.LoginOTPRepository {
+	return repository.NewLoginOTPRepository(store.NewLightningDB(nil))
+}
+
+func TestSaveLoginOTP_And_GetLoginOTP(t *testing.T) {
+	repo := setupLoginOTPRepo()
+	ctx := context.Background()
+
+	now := time.Now()
+	err := repo.SaveLoginOTP(ctx, &model.LoginOTP{AccountID: "acc-1", CodeHash: "hash-1", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	got, err := repo.GetLoginOTP(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got.CodeHash != "hash-1" {
+		t.Fatalf("unexpected record returned: %+v", got)
+	}
+
+	if err := repo.DeleteLoginOTP(ctx, "acc-1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := repo.GetLoginOTP(ctx, "acc-1"); !errors.Is(err, repository.ErrLoginOTPNotFound) {
+		t.Fatalf("expected ErrLoginOTPNotFound, got: %v", err)
+	}
+}
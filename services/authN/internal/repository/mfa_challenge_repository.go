@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+var ErrMFAChallengeNotFound = errors.New(constants.MFAChallengeInvalid)
+
+// MFAChallengeRepository keeps the pending second-factor step of a login, keyed by the challenge token
+type MFAChallengeRepository interface {
+	SaveChallenge(ctx context.Context, token string, challenge *model.MFAChallenge) error
+	GetChallenge(ctx context.Context, token string) (*model.MFAChallenge, error)
+	DeleteChallenge(ctx context.Context, token string) error
+}
+
+type mfaChallengeRepository struct {
+	store store.InMemoryStore
+}
+
+func NewMFAChallengeRepository(s store.InMemoryStore) MFAChallengeRepository {
+	return &mfaChallengeRepository{
+		store: s,
+	}
+}
+
+// SaveChallenge stores the challenge until it expires, saving again keeps the original expiry
+func (r *mfaChallengeRepository) SaveChallenge(ctx context.Context, token string, challenge *model.MFAChallenge) error {
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return ErrMFAChallengeNotFound
+	}
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+
+	err = r.store.SetWithTTL(r.challengeKey(token), string(data), ttl)
+	if err != nil {
+		logger.Error(constants.FailedToStoreChallenge, err, map[string]interface{}{
+			"method":     constants.Methods.SaveMFAChallenge,
+			"account_id": challenge.AccountID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *mfaChallengeRepository) GetChallenge(ctx context.Context, token string) (*model.MFAChallenge, error) {
+	val, err := r.store.Get(r.challengeKey(token))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return nil, ErrMFAChallengeNotFound
+		}
+		logger.Error(constants.FailedToFetchChallenge, err, map[string]interface{}{
+			"method": constants.Methods.GetMFAChallenge,
+		})
+		return nil, err
+	}
+
+	var raw []byte
+	switch v := val.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, errors.New(constants.FailedToFetchChallenge)
+	}
+
+	var challenge model.MFAChallenge
+	if err := json.Unmarshal(raw, &challenge); err != nil {
+		return nil, fmt.Errorf("%s: %w", constants.FailedToFetchChallenge, err)
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return nil, ErrMFAChallengeNotFound
+	}
+	return &challenge, nil
+}
+
+func (r *mfaChallengeRepository) DeleteChallenge(ctx context.Context, token string) error {
+	err := r.store.Delete(r.challengeKey(token))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return ErrMFAChallengeNotFound
+		}
+		logger.Error(constants.FailedToStoreChallenge, err, map[string]interface{}{
+			"method": constants.Methods.DeleteMFAChallenge,
+		})
+		return err
+	}
+	return nil
+}
+
+// challengeKey only keeps a hash of the token, a dump of the store can't be used to finish a login
+func (r *mfaChallengeRepository) challengeKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("%s:%s", constants.MFAChallenge_key, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+func setupMFAChallengeRepo() repository.MFAChallengeRepository {
+	return repository.NewMFAChallengeRepository(store.NewLightningDB(nil))
+}
+
+func TestSaveChallenge_And_GetChallenge(t *testing.T) {
+	repo := setupMFAChallengeRepo()
+	ctx := context.Background()
+
+	challenge := &model.MFAChallenge{AccountID: "acc-1", LoginID: "login-1", ExpiresAt: time.Now().Add(time.Minute)}
+	if err := repo.SaveChallenge(ctx, "token-1", challenge); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	got, err := repo.GetChallenge(ctx, "token-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got.AccountID != "acc-1" || got.LoginID != "login-1" {
+		t.Fatalf("unexpected challenge returned: %+v", got)
+	}
+
+	if _, err := repo.GetChallenge(ctx, "token-2"); !errors.Is(err, repository.ErrMFAChallengeNotFound) {
+		t.Fatalf("expected ErrMFAChallengeNotFound for an unknown token, got: %v", err)
+	}
+}
+
+func TestDeleteChallenge(t *testing.T) {
+	repo := setupMFAChallengeRepo()
+	ctx := context.Background()
+
+	_ = repo.SaveChallenge(ctx, "token-1", &model.MFAChallenge{AccountID: "acc-1", ExpiresAt: time.Now().Add(time.Minute)})
+	if err := repo.DeleteChallenge(ctx, "token-1"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := repo.GetChallenge(ctx, "token-1"); !errors.Is(err, repository.ErrMFAChallengeNotFound) {
+		t.Fatalf("expected the challenge to be gone, got: %v", err)
+	}
+}
+
+func TestSaveChallenge_Expired(t *testing.T) {
+	repo := setupMFAChallengeRepo()
+
+	err := repo.SaveChallenge(context.Background(), "token-1", &model.MFAChallenge{AccountID: "acc-1", ExpiresAt: time.Now().Add(-time.Second)})
+	if !errors.Is(err, repository.ErrMFAChallengeNotFound) {
+		t.Fatalf("expected an expired challenge to be refused, got: %v", err)
+	}
+}
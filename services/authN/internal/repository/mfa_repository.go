@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/dbutils"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/lib/pq"
+)
+
+var ErrMFANotEnrolled = errors.New(constants.MFANotEnrolled)
+
+// MFARepository keeps the TOTP enrollment of team accounts in outlet.account_mfa
+type MFARepository interface {
+	GetMFA(ctx context.Context, accountID string) (*model.MFAEnrollment, error)
+	SaveMFA(ctx context.Context, enrollment *model.MFAEnrollment) error
+}
+
+type mfaRepository struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) MFARepository {
+	return &mfaRepository{
+		db: db,
+	}
+}
+
+var mfaColumns = []string{
+	"account_id",
+	"totp_secret",
+	"recovery_codes",
+	"last_used_step",
+	"confirmed_at",
+	"created_at",
+}
+
+// GetMFA returns ErrMFANotEnrolled when the account never started an enrollment
+func (r *mfaRepository) GetMFA(ctx context.Context, accountID string) (*model.MFAEnrollment, error) {
+	var method = constants.Methods.GetMFA
+	var table = constants.DB.Table_Account_MFA
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return nil, err
+	}
+
+	conditions := map[string]any{
+		"account_id": accountID,
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table},
+			Columns: mfaColumns,
+		},
+	}
+
+	query, args, err := dbutils.BuildSelectQuery(method, schema_outlet, table, mfaColumns, conditions, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var enrollment model.MFAEnrollment
+	var confirmedAt sql.NullTime
+	err = dbutils.ExecuteAndScanRow(ctx, method, r.db, query, args,
+		&enrollment.AccountID,
+		&enrollment.Secret,
+		pq.Array(&enrollment.RecoveryCodes),
+		&enrollment.LastUsedStep,
+		&confirmedAt,
+		&enrollment.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		enrollment.ConfirmedAt = &confirmedAt.Time
+	}
+	return &enrollment, nil
+}
+
+// SaveMFA inserts the enrollment or replaces the existing one of the account
+func (r *mfaRepository) SaveMFA(ctx context.Context, enrollment *model.MFAEnrollment) error {
+	var method = constants.Methods.SaveMFA
+	var table = constants.DB.Table_Account_MFA
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return err
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table},
+			Columns: mfaColumns,
+		},
+	}
+
+	query, err := dbutils.BuildInsertQuery(method, schema_outlet, table, mfaColumns, opts)
+	if err != nil {
+		return err
+	}
+	query += ` ON CONFLICT ("account_id") DO UPDATE SET "totp_secret" = EXCLUDED."totp_secret",` +
+		` "recovery_codes" = EXCLUDED."recovery_codes", "last_used_step" = EXCLUDED."last_used_step",` +
+		` "confirmed_at" = EXCLUDED."confirmed_at", "created_at" = EXCLUDED."created_at",` +
+		fmt.Sprintf(` "updated_at" = $%d`, len(mfaColumns)+1)
+
+	var confirmedAt sql.NullTime
+	if enrollment.ConfirmedAt != nil {
+		confirmedAt = sql.NullTime{Time: *enrollment.ConfirmedAt, Valid: true}
+	}
+	_, err = r.db.ExecContext(ctx, query,
+		enrollment.AccountID,
+		enrollment.Secret,
+		pq.Array(enrollment.RecoveryCodes),
+		enrollment.LastUsedStep,
+		confirmedAt,
+		enrollment.CreatedAt,
+		time.Now())
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return err
+	}
+	return nil
+}
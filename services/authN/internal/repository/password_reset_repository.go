@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+var ErrResetCodeNotFound = errors.New(constants.ResetCodeNotFound)
+
+// PasswordResetRepository keeps at most one pending reset code per account
+type PasswordResetRepository interface {
+	SaveResetCode(ctx context.Context, record *model.PasswordResetCode) error
+	GetResetCode(ctx context.Context, accountID string) (*model.PasswordResetCode, error)
+	DeleteResetCode(ctx context.Context, accountID string) error
+}
+
+type passwordResetRepository struct {
+	store store.InMemoryStore
+}
+
+func NewPasswordResetRepository(s store.InMemoryStore) PasswordResetRepository {
+	return &passwordResetRepository{
+		store: s,
+	}
+}
+
+// SaveResetCode stores the record until it expires, saving again replaces the previous code
+func (r *passwordResetRepository) SaveResetCode(ctx context.Context, record *model.PasswordResetCode) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return ErrResetCodeNotFound
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	err = r.store.SetWithTTL(r.resetKey(record.AccountID), string(data), ttl)
+	if err != nil {
+		logger.Error(constants.FailedToStoreResetCode, err, map[string]interface{}{
+			"method":     constants.Methods.SaveResetCode,
+			"account_id": record.AccountID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *passwordResetRepository) GetResetCode(ctx context.Context, accountID string) (*model.PasswordResetCode, error) {
+	val, err := r.store.Get(r.resetKey(accountID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return nil, ErrResetCodeNotFound
+		}
+		logger.Error(constants.FailedToFetchResetCode, err, map[string]interface{}{
+			"method":     constants.Methods.GetResetCode,
+			"account_id": accountID,
+		})
+		return nil, err
+	}
+
+	var raw []byte
+	switch v := val.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, errors.New(constants.FailedToFetchResetCode)
+	}
+
+	var record model.PasswordResetCode
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", constants.FailedToFetchResetCode, err)
+	}
+	return &record, nil
+}
+
+func (r *passwordResetRepository) DeleteResetCode(ctx context.Context, accountID string) error {
+	err := r.store.Delete(r.resetKey(accountID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return ErrResetCodeNotFound
+		}
+		logger.Error(constants.FailedToStoreResetCode, err, map[string]interface{}{
+			"method":     constants.Methods.DeleteResetCode,
+			"account_id": accountID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *passwordResetRepository) resetKey(accountID string) string {
+	return fmt.Sprintf("%s:%s", constants.Reset_key, accountID)
+}
@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+func setupPasswordResetRepo() repository.PasswordResetRepository {
+	return repository.NewPasswordResetRepository(store.NewLightningDB(nil))
+}
+
+func TestSaveResetCode_And_GetResetCode(t *testing.T) {
+	repo := setupPasswordResetRepo()
+	ctx := context.Background()
+
+	now := time.Now()
+	err := repo.SaveResetCode(ctx, &model.PasswordResetCode{AccountID: "acc-1", CodeHash: "hash-1", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	got, err := repo.GetResetCode(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got.CodeHash != "hash-1" || got.Attempts != 0 {
+		t.Fatalf("unexpected record returned: %+v", got)
+	}
+
+	// a new code replaces the pending one
+	_ = repo.SaveResetCode(ctx, &model.PasswordResetCode{AccountID: "acc-1", CodeHash: "hash-2", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	got, _ = repo.GetResetCode(ctx, "acc-1")
+	if got.CodeHash != "hash-2" {
+		t.Fatalf("expected the second code to replace the first, got: %+v", got)
+	}
+}
+
+func TestDeleteResetCode_SingleUse(t *testing.T) {
+	repo := setupPasswordResetRepo()
+	ctx := context.Background()
+
+	now := time.Now()
+	_ = repo.SaveResetCode(ctx, &model.PasswordResetCode{AccountID: "acc-1", CodeHash: "hash-1", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+
+	if err := repo.DeleteResetCode(ctx, "acc-1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := repo.DeleteResetCode(ctx, "acc-1"); !errors.Is(err, repository.ErrResetCodeNotFound) {
+		t.Fatalf("expected ErrResetCodeNotFound deleting twice, got: %v", err)
+	}
+	if _, err := repo.GetResetCode(ctx, "acc-1"); !errors.Is(err, repository.ErrResetCodeNotFound) {
+		t.Fatalf("expected ErrResetCodeNotFound, got: %v", err)
+	}
+}
+
+func TestSaveResetCode_Expired(t *testing.T) {
+	repo := setupPasswordResetRepo()
+
+	now := time.Now()
+	err := repo.SaveResetCode(context.Background(), &model.PasswordResetCode{AccountID: "acc-1", CodeHash: "hash-1", CreatedAt: now, ExpiresAt: now.Add(-time.Second)})
+	if !errors.Is(err, repository.ErrResetCodeNotFound) {
+		t.Fatalf("expected an expired code to be rejected, got: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+var ErrSessionNotFound = errors.New(constants.SessionNotFound)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *model.Session, expiry time.Duration) error
+	GetSession(ctx context.Context, accountID, sessionID string) (*model.Session, error)
+	ListSessions(ctx context.Context, accountID string) ([]*model.Session, error)
+	DeleteSession(ctx context.Context, accountID, sessionID string) error
+	TouchSession(ctx context.Context, session *model.Session, at time.Time) error
+}
+
+type sessionRepository struct {
+	store store.InMemoryStore
+}
+
+func NewSessionRepository(s store.InMemoryStore) SessionRepository {
+	return &sessionRepository{
+		store: s,
+	}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *model.Session, expiry time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	key := r.sessionKey(session.AccountID, session.ID)
+	if expiry > 0 {
+		err = r.store.SetWithTTL(key, string(data), expiry)
+	} else {
+		err = r.store.Set(key, string(data))
+	}
+	if err != nil {
+		logger.Error(constants.FailedToStoreSession, err, map[string]interface{}{
+			"method":     constants.Methods.CreateSession,
+			"account_id": session.AccountID,
+		})
+		return err
+	}
+
+	logger.Info(constants.SessionCreated, map[string]interface{}{
+		"method":     constants.Methods.CreateSession,
+		"account_id": session.AccountID,
+		"session_id": session.ID,
+	})
+	return nil
+}
+
+func (r *sessionRepository) GetSession(ctx context.Context, accountID, sessionID string) (*model.Session, error) {
+	val, err := r.store.Get(r.sessionKey(accountID, sessionID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		logger.Error(constants.FailedToFetchSession, err, map[string]interface{}{
+			"method":     constants.Methods.GetSession,
+			"account_id": accountID,
+		})
+		return nil, err
+	}
+	return decodeSession(val)
+}
+
+func (r *sessionRepository) ListSessions(ctx context.Context, accountID string) ([]*model.Session, error) {
+	prefix := r.sessionKey(accountID, "")
+	keys, err := r.store.Keys(prefix + "*")
+	if err != nil {
+		logger.Error(constants.FailedToFetchSession, err, map[string]interface{}{
+			"method":     constants.Methods.ListSessions,
+			"account_id": accountID,
+		})
+		return nil, err
+	}
+
+	sessions := make([]*model.Session, 0, len(keys))
+	for _, key := range keys {
+		// not every store honours the pattern, so filter the prefix here as well
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		val, err := r.store.Get(key)
+		if err != nil {
+			if errors.Is(err, store.ErrKeyNotFound) {
+				continue // expired between Keys and Get
+			}
+			return nil, err
+		}
+		session, err := decodeSession(val)
+		if err != nil {
+			logger.Warn(constants.FailedToDecodeSession, map[string]interface{}{
+				"method": constants.Methods.ListSessions,
+				"key":    key,
+			})
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) DeleteSession(ctx context.Context, accountID, sessionID string) error {
+	err := r.store.Delete(r.sessionKey(accountID, sessionID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return ErrSessionNotFound
+		}
+		logger.Error(constants.FailedToDeleteSession, err, map[string]interface{}{
+			"method":     constants.Methods.DeleteSession,
+			"account_id": accountID,
+		})
+		return err
+	}
+	return nil
+}
+
+// TouchSession stores the session again with LastActivityAt set to at, the expiry stays the same
+func (r *sessionRepository) TouchSession(ctx context.Context, session *model.Session, at time.Time) error {
+	remaining := time.Until(session.ExpiresAt)
+	if remaining <= 0 {
+		return ErrSessionNotFound
+	}
+	session.LastActivityAt = at
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := r.store.SetWithTTL(r.sessionKey(session.AccountID, session.ID), string(data), remaining); err != nil {
+		logger.Error(constants.FailedToTouchSession, err, map[string]interface{}{
+			"method":     constants.Methods.TouchSession,
+			"account_id": session.AccountID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *sessionRepository) sessionKey(accountID, sessionID string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.Session_key, accountID, sessionID)
+}
+
+func decodeSession(val interface{}) (*model.Session, error) {
+	var raw []byte
+	switch v := val.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, errors.New(constants.FailedToDecodeSession)
+	}
+
+	var session model.Session
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return nil, fmt.Errorf("%s: %w", constants.FailedToDecodeSession, err)
+	}
+	return &session, nil
+}
@@ -0,0 +1,139 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+)
+
+func setupSessionRepo() repository.SessionRepository {
+	return repository.NewSessionRepository(store.NewLightningDB(nil))
+}
+
+func newTestSession(accountID, sessionID, device string) *model.Session {
+	now := time.Now()
+	return &model.Session{
+		ID:          sessionID,
+		AccountID:   accountID,
+		AccountType: "food_packer",
+		DeviceLabel: device,
+		IPAddress:   "10.0.0.1",
+		UserAgent:   "zrms-tablet/1.0",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+}
+
+func TestCreateSession_And_GetSession(t *testing.T) {
+	repo := setupSessionRepo()
+	ctx := context.Background()
+
+	session := newTestSession("acc-1", "sess-1", "tablet-1")
+	if err := repo.CreateSession(ctx, session, time.Hour); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	got, err := repo.GetSession(ctx, "acc-1", "sess-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got.DeviceLabel != "tablet-1" || got.IPAddress != "10.0.0.1" || got.UserAgent != "zrms-tablet/1.0" {
+		t.Fatalf("unexpected session returned: %+v", got)
+	}
+}
+
+func TestListSessions_MultipleDevices(t *testing.T) {
+	repo := setupSessionRepo()
+	ctx := context.Background()
+
+	_ = repo.CreateSession(ctx, newTestSession("acc-1", "sess-1", "tablet-1"), time.Hour)
+	_ = repo.CreateSession(ctx, newTestSession("acc-1", "sess-2", "tablet-2"), time.Hour)
+	_ = repo.CreateSession(ctx, newTestSession("acc-2", "sess-3", "phone"), time.Hour)
+
+	sessions, err := repo.ListSessions(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions for acc-1, got %d", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.AccountID != "acc-1" {
+			t.Fatalf("session of another account returned: %+v", s)
+		}
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	repo := setupSessionRepo()
+	ctx := context.Background()
+
+	_ = repo.CreateSession(ctx, newTestSession("acc-1", "sess-1", "tablet-1"), time.Hour)
+
+	if err := repo.DeleteSession(ctx, "acc-1", "sess-1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	_, err := repo.GetSession(ctx, "acc-1", "sess-1")
+	if !errors.Is(err, repository.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got: %v", err)
+	}
+
+	err = repo.DeleteSession(ctx, "acc-1", "sess-1")
+	if !errors.Is(err, repository.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound deleting twice, got: %v", err)
+	}
+}
+
+func TestSession_Expires(t *testing.T) {
+	repo := setupSessionRepo()
+	ctx := context.Background()
+
+	_ = repo.CreateSession(ctx, newTestSession("acc-1", "sess-1", "tablet-1"), 1*time.Second)
+	time.Sleep(2 * time.Second)
+
+	_, err := repo.GetSession(ctx, "acc-1", "sess-1")
+	if !errors.Is(err, repository.ErrSessionNotFound) {
+		t.Fatalf("expected session to be expired, got: %v", err)
+	}
+
+	sessions, err := repo.ListSessions(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("expected no sessions after expiry, got %d", len(sessions))
+	}
+}
+
+func TestTouchSession(t *testing.T) {
+	repo := setupSessionRepo()
+	ctx := context.Background()
+
+	session := newTestSession("acc-1", "sess-1", "tablet-1")
+	_ = repo.CreateSession(ctx, session, time.Hour)
+
+	at := session.CreatedAt.Add(10 * time.Minute)
+	if err := repo.TouchSession(ctx, session, at); err != nil {
+		t.Fatalf("touch failed: %v", err)
+	}
+
+	got, err := repo.GetSession(ctx, "acc-1", "sess-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !got.LastActive().Equal(at) || !got.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Fatalf("expected last activity %v and unchanged expiry, got %+v", at, got)
+	}
+
+	expired := newTestSession("acc-1", "sess-2", "tablet-2")
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	if err := repo.TouchSession(ctx, expired, time.Now()); !errors.Is(err, repository.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound for an expired session, got: %v", err)
+	}
+}
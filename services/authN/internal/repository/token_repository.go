@@ -2,18 +2,44 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ashish19912009/zrms/services/authN/internal/constants"
 	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
 	"github.com/ashish19912009/zrms/services/authN/internal/store"
 )
 
+var (
+	ErrRefreshRecordNotFound = errors.New(constants.RefreshRecordNotFound)
+	ErrRefreshRecordClaimed  = errors.New(constants.RefreshRecordClaimed)
+)
+
+// values of the claim of a refresh token, see ClaimRefreshRecord
+const (
+	refreshClaimed = "claimed"
+	refreshReused  = "reused"
+)
+
 type TokenRepository interface {
 	StoreToken(ctx context.Context, keyName, accountID string, token string, expire time.Duration) error
 	CheckToken(ctx context.Context, keyName, accountID string, token string) (bool, error)
 	DeleteToken(ctx context.Context, keyName, accountID string) error
+	StoreRefreshRecord(ctx context.Context, record *model.RefreshTokenRecord) error
+	GetRefreshRecord(ctx context.Context, familyID, jti string) (*model.RefreshTokenRecord, error)
+	ClaimRefreshRecord(ctx context.Context, record *model.RefreshTokenRecord) error
+	MarkRefreshRecordReused(ctx context.Context, record *model.RefreshTokenRecord) error
+	IsRefreshRecordReused(ctx context.Context, record *model.RefreshTokenRecord) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	DenyToken(ctx context.Context, jti string, expiry time.Duration) error
+	IsTokenDenied(ctx context.Context, jti string) (bool, error)
+	RevokeTokensBefore(ctx context.Context, accountID string, before time.Time, expiry time.Duration) error
+	GetRevokedBefore(ctx context.Context, accountID string) (time.Time, error)
 }
 
 type tokenRepository struct {
@@ -99,6 +125,239 @@ func (r *tokenRepository) DeleteToken(ctx context.Context, keyName, accountID st
 	return nil
 }
 
+// StoreRefreshRecord saves the record until the refresh token itself expires,
+// so a rotated token can still be recognised if it is presented again
+func (r *tokenRepository) StoreRefreshRecord(ctx context.Context, record *model.RefreshTokenRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	key := r.familyKey(record.FamilyID, record.JTI)
+	ttl := time.Until(record.ExpiresAt)
+	if ttl > 0 {
+		err = r.store.SetWithTTL(key, string(data), ttl)
+	} else {
+		err = r.store.Set(key, string(data))
+	}
+	if err != nil {
+		logger.Error(constants.FailedToStoreRshRecord, err, map[string]interface{}{
+			"method":     constants.Methods.StoreRefreshRecord,
+			"account_id": record.AccountID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *tokenRepository) GetRefreshRecord(ctx context.Context, familyID, jti string) (*model.RefreshTokenRecord, error) {
+	val, err := r.store.Get(r.familyKey(familyID, jti))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return nil, ErrRefreshRecordNotFound
+		}
+		logger.Error(constants.RedisOperationFailed, err, map[string]interface{}{
+			"method":    constants.Methods.GetRefreshRecord,
+			"family_id": familyID,
+		})
+		return nil, err
+	}
+
+	var raw []byte
+	switch v := val.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, ErrRefreshRecordNotFound
+	}
+
+	var record model.RefreshTokenRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ClaimRefreshRecord marks the refresh token as used before it is rotated. The claim is set only
+// if absent, so of concurrent refreshes with the same token exactly one gets past it, the others
+// get ErrRefreshRecordClaimed. It is kept apart from the family so RevokeFamily can't free it again.
+func (r *tokenRepository) ClaimRefreshRecord(ctx context.Context, record *model.RefreshTokenRecord) error {
+	err := r.store.SetIfNotExists(r.claimKey(record.FamilyID, record.JTI), refreshClaimed, time.Until(record.ExpiresAt))
+	if errors.Is(err, store.ErrKeyAlreadyExists) {
+		return ErrRefreshRecordClaimed
+	}
+	if err != nil {
+		logger.Error(constants.FailedToStoreRshRecord, err, map[string]interface{}{
+			"method":    constants.Methods.ClaimRefreshRecord,
+			"family_id": record.FamilyID,
+		})
+		return err
+	}
+	return nil
+}
+
+// MarkRefreshRecordReused records on the claim that the token came back after it was claimed,
+// the request that holds the claim checks it once its rotation is written
+func (r *tokenRepository) MarkRefreshRecordReused(ctx context.Context, record *model.RefreshTokenRecord) error {
+	err := r.store.SetWithTTL(r.claimKey(record.FamilyID, record.JTI), refreshReused, time.Until(record.ExpiresAt))
+	if err != nil {
+		logger.Error(constants.FailedToStoreRshRecord, err, map[string]interface{}{
+			"method":    constants.Methods.ClaimRefreshRecord,
+			"family_id": record.FamilyID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (r *tokenRepository) IsRefreshRecordReused(ctx context.Context, record *model.RefreshTokenRecord) (bool, error) {
+	val, err := r.store.Get(r.claimKey(record.FamilyID, record.JTI))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return false, nil
+		}
+		logger.Error(constants.RedisOperationFailed, err, map[string]interface{}{
+			"method":    constants.Methods.ClaimRefreshRecord,
+			"family_id": record.FamilyID,
+		})
+		return false, err
+	}
+	return val == refreshReused, nil
+}
+
+// RevokeFamily drops every refresh token record of the family, after which none of them can be used again
+func (r *tokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	prefix := r.familyKey(familyID, "")
+	keys, err := r.store.Keys(prefix + "*")
+	if err != nil {
+		logger.Error(constants.FailedToRevokeFamily, err, map[string]interface{}{
+			"method":    constants.Methods.RevokeTokenFamily,
+			"family_id": familyID,
+		})
+		return err
+	}
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := r.store.Delete(key); err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+			logger.Error(constants.FailedToRevokeFamily, err, map[string]interface{}{
+				"method":    constants.Methods.RevokeTokenFamily,
+				"family_id": familyID,
+			})
+			return err
+		}
+	}
+
+	logger.Info(constants.TokenFamilyRevoked, map[string]interface{}{
+		"method":    constants.Methods.RevokeTokenFamily,
+		"family_id": familyID,
+	})
+	return nil
+}
+
+// DenyToken puts a jti on the denylist; expiry should be the remaining lifetime of the token
+// so the entry disappears together with the token it blocks
+func (r *tokenRepository) DenyToken(ctx context.Context, jti string, expiry time.Duration) error {
+	if expiry <= 0 {
+		return nil // already expired, nothing left to block
+	}
+	err := r.store.SetWithTTL(r.denylistKey(jti), "1", expiry)
+	if err != nil {
+		logger.Error(constants.FailedToDenyToken, err, map[string]interface{}{
+			"method": constants.Methods.DenyToken,
+			"jti":    jti,
+		})
+		return err
+	}
+
+	logger.Info(constants.TokenDenied, map[string]interface{}{
+		"method": constants.Methods.DenyToken,
+		"jti":    jti,
+	})
+	return nil
+}
+
+func (r *tokenRepository) IsTokenDenied(ctx context.Context, jti string) (bool, error) {
+	exists, err := r.store.Exists(r.denylistKey(jti))
+	if err != nil {
+		logger.Error(constants.FailedToCheckDenylist, err, map[string]interface{}{
+			"method": constants.Methods.IsTokenDenied,
+			"jti":    jti,
+		})
+		return false, err
+	}
+	return exists, nil
+}
+
+// RevokeTokensBefore invalidates every token of the account issued at or before the given time
+func (r *tokenRepository) RevokeTokensBefore(ctx context.Context, accountID string, before time.Time, expiry time.Duration) error {
+	value := strconv.FormatInt(before.Unix(), 10)
+	var err error
+	if expiry > 0 {
+		err = r.store.SetWithTTL(r.revokedKey(accountID), value, expiry)
+	} else {
+		err = r.store.Set(r.revokedKey(accountID), value)
+	}
+	if err != nil {
+		logger.Error(constants.FailedToRevokeAccount, err, map[string]interface{}{
+			"method":     constants.Methods.RevokeAccountTokens,
+			"account_id": accountID,
+		})
+		return err
+	}
+	return nil
+}
+
+// GetRevokedBefore returns the zero time when the account has no revocation in place
+func (r *tokenRepository) GetRevokedBefore(ctx context.Context, accountID string) (time.Time, error) {
+	val, err := r.store.Get(r.revokedKey(accountID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return time.Time{}, nil
+		}
+		logger.Error(constants.FailedToCheckDenylist, err, map[string]interface{}{
+			"method":     constants.Methods.IsTokenDenied,
+			"account_id": accountID,
+		})
+		return time.Time{}, err
+	}
+
+	var raw string
+	switch v := val.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return time.Time{}, errors.New(constants.FailedToCheckDenylist)
+	}
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0), nil
+}
+
 func (r *tokenRepository) tokenKey(keyName string, accountID string) string {
 	return fmt.Sprintf("%s:%s", accountID, keyName)
 }
+
+func (r *tokenRepository) familyKey(familyID, jti string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.Family_key, familyID, jti)
+}
+
+func (r *tokenRepository) claimKey(familyID, jti string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.Claim_key, familyID, jti)
+}
+
+func (r *tokenRepository) denylistKey(jti string) string {
+	return fmt.Sprintf("%s:%s", constants.Denylist_key, jti)
+}
+
+func (r *tokenRepository) revokedKey(accountID string) string {
+	return fmt.Sprintf("%s:%s", constants.Revoked_key, accountID)
+}
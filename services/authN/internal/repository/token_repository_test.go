@@ -2,10 +2,12 @@ package repository_test
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
 	"github.com/ashish19912009/zrms/services/authN/internal/repository"
 	"github.com/ashish19912009/zrms/services/authN/internal/store"
 )
@@ -163,3 +165,167 @@ func TestConcurrentAccess(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestRefreshRecord_RotationChain(t *testing.T) {
+	repo := setupTestRepo()
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	parent := &model.RefreshTokenRecord{JTI: "jti-1", FamilyID: "fam-1", AccountID: "emp123", ExpiresAt: expires}
+	if err := repo.StoreRefreshRecord(ctx, parent); err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+	child := &model.RefreshTokenRecord{JTI: "jti-2", ParentJTI: "jti-1", FamilyID: "fam-1", AccountID: "emp123", ExpiresAt: expires}
+	if err := repo.StoreRefreshRecord(ctx, child); err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+	parent.RotatedTo = child.JTI
+	if err := repo.StoreRefreshRecord(ctx, parent); err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+
+	got, err := repo.GetRefreshRecord(ctx, "fam-1", "jti-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got.RotatedTo != "jti-2" {
+		t.Fatalf("expected parent to point at jti-2, got %q", got.RotatedTo)
+	}
+
+	got, err = repo.GetRefreshRecord(ctx, "fam-1", "jti-2")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got.ParentJTI != "jti-1" || got.RotatedTo != "" {
+		t.Fatalf("unexpected child record: %+v", got)
+	}
+}
+
+func TestClaimRefreshRecord(t *testing.T) {
+	repo := setupTestRepo()
+	ctx := context.Background()
+	record := &model.RefreshTokenRecord{JTI: "jti-1", FamilyID: "fam-1", AccountID: "emp123", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.StoreRefreshRecord(ctx, record); err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- repo.ClaimRefreshRecord(ctx, record)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	claimed := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			claimed++
+		case !errors.Is(err, repository.ErrRefreshRecordClaimed):
+			t.Fatalf("expected ErrRefreshRecordClaimed, got: %v", err)
+		}
+	}
+	if claimed != 1 {
+		t.Fatalf("expected exactly one claim, got %d", claimed)
+	}
+
+	if ok, err := repo.IsRefreshRecordReused(ctx, record); err != nil || ok {
+		t.Fatalf("expected no reuse yet, got %v, %v", ok, err)
+	}
+	if err := repo.MarkRefreshRecordReused(ctx, record); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+	if ok, err := repo.IsRefreshRecordReused(ctx, record); err != nil || !ok {
+		t.Fatalf("expected the reuse to be recorded, got %v, %v", ok, err)
+	}
+
+	// revoking the family must not free the token for another claim
+	if err := repo.RevokeFamily(ctx, "fam-1"); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if err := repo.ClaimRefreshRecord(ctx, record); !errors.Is(err, repository.ErrRefreshRecordClaimed) {
+		t.Fatalf("expected ErrRefreshRecordClaimed after revocation, got: %v", err)
+	}
+}
+
+func TestRevokeFamily(t *testing.T) {
+	repo := setupTestRepo()
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	_ = repo.StoreRefreshRecord(ctx, &model.RefreshTokenRecord{JTI: "jti-1", FamilyID: "fam-1", ExpiresAt: expires})
+	_ = repo.StoreRefreshRecord(ctx, &model.RefreshTokenRecord{JTI: "jti-2", FamilyID: "fam-1", ExpiresAt: expires})
+	_ = repo.StoreRefreshRecord(ctx, &model.RefreshTokenRecord{JTI: "jti-3", FamilyID: "fam-2", ExpiresAt: expires})
+
+	if err := repo.RevokeFamily(ctx, "fam-1"); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+
+	for _, jti := range []string{"jti-1", "jti-2"} {
+		if _, err := repo.GetRefreshRecord(ctx, "fam-1", jti); !errors.Is(err, repository.ErrRefreshRecordNotFound) {
+			t.Fatalf("expected %s to be revoked, got: %v", jti, err)
+		}
+	}
+	if _, err := repo.GetRefreshRecord(ctx, "fam-2", "jti-3"); err != nil {
+		t.Fatalf("expected other family to be untouched, got: %v", err)
+	}
+}
+
+func TestDenyToken(t *testing.T) {
+	repo := setupTestRepo()
+	ctx := context.Background()
+
+	if err := repo.DenyToken(ctx, "jti-1", 1*time.Second); err != nil {
+		t.Fatalf("deny failed: %v", err)
+	}
+
+	denied, err := repo.IsTokenDenied(ctx, "jti-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !denied {
+		t.Fatal("expected jti to be denylisted")
+	}
+
+	time.Sleep(2 * time.Second)
+
+	denied, err = repo.IsTokenDenied(ctx, "jti-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if denied {
+		t.Fatal("expected denylist entry to expire with the token")
+	}
+}
+
+func TestRevokeTokensBefore(t *testing.T) {
+	repo := setupTestRepo()
+	ctx := context.Background()
+
+	before, err := repo.GetRevokedBefore(ctx, "emp123")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !before.IsZero() {
+		t.Fatalf("expected no revocation, got %v", before)
+	}
+
+	now := time.Now()
+	if err := repo.RevokeTokensBefore(ctx, "emp123", now, time.Hour); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+
+	before, err = repo.GetRevokedBefore(ctx, "emp123")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if before.Unix() != now.Unix() {
+		t.Fatalf("expected %v, got %v", now.Unix(), before.Unix())
+	}
+}
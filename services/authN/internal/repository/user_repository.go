@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ashish19912009/zrms/services/authN/internal/constants"
 	"github.com/ashish19912009/zrms/services/authN/internal/dbutils"
@@ -15,9 +18,20 @@ var schema_global = constants.DB.Schema_Global
 
 type UserRepository interface {
 	GetUser(ctx context.Context, loginID_accountID string, accountType string) (*model.User, error)
+	GetUserByID(ctx context.Context, accountID string) (*model.User, error)
+	GetUserByMobile(ctx context.Context, mobileNo string, accountType string) (*model.User, error)
 	GetFranchiseRolePermissions(ctx context.Context, franchiseID string) ([]*model.ResourceAction, error)
+	UpdateAccountStatus(ctx context.Context, accountID string, status string) error
+	UpdatePassword(ctx context.Context, accountID string, passwordHash string) error
+	AddPasswordHistory(ctx context.Context, accountID string, passwordHash string) error
+	GetPasswordHistory(ctx context.Context, accountID string, limit int) ([]string, error)
+	GetFranchiseStatus(ctx context.Context, franchiseID string) (*model.FranchiseStatus, error)
+	ListFranchiseAccountIDs(ctx context.Context, franchiseID string) ([]string, error)
+	ListFranchiseMemberships(ctx context.Context, accountID, ownerID string) ([]*model.FranchiseMembership, error)
 }
 
+var ErrFranchiseNotFound = errors.New(constants.FranchiseNotFound)
+
 type userRepository struct {
 	db *sql.DB
 }
@@ -29,7 +43,31 @@ func NewUserRepository(db *sql.DB) UserRepository {
 }
 
 func (r *userRepository) GetUser(ctx context.Context, indentifier string, accountType string) (*model.User, error) {
-	var method = constants.Methods.GetUser
+	conditions := map[string]any{
+		"login_id":     indentifier,
+		"account_type": accountType,
+	}
+	return r.getUser(ctx, constants.Methods.GetUser, conditions)
+}
+
+// GetUserByID looks an account up by the id tokens carry as their subject
+func (r *userRepository) GetUserByID(ctx context.Context, accountID string) (*model.User, error) {
+	conditions := map[string]any{
+		"id": accountID,
+	}
+	return r.getUser(ctx, constants.Methods.GetUserByID, conditions)
+}
+
+// GetUserByMobile looks an account up by team_accounts.mobile_no for OTP login
+func (r *userRepository) GetUserByMobile(ctx context.Context, mobileNo string, accountType string) (*model.User, error) {
+	conditions := map[string]any{
+		"mobile_no":    mobileNo,
+		"account_type": accountType,
+	}
+	return r.getUser(ctx, constants.Methods.GetUserByMobile, conditions)
+}
+
+func (r *userRepository) getUser(ctx context.Context, method string, conditions map[string]any) (*model.User, error) {
 	var table = constants.DB.Table_Franchise_Accounts
 	select {
 	case <-ctx.Done():
@@ -44,6 +82,7 @@ func (r *userRepository) GetUser(ctx context.Context, indentifier string, accoun
 	// Define columns with alias prefixes
 	columns := []string{
 		"id",
+		"login_id",
 		"franchise_id",
 		"employee_id",
 		"password_hash",
@@ -53,11 +92,8 @@ func (r *userRepository) GetUser(ctx context.Context, indentifier string, accoun
 		"email",
 		"role_id",
 		"status",
-	}
-
-	conditions := map[string]any{
-		"login_id":     indentifier,
-		"account_type": accountType,
+		"owner_id",
+		"deleted_at",
 	}
 
 	opts := &dbutils.QueryBuilderOptions{
@@ -83,9 +119,12 @@ func (r *userRepository) GetUser(ctx context.Context, indentifier string, accoun
 		"conditions": conditions,
 	})
 	var user model.User
+	var ownerID sql.NullString
+	var deletedAt sql.NullTime
 
 	if err := dbutils.ExecuteAndScanRow(ctx, method, r.db, query, args,
 		&user.AccountID,
+		&user.LoginID,
 		&user.FranchiseID,
 		&user.EmployeeID,
 		&user.Password,
@@ -94,17 +133,23 @@ func (r *userRepository) GetUser(ctx context.Context, indentifier string, accoun
 		&user.MobileNo,
 		&user.Email,
 		&user.RoleID,
-		&user.Status); err != nil {
+		&user.Status,
+		&ownerID,
+		&deletedAt); err != nil {
 		logger.Error(constants.DBQueryError, err, nil)
 		return nil, err
 	}
+	user.OwnerID = ownerID.String
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
 
 	return &user, nil
 }
 
 func (r *userRepository) GetFranchiseRolePermissions(ctx context.Context, franchiseID string) ([]*model.ResourceAction, error) {
 	var method = constants.Methods.GetFranchiseRolePermissions
-	var table = constants.DB.Table_Role
+	var table = constants.DB.Table_Roles
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -172,7 +217,7 @@ func (r *userRepository) GetFranchiseRolePermissions(ctx context.Context, franch
 	// Process the result
 	var allResourceMatrix []*model.ResourceAction
 	for rows.Next() {
-		var res *model.ResourceAction
+		res := &model.ResourceAction{}
 		err := rows.Scan(
 			&res.Resource,
 			&res.Action,
@@ -186,3 +231,406 @@ func (r *userRepository) GetFranchiseRolePermissions(ctx context.Context, franch
 
 	return allResourceMatrix, nil
 }
+
+// UpdateAccountStatus sets team_accounts.status, used to lock an account permanently and to unlock it again
+func (r *userRepository) UpdateAccountStatus(ctx context.Context, accountID string, status string) error {
+	return r.updateAccount(ctx, constants.Methods.UpdateAccountStatus, accountID, []string{"status"}, status)
+}
+
+// UpdatePassword replaces the password hash of an account
+func (r *userRepository) UpdatePassword(ctx context.Context, accountID string, passwordHash string) error {
+	return r.updateAccount(ctx, constants.Methods.UpdatePassword, accountID, []string{"password_hash"}, passwordHash)
+}
+
+// updateAccount sets the given columns of one team account and bumps updated_at
+func (r *userRepository) updateAccount(ctx context.Context, method, accountID string, columns []string, values ...any) error {
+	var table = constants.DB.Table_Franchise_Accounts
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return err
+	}
+
+	columns = append(columns, "updated_at")
+	values = append(values, time.Now())
+
+	conditions := map[string]any{
+		"id": accountID,
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table},
+			Columns: append([]string{"id"}, columns...),
+		},
+	}
+
+	query, args, err := dbutils.BuildUpdateQuery(method, schema_outlet, table, columns, conditions, opts)
+	if err != nil {
+		return err
+	}
+	copy(args, values)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddPasswordHistory remembers a hash that was replaced, ChangePassword refuses to set it again
+func (r *userRepository) AddPasswordHistory(ctx context.Context, accountID string, passwordHash string) error {
+	var method = constants.Methods.AddPasswordHistory
+	var table = constants.DB.Table_Password_History
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return err
+	}
+
+	columns := []string{
+		"account_id",
+		"password_hash",
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table},
+			Columns: columns,
+		},
+	}
+
+	query, err := dbutils.BuildInsertQuery(method, schema_outlet, table, columns, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, accountID, passwordHash); err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return err
+	}
+	return nil
+}
+
+// GetPasswordHistory returns the latest replaced hashes of an account, newest first
+func (r *userRepository) GetPasswordHistory(ctx context.Context, accountID string, limit int) ([]string, error) {
+	var method = constants.Methods.GetPasswordHistory
+	var table = constants.DB.Table_Password_History
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return nil, err
+	}
+
+	columns := []string{
+		"password_hash",
+	}
+
+	conditions := map[string]any{
+		"account_id": accountID,
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table},
+			Columns: append(columns, "account_id"),
+		},
+	}
+
+	query, args, err := dbutils.BuildSelectQuery(method, schema_outlet, table, columns, conditions, opts)
+	if err != nil {
+		return nil, err
+	}
+	query += fmt.Sprintf(` ORDER BY "created_at" DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// GetFranchiseStatus reads the lifecycle columns of outlet.franchises, login checks it for every account
+func (r *userRepository) GetFranchiseStatus(ctx context.Context, franchiseID string) (*model.FranchiseStatus, error) {
+	var method = constants.Methods.GetFranchiseStatus
+	var table = constants.DB.Table_Franchises
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return nil, err
+	}
+
+	columns := []string{
+		"id",
+		"status",
+		"deleted_at",
+	}
+
+	conditions := map[string]any{
+		"id": franchiseID,
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table},
+			Columns: columns,
+		},
+	}
+
+	query, args, err := dbutils.BuildSelectQuery(method, schema_outlet, table, columns, conditions, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var franchise model.FranchiseStatus
+	var franchiseStatus sql.NullString
+	var deletedAt sql.NullTime
+	err = dbutils.ExecuteAndScanRow(ctx, method, r.db, query, args,
+		&franchise.ID,
+		&franchiseStatus,
+		&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFranchiseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the column defaults to active, a NULL status is treated the same way
+	franchise.Status = constants.FranchiseStatusActive
+	if franchiseStatus.Valid && franchiseStatus.String != "" {
+		franchise.Status = franchiseStatus.String
+	}
+	if deletedAt.Valid {
+		franchise.DeletedAt = &deletedAt.Time
+	}
+	return &franchise, nil
+}
+
+// ListFranchiseAccountIDs returns the ids of every team account of the franchise, deleted ones included
+func (r *userRepository) ListFranchiseAccountIDs(ctx context.Context, franchiseID string) ([]string, error) {
+	var method = constants.Methods.ListFranchiseAccountIDs
+	var table = constants.DB.Table_Franchise_Accounts
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return nil, err
+	}
+
+	columns := []string{
+		"id",
+	}
+
+	conditions := map[string]any{
+		"franchise_id": franchiseID,
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table},
+			Columns: append(columns, "franchise_id"),
+		},
+	}
+
+	query, args, err := dbutils.BuildSelectQuery(method, schema_outlet, table, columns, conditions, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListFranchiseMemberships returns the franchises of the person behind an account. Accounts that share
+// an owner_id belong to one person, without an owner_id the account's own franchise is the only one.
+// Deleted accounts and deleted franchises are left out.
+func (r *userRepository) ListFranchiseMemberships(ctx context.Context, accountID, ownerID string) ([]*model.FranchiseMembership, error) {
+	var method = constants.Methods.ListFranchiseMemberships
+	var table = constants.DB.Table_Franchise_Accounts
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if err := dbutils.CheckDBConn(r.db, method); err != nil {
+		return nil, err
+	}
+
+	columns := []string{
+		"ta.id AS account_id",
+		"ta.franchise_id AS franchise_id",
+		"ta.account_type AS account_type",
+		"f.business_name AS business_name",
+		"f.status AS franchise_status",
+		"fo.role AS owner_role",
+		"ta.deleted_at AS account_deleted_at",
+		"f.deleted_at AS franchise_deleted_at",
+	}
+
+	// The owner role only shows up for franchises the person also owns through franchise_owners
+	joins := []dbutils.JoinClause{
+		{
+			Type:   "INNER",
+			Schema: schema_outlet,
+			Table:  constants.DB.Table_Franchises,
+			Alias:  "f",
+			On:     "ta.franchise_id = f.id",
+		},
+		{
+			Type:   "LEFT",
+			Schema: schema_outlet,
+			Table:  constants.DB.Table_Franchise_Owners,
+			Alias:  "fo",
+			On:     "fo.franchise_id = ta.franchise_id AND fo.owner_id = ta.owner_id",
+		},
+	}
+
+	conditions := map[string]any{
+		"ta.id": accountID,
+	}
+	if ownerID != "" {
+		conditions = map[string]any{
+			"ta.owner_id": ownerID,
+		}
+	}
+
+	opts := &dbutils.QueryBuilderOptions{
+		Whilelist: struct {
+			Schemas []string
+			Tables  []string
+			Columns []string
+		}{
+			Schemas: []string{schema_outlet},
+			Tables:  []string{table, constants.DB.Table_Franchises, constants.DB.Table_Franchise_Owners},
+			Columns: columns,
+		},
+	}
+
+	query, args, err := dbutils.BuildJoinSelectQuery(method, schema_outlet, table, "ta", columns, joins, conditions, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error(constants.DBQueryError, err, map[string]interface{}{
+			"method": method,
+		})
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []*model.FranchiseMembership
+	for rows.Next() {
+		var m model.FranchiseMembership
+		var franchiseStatus, ownerRole sql.NullString
+		var accountDeletedAt, franchiseDeletedAt sql.NullTime
+		if err := rows.Scan(
+			&m.AccountID,
+			&m.FranchiseID,
+			&m.AccountType,
+			&m.BusinessName,
+			&franchiseStatus,
+			&ownerRole,
+			&accountDeletedAt,
+			&franchiseDeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		if accountDeletedAt.Valid || franchiseDeletedAt.Valid {
+			continue
+		}
+		m.FranchiseStatus = constants.FranchiseStatusActive
+		if franchiseStatus.Valid && franchiseStatus.String != "" {
+			m.FranchiseStatus = franchiseStatus.String
+		}
+		m.OwnerRole = ownerRole.String
+		memberships = append(memberships, &m)
+	}
+	return memberships, rows.Err()
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/apikey"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// last_used_at is only written once per interval, verifying a key shouldn't cost a write per request
+const apiKeyTouchInterval = time.Minute
+
+// CreateAPIKey creates a key for a partner integration. Managers get keys of their own franchise,
+// super admins of any franchise.
+func (s *authService) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.CreateAPIKeyRequest(req)
+	if input.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyNameRequired)
+	}
+	if len(input.Scopes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyScopesRequired)
+	}
+	for _, scope := range input.Scopes {
+		if !apikey.ValidScope(scope) {
+			return nil, status.Errorf(codes.InvalidArgument, constants.APIKeyInvalidScope, scope)
+		}
+	}
+	if input.ExpiresIn < 0 {
+		input.ExpiresIn = 0
+	}
+	if s.apiKeys.MaxTTL > 0 {
+		if input.ExpiresIn > s.apiKeys.MaxTTL {
+			return nil, status.Error(codes.InvalidArgument, constants.APIKeyExpiryTooLong)
+		}
+		if input.ExpiresIn == 0 {
+			input.ExpiresIn = s.apiKeys.MaxTTL
+		}
+	}
+
+	claims, err := s.apiKeyManager(ctx, constants.Methods.CreateAPIKey, input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	franchiseID, err := apiKeyFranchise(claims, input.FranchiseID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		logger.Error(constants.FailedToCreateAPIKey, err, map[string]interface{}{
+			"method": constants.Methods.CreateAPIKey,
+		})
+		return nil, status.Error(codes.Internal, constants.FailedToCreateAPIKey)
+	}
+	now := time.Now()
+	key := &model.APIKey{
+		ID:          uuid.New().String(),
+		FranchiseID: franchiseID,
+		Name:        input.Name,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Scopes:      input.Scopes,
+		CreatedBy:   claims.RegisteredClaims.Subject,
+		CreatedAt:   now,
+	}
+	if input.ExpiresIn > 0 {
+		expiresAt := now.Add(input.ExpiresIn)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, key); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToCreateAPIKey)
+	}
+
+	logger.Warn(constants.APIKeyCreated, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAPIKeyCreated,
+		"method":                constants.Methods.CreateAPIKey,
+		"key_id":                key.ID,
+		"prefix":                key.Prefix,
+		"franchise_id":          key.FranchiseID,
+		"scopes":                key.Scopes,
+		"account_id":            key.CreatedBy,
+	})
+	return mapper.CreateAPIKeyResponse(key, secret), nil
+}
+
+func (s *authService) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.ListAPIKeysRequest(req)
+
+	claims, err := s.apiKeyManager(ctx, constants.Methods.ListAPIKeys, input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	franchiseID, err := apiKeyFranchise(claims, input.FranchiseID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.apiKeyRepo.ListAPIKeys(ctx, franchiseID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchAPIKey)
+	}
+	return mapper.ListAPIKeysResponse(keys), nil
+}
+
+// RotateAPIKey replaces the key, the integration has to switch to the returned one right away
+func (s *authService) RotateAPIKey(ctx context.Context, req *pb.RotateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RotateAPIKeyRequest(req)
+
+	claims, key, err := s.managedAPIKey(ctx, constants.Methods.RotateAPIKey, input)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(time.Now()) {
+		return nil, status.Error(codes.FailedPrecondition, constants.APIKeyInvalid)
+	}
+
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToUpdateAPIKey)
+	}
+	if err := s.apiKeyRepo.UpdateAPIKeySecret(ctx, key.ID, prefix, hash); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToUpdateAPIKey)
+	}
+	oldPrefix := key.Prefix
+	key.Prefix, key.KeyHash = prefix, hash
+
+	logger.Warn(constants.APIKeyRotated, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAPIKeyRotated,
+		"method":                constants.Methods.RotateAPIKey,
+		"key_id":                key.ID,
+		"old_prefix":            oldPrefix,
+		"prefix":                key.Prefix,
+		"franchise_id":          key.FranchiseID,
+		"account_id":            claims.RegisteredClaims.Subject,
+	})
+	return mapper.CreateAPIKeyResponse(key, secret), nil
+}
+
+func (s *authService) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.APIKey, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RevokeAPIKeyRequest(req)
+
+	claims, key, err := s.managedAPIKey(ctx, constants.Methods.RevokeAPIKey, input)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, status.Error(codes.FailedPrecondition, constants.APIKeyRevokedAlready)
+	}
+
+	now := time.Now()
+	if err := s.apiKeyRepo.RevokeAPIKey(ctx, key.ID, now); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToUpdateAPIKey)
+	}
+	key.RevokedAt = &now
+
+	logger.Warn(constants.APIKeyRevoked, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAPIKeyRevoked,
+		"method":                constants.Methods.RevokeAPIKey,
+		"key_id":                key.ID,
+		"prefix":                key.Prefix,
+		"franchise_id":          key.FranchiseID,
+		"account_id":            claims.RegisteredClaims.Subject,
+	})
+	return mapper.APIKeyResponse(key), nil
+}
+
+// VerifyAPIKey turns an x-api-key header into claims. The key must be active and its franchise too,
+// every failure looks the same to the caller.
+func (s *authService) VerifyAPIKey(ctx context.Context, req *pb.VerifyAPIKeyRequest) (*pb.AuthClaims, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.VerifyAPIKeyRequest(req)
+	if input.APIKey == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.APIKeyRequired)
+	}
+
+	prefix, ok := apikey.Prefix(input.APIKey)
+	if !ok {
+		return nil, s.rejectAPIKey(ctx, "", "malformed")
+	}
+	key, err := s.apiKeyRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, s.rejectAPIKey(ctx, prefix, "unknown")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchAPIKey)
+	}
+	now := time.Now()
+	if !apikey.Verify(input.APIKey, key.KeyHash) {
+		return nil, s.rejectAPIKey(ctx, prefix, "hash mismatch")
+	}
+	if !key.Active(now) {
+		return nil, s.rejectAPIKey(ctx, prefix, "revoked or expired")
+	}
+
+	// A suspended or deleted franchise takes its integrations down with it
+	franchise, err := s.userRepo.GetFranchiseStatus(ctx, key.FranchiseID)
+	if err != nil && !errors.Is(err, repository.ErrFranchiseNotFound) {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchFranchise)
+	}
+	if err != nil || franchiseBlockedReason(franchise) != "" {
+		return nil, s.rejectAPIKey(ctx, prefix, "franchise not active")
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			logger.Error(constants.FailedToUpdateAPIKey, err, map[string]interface{}{
+				"method": constants.Methods.VerifyAPIKey,
+				"key_id": key.ID,
+			})
+		}
+	}
+	return mapper.APIKeyClaims(key), nil
+}
+
+func (s *authService) rejectAPIKey(ctx context.Context, prefix, reason string) error {
+	logger.Warn(constants.APIKeyInvalid, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAPIKeyRejected,
+		"method":                constants.Methods.VerifyAPIKey,
+		"prefix":                prefix,
+		"reason":                reason,
+		"ip_address":            s.clientInfo(ctx).IPAddress,
+	})
+	return status.Error(codes.Unauthenticated, constants.APIKeyInvalid)
+}
+
+// apiKeyManager authenticates an account allowed to manage keys. Impersonation tokens are refused,
+// a temporary impersonation must not leave long lived credentials behind.
+func (s *authService) apiKeyManager(ctx context.Context, method, accessToken string) (*model.AuthClaims, error) {
+	claims, err := s.authenticate(ctx, method, accessToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if claims.TokenType != constants.TokenTypeAccess || claims.Act != nil || !s.apiKeys.Manager(claims.AccountType) {
+		logger.Warn(constants.APIKeyManageNotAllowed, map[string]interface{}{
+			"method":       method,
+			"account_id":   claims.RegisteredClaims.Subject,
+			"account_type": claims.AccountType,
+		})
+		return nil, status.Error(codes.PermissionDenied, constants.APIKeyManageNotAllowed)
+	}
+	return claims, nil
+}
+
+// managedAPIKey loads a key the caller may manage, keys of other franchises look like missing ones
+func (s *authService) managedAPIKey(ctx context.Context, method string, input *model.APIKeyInput) (*model.AuthClaims, *model.APIKey, error) {
+	if input.KeyID == "" {
+		return nil, nil, status.Error(codes.InvalidArgument, constants.APIKeyIDRequired)
+	}
+	claims, err := s.apiKeyManager(ctx, method, input.AccessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := s.apiKeyRepo.GetAPIKey(ctx, input.KeyID)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, nil, status.Error(codes.NotFound, constants.APIKeyNotFound)
+	}
+	if err != nil {
+		return nil, nil, status.Error(codes.Internal, constants.FailedToFetchAPIKey)
+	}
+	if claims.AccountType != constants.AccountTypeSuperAdmin && key.FranchiseID != claims.FranchiseID {
+		return nil, nil, status.Error(codes.NotFound, constants.APIKeyNotFound)
+	}
+	return claims, key, nil
+}
+
+// apiKeyFranchise picks the franchise keys are managed for, only super admins can name another one
+func apiKeyFranchise(claims *model.AuthClaims, requested string) (string, error) {
+	franchiseID := claims.FranchiseID
+	if requested != "" && requested != franchiseID {
+		if claims.AccountType != constants.AccountTypeSuperAdmin {
+			return "", status.Error(codes.PermissionDenied, constants.APIKeyFranchiseMismatch)
+		}
+		franchiseID = requested
+	}
+	if franchiseID == "" {
+		return "", status.Error(codes.InvalidArgument, constants.FranchiseIDRequired)
+	}
+	return franchiseID, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *authService) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.ChangePasswordRequest(req)
+	if input.OldPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.OldPasswordRequired)
+	}
+	if input.NewPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.NewPasswordRequired)
+	}
+
+	claims, err := s.authenticate(ctx, constants.Methods.ChangePassword, input.AccessToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	accountID := claims.RegisteredClaims.Subject
+
+	user, err := s.userRepo.GetUserByID(ctx, accountID)
+	if err != nil || user == nil {
+		return nil, status.Error(codes.NotFound, constants.ErrUserNotFound)
+	}
+
+	// A stolen access token alone must not be enough to take the account over,
+	// wrong old passwords count towards the login lockout
+	info := s.clientInfo(ctx)
+	if err := s.checkLoginAllowed(ctx, user.LoginID, info.IPAddress); err != nil {
+		return nil, err
+	}
+	if !s.verifyPassword(user.Password, input.OldPassword) {
+		s.recordLoginFailure(ctx, user.LoginID, info.IPAddress, user)
+		return nil, status.Error(codes.PermissionDenied, constants.OldPasswordIncorrect)
+	}
+
+	if err := s.checkNewPassword(ctx, user, input.NewPassword); err != nil {
+		return nil, err
+	}
+	if err := s.setPassword(ctx, constants.Methods.ChangePassword, user, input.NewPassword); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToChangePassword)
+	}
+
+	// The session that changed the password stays logged in, every other device is logged out
+	revoked, err := s.revokeOtherSessions(ctx, accountID, claims.SessionID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToRevokeAccount)
+	}
+	s.resetLoginFailures(ctx, user.LoginID)
+
+	logger.Warn(constants.PasswordChanged, map[string]interface{}{
+		constants.SecurityEvent: constants.EventPasswordChanged,
+		"method":                constants.Methods.ChangePassword,
+		"account_id":            accountID,
+		"session_id":            claims.SessionID,
+		"revoked":               revoked,
+		"ip_address":            info.IPAddress,
+	})
+	return mapper.ChangePasswordResponse(true, revoked), nil
+}
+
+// checkNewPassword applies the password policy and refuses the current password
+// and the last HistorySize ones
+func (s *authService) checkNewPassword(ctx context.Context, user *model.User, password string) error {
+	if err := s.policy.Validate(password); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if s.policy.HistorySize <= 0 {
+		return nil
+	}
+
+	history, err := s.userRepo.GetPasswordHistory(ctx, user.AccountID, s.policy.HistorySize)
+	if err != nil {
+		logger.Error(constants.FailedToFetchPwdHistory, err, map[string]interface{}{
+			"method":     constants.Methods.GetPasswordHistory,
+			"account_id": user.AccountID,
+		})
+		return status.Error(codes.Internal, constants.FailedToFetchPwdHistory)
+	}
+	for _, hash := range append([]string{user.Password}, history...) {
+		if match, _ := s.hasher.Verify(hash, password); hash != "" && match {
+			return status.Error(codes.InvalidArgument, passwordpolicy.ErrReused.Error())
+		}
+	}
+	return nil
+}
+
+// setPassword stores the new hash and moves the replaced one into the password history
+func (s *authService) setPassword(ctx context.Context, method string, user *model.User, password string) error {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		logger.Error(constants.FailedToHashPassword, err, map[string]interface{}{
+			"method": method,
+		})
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.AccountID, hash); err != nil {
+		return err
+	}
+	previous := user.Password
+	user.Password = hash
+	if previous == "" {
+		return nil
+	}
+
+	// the password is already changed, a missing history entry only weakens the reuse check
+	if err := s.userRepo.AddPasswordHistory(ctx, user.AccountID, previous); err != nil {
+		logger.Error(constants.FailedToStorePwdHistory, err, map[string]interface{}{
+			"method":     method,
+			"account_id": user.AccountID,
+		})
+	}
+	return nil
+}
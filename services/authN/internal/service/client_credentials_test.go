@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testClientSecret = "account-service-secret"
+
+func withServiceClient(d *Deps) {
+	sum := sha256.Sum256([]byte(testClientSecret))
+	d.ClientCredentials = config.ClientCredentialsConfig{Clients: []config.OAuthClientConfig{{
+		ClientID:     "account-service",
+		SecretSHA256: hex.EncodeToString(sum[:]),
+		Scopes:       []string{constants.ScopeAuthZCheck, constants.ScopeRevokeAccountTokens},
+	}}}
+}
+
+func TestClientCredentials(t *testing.T) {
+	cases := map[string]struct {
+		clientID   string
+		secret     string
+		scopes     []string
+		want       codes.Code
+		wantScopes []string
+	}{
+		"every registered scope": {
+			clientID:   "account-service",
+			secret:     testClientSecret,
+			want:       codes.OK,
+			wantScopes: []string{constants.ScopeAuthZCheck, constants.ScopeRevokeAccountTokens},
+		},
+		"narrowed to one scope": {
+			clientID:   "account-service",
+			secret:     testClientSecret,
+			scopes:     []string{constants.ScopeAuthZCheck},
+			want:       codes.OK,
+			wantScopes: []string{constants.ScopeAuthZCheck},
+		},
+		"scope the client wasn't registered with": {
+			clientID: "account-service",
+			secret:   testClientSecret,
+			scopes:   []string{constants.ScopeAuthZCheck, constants.ScopeRevokeFranchiseSessions},
+			want:     codes.PermissionDenied,
+		},
+		"wrong secret":   {clientID: "account-service", secret: "guessed", want: codes.Unauthenticated},
+		"unknown client": {clientID: "billing", secret: testClientSecret, want: codes.Unauthenticated},
+		"missing secret": {clientID: "account-service", want: codes.InvalidArgument},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t, withServiceClient)
+
+			resp, err := env.svc.ClientCredentials(context.Background(), &pb.ClientCredentialsRequest{
+				ClientId:     tc.clientID,
+				ClientSecret: tc.secret,
+				Scopes:       tc.scopes,
+			})
+			require.Equal(t, tc.want, status.Code(err), "%v", err)
+			if tc.want != codes.OK {
+				return
+			}
+			assert.Equal(t, tc.wantScopes, resp.Scopes)
+
+			claims, err := env.svc.VerifyAccessToken(context.Background(), &pb.VerifyTokenRequest{AccessToken: resp.AccessToken})
+			require.NoError(t, err)
+			assert.Equal(t, constants.TokenTypeService, claims.TokenType)
+			assert.Equal(t, tc.wantScopes, claims.Scopes, "the token carries the granted scopes only")
+		})
+	}
+}
+
+func TestNarrowedServiceTokenLosesScope(t *testing.T) {
+	env := newTestEnv(t, withServiceClient)
+	target := env.addUser(t, model.User{AccountID: "acc-1"})
+
+	resp, err := env.svc.ClientCredentials(context.Background(), &pb.ClientCredentialsRequest{
+		ClientId:     "account-service",
+		ClientSecret: testClientSecret,
+		Scopes:       []string{constants.ScopeAuthZCheck},
+	})
+	require.NoError(t, err)
+
+	// the client is registered for the revocation, the token it asked for is not
+	_, err = env.svc.RevokeAccountTokens(context.Background(), &pb.RevokeAccountTokensRequest{
+		AccountId:   target.AccountID,
+		Reason:      "suspended",
+		AccessToken: resp.AccessToken,
+	})
+	requireCode(t, err, codes.PermissionDenied)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListMyFranchises lists the franchises the caller can switch to, the current one included
+func (s *authService) ListMyFranchises(ctx context.Context, req *pb.ListMyFranchisesRequest) (*pb.ListMyFranchisesResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.ListMyFranchisesRequest(req)
+
+	claims, user, err := s.franchiseCaller(ctx, constants.Methods.ListMyFranchises, input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.userRepo.ListFranchiseMemberships(ctx, user.AccountID, user.OwnerID)
+	if err != nil {
+		logger.Error(constants.FailedToListFranchises, err, map[string]interface{}{
+			"method":     constants.Methods.ListMyFranchises,
+			"account_id": user.AccountID,
+		})
+		return nil, status.Error(codes.Internal, constants.FailedToListFranchises)
+	}
+	return mapper.ListMyFranchisesResponse(memberships, claims.FranchiseID), nil
+}
+
+// SwitchFranchise exchanges an access token for tokens of the caller's account in another franchise.
+// The new account goes through the same lifecycle and MFA checks as a login, the old session is
+// revoked once the new one is open so one device keeps one session.
+func (s *authService) SwitchFranchise(ctx context.Context, req *pb.SwitchFranchiseRequest) (*pb.LoginResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.SwitchFranchiseRequest(req)
+	if input.FranchiseID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.FranchiseIDRequired)
+	}
+
+	claims, user, err := s.franchiseCaller(ctx, constants.Methods.SwitchFranchise, input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.FranchiseID == input.FranchiseID {
+		return nil, status.Error(codes.InvalidArgument, constants.AlreadyInFranchise)
+	}
+
+	memberships, err := s.userRepo.ListFranchiseMemberships(ctx, user.AccountID, user.OwnerID)
+	if err != nil {
+		logger.Error(constants.FailedToListFranchises, err, map[string]interface{}{
+			"method":     constants.Methods.SwitchFranchise,
+			"account_id": user.AccountID,
+		})
+		return nil, status.Error(codes.Internal, constants.FailedToListFranchises)
+	}
+	var target *model.FranchiseMembership
+	for _, m := range memberships {
+		if m.FranchiseID == input.FranchiseID {
+			target = m
+			break
+		}
+	}
+	if target == nil {
+		logger.Warn(constants.NotAFranchiseMember, map[string]interface{}{
+			constants.SecurityEvent: constants.EventFranchiseSwitchDenied,
+			"method":                constants.Methods.SwitchFranchise,
+			"account_id":            user.AccountID,
+			"franchise_id":          input.FranchiseID,
+			"ip_address":            s.clientInfo(ctx).IPAddress,
+		})
+		return nil, status.Error(codes.PermissionDenied, constants.NotAFranchiseMember)
+	}
+
+	targetUser, err := s.userRepo.GetUserByID(ctx, target.AccountID)
+	if err != nil || targetUser == nil {
+		return nil, status.Error(codes.NotFound, constants.ErrUserNotFound)
+	}
+	if err := s.checkLifecycle(ctx, constants.Methods.SwitchFranchise, targetUser); err != nil {
+		return nil, err
+	}
+	// The target account may be enrolled even though the current one isn't, VerifyMFA finishes the switch
+	// and ends the current session then
+	if challenge, err := s.startMFAChallenge(ctx, targetUser, claims); err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	resp, err := s.completeLogin(ctx, constants.Methods.SwitchFranchise, targetUser)
+	if err != nil {
+		return nil, err
+	}
+	s.endSwitchedSession(ctx, constants.Methods.SwitchFranchise, user.AccountID, claims.SessionID)
+
+	logger.Info(constants.FranchiseSwitched, map[string]interface{}{
+		"method":            constants.Methods.SwitchFranchise,
+		"from_account_id":   user.AccountID,
+		"from_franchise_id": claims.FranchiseID,
+		"account_id":        targetUser.AccountID,
+		"franchise_id":      targetUser.FranchiseID,
+		"session_id":        resp.SessionId,
+	})
+	return resp, nil
+}
+
+// endSwitchedSession revokes the session a franchise switch started from, the new one is open already
+func (s *authService) endSwitchedSession(ctx context.Context, method, accountID, sessionID string) {
+	if err := s.revokeSession(ctx, accountID, sessionID); err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+		logger.Error(constants.FailedToDeleteSession, err, map[string]interface{}{
+			"method":     method,
+			"account_id": accountID,
+			"session_id": sessionID,
+		})
+	}
+}
+
+// franchiseCaller authenticates a plain account access token, service and impersonation tokens can't switch
+func (s *authService) franchiseCaller(ctx context.Context, method, accessToken string) (*model.AuthClaims, *model.User, error) {
+	claims, err := s.authenticate(ctx, method, accessToken)
+	if err != nil {
+		return nil, nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if claims.TokenType != constants.TokenTypeAccess || claims.Act != nil {
+		return nil, nil, status.Error(codes.PermissionDenied, constants.SwitchNotAllowed)
+	}
+	user, err := s.userRepo.GetUserByID(ctx, claims.RegisteredClaims.Subject)
+	if err != nil || user == nil {
+		return nil, nil, status.Error(codes.NotFound, constants.ErrUserNotFound)
+	}
+	return claims, user, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// addOwnedAccounts stores the accounts one owner has in fr-1 and fr-2 and an account of another owner in fr-3
+func addOwnedAccounts(t *testing.T, env *testEnv) (home, other *model.User) {
+	t.Helper()
+	home = env.addUser(t, model.User{AccountID: "acc-home", LoginID: "owner@example.com", OwnerID: "owner-1", FranchiseID: "fr-1"})
+	other = env.addUser(t, model.User{AccountID: "acc-other", LoginID: "owner+2@example.com", OwnerID: "owner-1", FranchiseID: "fr-2"})
+	env.addUser(t, model.User{AccountID: "acc-stranger", OwnerID: "owner-2", FranchiseID: "fr-3"})
+	return home, other
+}
+
+func TestSwitchFranchise(t *testing.T) {
+	cases := map[string]struct {
+		franchiseID string
+		setup       func(t *testing.T, env *testEnv)
+		token       func(t *testing.T, env *testEnv, login *pb.LoginResponse) string
+		want        codes.Code
+		wantReason  string
+	}{
+		"franchise of the same owner": {franchiseID: "fr-2", want: codes.OK},
+		"franchise of another owner": {
+			franchiseID: "fr-3",
+			want:        codes.PermissionDenied,
+			wantReason:  constants.NotAFranchiseMember,
+		},
+		"unknown franchise": {
+			franchiseID: "fr-9",
+			want:        codes.PermissionDenied,
+			wantReason:  constants.NotAFranchiseMember,
+		},
+		"current franchise": {
+			franchiseID: "fr-1",
+			want:        codes.InvalidArgument,
+			wantReason:  constants.AlreadyInFranchise,
+		},
+		"suspended franchise": {
+			franchiseID: "fr-2",
+			setup:       func(t *testing.T, env *testEnv) { env.users.setFranchise("fr-2", constants.FranchiseStatusSuspended) },
+			want:        codes.PermissionDenied,
+		},
+		"locked target account": {
+			franchiseID: "fr-2",
+			setup: func(t *testing.T, env *testEnv) {
+				require.NoError(t, env.users.UpdateAccountStatus(context.Background(), "acc-other", constants.AccountStatusLocked))
+			},
+			want:       codes.PermissionDenied,
+			wantReason: constants.AccountLocked,
+		},
+		"service token": {
+			franchiseID: "fr-2",
+			token: func(t *testing.T, env *testEnv, _ *pb.LoginResponse) string {
+				return env.serviceToken(t, "billing", constants.ScopeAuthZCheck)
+			},
+			want:       codes.PermissionDenied,
+			wantReason: constants.SwitchNotAllowed,
+		},
+		"refresh token": {
+			franchiseID: "fr-2",
+			token:       func(t *testing.T, _ *testEnv, login *pb.LoginResponse) string { return login.RefreshToken },
+			want:        codes.Unauthenticated,
+		},
+		"missing franchise": {want: codes.InvalidArgument, wantReason: constants.FranchiseIDRequired},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			home, _ := addOwnedAccounts(t, env)
+			if tc.setup != nil {
+				tc.setup(t, env)
+			}
+			login := env.login(t, home)
+			token := login.AccessToken
+			if tc.token != nil {
+				token = tc.token(t, env, login)
+			}
+
+			resp, err := env.svc.SwitchFranchise(context.Background(), &pb.SwitchFranchiseRequest{AccessToken: token, FranchiseId: tc.franchiseID})
+			require.Equal(t, tc.want, status.Code(err), "%v", err)
+			if tc.wantReason != "" {
+				assert.Equal(t, tc.wantReason, status.Convert(err).Message())
+			}
+
+			// the session switched from ends with a successful switch only
+			_, refreshErr := env.svc.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+			if tc.want == codes.OK {
+				assert.Equal(t, "acc-other", resp.AccountId)
+				assert.Equal(t, "fr-2", resp.FranchiseId)
+				assert.NotEmpty(t, resp.AccessToken)
+				assert.Error(t, refreshErr)
+			} else {
+				assert.NoError(t, refreshErr)
+			}
+		})
+	}
+}
+
+func TestSwitchFranchiseWithMFA(t *testing.T) {
+	t.Run("verifying the challenge ends the old session", func(t *testing.T) {
+		env := newTestEnv(t)
+		home, other := addOwnedAccounts(t, env)
+		secret := env.enrollMFA(t, other.AccountID)
+		login := env.login(t, home)
+
+		challenge, err := env.svc.SwitchFranchise(context.Background(), &pb.SwitchFranchiseRequest{AccessToken: login.AccessToken, FranchiseId: "fr-2"})
+		require.NoError(t, err)
+		require.True(t, challenge.MfaRequired)
+		assert.Empty(t, challenge.AccessToken)
+
+		resp, err := env.svc.VerifyMFA(context.Background(), &pb.VerifyMFARequest{MfaToken: challenge.MfaToken, Code: mfaCode(t, secret)})
+		require.NoError(t, err)
+		assert.Equal(t, other.AccountID, resp.AccountId)
+
+		_, err = env.svc.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+		assert.Error(t, err, "the session switched from is revoked")
+	})
+
+	t.Run("the old session has to be open when the challenge is verified", func(t *testing.T) {
+		env := newTestEnv(t)
+		home, other := addOwnedAccounts(t, env)
+		secret := env.enrollMFA(t, other.AccountID)
+		login := env.login(t, home)
+
+		challenge, err := env.svc.SwitchFranchise(context.Background(), &pb.SwitchFranchiseRequest{AccessToken: login.AccessToken, FranchiseId: "fr-2"})
+		require.NoError(t, err)
+		require.True(t, challenge.MfaRequired)
+
+		_, err = env.svc.RevokeSession(context.Background(), &pb.RevokeSessionRequest{AccountId: home.AccountID, SessionId: login.SessionId, AccessToken: login.AccessToken})
+		require.NoError(t, err)
+
+		_, err = env.svc.VerifyMFA(context.Background(), &pb.VerifyMFARequest{MfaToken: challenge.MfaToken, Code: mfaCode(t, secret)})
+		requireCode(t, err, codes.Unauthenticated)
+	})
+}
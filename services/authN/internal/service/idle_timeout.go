@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+)
+
+// checkIdle rejects a session that wasn't used for longer than the idle timeout of its account type
+// and records the activity otherwise. An idle session is revoked so its refresh token dies with it.
+func (s *authService) checkIdle(ctx context.Context, method string, session *model.Session) error {
+	now := time.Now()
+	idle := now.Sub(session.LastActive())
+	limit := s.idleTimeout.For(session.AccountType)
+
+	if limit > 0 && idle > limit {
+		logger.Warn(constants.SessionIdleExpired, map[string]interface{}{
+			constants.SecurityEvent: constants.EventSessionIdle,
+			"method":                method,
+			"account_id":            session.AccountID,
+			"account_type":          session.AccountType,
+			"session_id":            session.ID,
+			"idle":                  idle.Round(time.Second).String(),
+		})
+		if err := s.revokeSession(ctx, session.AccountID, session.ID); err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			logger.Error(constants.FailedToDeleteSession, err, map[string]interface{}{
+				"method":     method,
+				"session_id": session.ID,
+			})
+		}
+		return errors.New(constants.SessionIdleExpired)
+	}
+
+	// Writing on every request would cost a store round trip each time, a short limit still gets touched often enough
+	touchEvery := s.idleTimeout.TouchInterval
+	if limit > 0 && limit/2 < touchEvery {
+		touchEvery = limit / 2
+	}
+	if idle >= touchEvery {
+		if err := s.sessionRepo.TouchSession(ctx, session, now); err != nil {
+			logger.Error(constants.FailedToTouchSession, err, map[string]interface{}{
+				"method":     method,
+				"session_id": session.ID,
+			})
+		}
+	}
+	return nil
+}
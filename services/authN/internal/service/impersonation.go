@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Impersonate lets a super admin act as another account for support work. The token carries the
+// target as subject and the admin in the act claim (RFC 8693), it has no refresh token and never
+// outlives the admin's own access token.
+func (s *authService) Impersonate(ctx context.Context, req *pb.ImpersonateRequest) (*pb.ImpersonateResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.ImpersonateRequest(req)
+	if input.TargetAccountID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.TargetAccountIDRequired)
+	}
+	if input.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.ImpersonationReasonRequired)
+	}
+
+	admin, err := s.authenticate(ctx, constants.Methods.Impersonate, input.AccessToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if reason := impersonationDeniedReason(admin); reason != "" {
+		s.logImpersonationDenied(ctx, admin, input, reason)
+		return nil, status.Error(codes.PermissionDenied, reason)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, input.TargetAccountID)
+	if err != nil || user == nil {
+		return nil, status.Error(codes.NotFound, constants.ErrUserNotFound)
+	}
+	if user.AccountType == constants.AccountTypeSuperAdmin {
+		s.logImpersonationDenied(ctx, admin, input, constants.ImpersonationOfSuperAdmin)
+		return nil, status.Error(codes.PermissionDenied, constants.ImpersonationOfSuperAdmin)
+	}
+	// Locked, deleted or franchise-suspended accounts stay off limits, like for their owner
+	if err := s.checkLifecycle(ctx, constants.Methods.Impersonate, user); err != nil {
+		return nil, err
+	}
+
+	ttl := s.impersonation.TTL
+	if admin.RegisteredClaims.ExpiresAt != nil {
+		if remaining := time.Until(admin.RegisteredClaims.ExpiresAt.Time); remaining < ttl {
+			ttl = remaining
+		}
+	}
+
+	actor := &model.Actor{Subject: admin.RegisteredClaims.Subject, SessionID: admin.SessionID}
+	accessToken, claims, err := s.tokenManager.GenerateImpersonationToken(user, actor, ttl)
+	if err != nil {
+		logger.Error(constants.FailedToImpersonate, err, map[string]interface{}{
+			"method":     constants.Methods.Impersonate,
+			"account_id": user.AccountID,
+		})
+		return nil, status.Error(codes.Internal, constants.FailedToImpersonate)
+	}
+
+	logger.Warn(constants.ImpersonationStarted, map[string]interface{}{
+		constants.SecurityEvent: constants.EventImpersonationStarted,
+		"method":                constants.Methods.Impersonate,
+		"account_id":            user.AccountID,
+		"franchise_id":          user.FranchiseID,
+		"impersonated_by":       actor.Subject,
+		"reason":                input.Reason,
+		"jti":                   claims.RegisteredClaims.ID,
+		"expires_in":            ttl.String(),
+		"ip_address":            s.clientInfo(ctx).IPAddress,
+	})
+	return mapper.ImpersonateResponse(accessToken, claims, ttl), nil
+}
+
+func impersonationDeniedReason(admin *model.AuthClaims) string {
+	switch {
+	case admin.Act != nil:
+		return constants.ImpersonationNested
+	case admin.TokenType != constants.TokenTypeAccess, admin.AccountType != constants.AccountTypeSuperAdmin:
+		return constants.ImpersonationNotAllowed
+	default:
+		return ""
+	}
+}
+
+func (s *authService) logImpersonationDenied(ctx context.Context, admin *model.AuthClaims, input *model.ImpersonateInput, reason string) {
+	logger.Warn(reason, map[string]interface{}{
+		constants.SecurityEvent: constants.EventImpersonationDenied,
+		"method":                constants.Methods.Impersonate,
+		"account_id":            admin.RegisteredClaims.Subject,
+		"target_account_id":     input.TargetAccountID,
+		"ip_address":            s.clientInfo(ctx).IPAddress,
+	})
+}
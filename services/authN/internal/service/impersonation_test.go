@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func impersonate(env *testEnv, accessToken, targetAccountID string) (*pb.ImpersonateResponse, error) {
+	return env.svc.Impersonate(context.Background(), &pb.ImpersonateRequest{
+		AccessToken:     accessToken,
+		TargetAccountId: targetAccountID,
+		Reason:          "ticket 42",
+	})
+}
+
+// addImpersonationAccounts stores a super admin, a manager and the usual targets
+func addImpersonationAccounts(t *testing.T, env *testEnv) {
+	t.Helper()
+	env.addUser(t, model.User{AccountID: "acc-admin", AccountType: constants.AccountTypeSuperAdmin})
+	env.addUser(t, model.User{AccountID: "acc-admin-2", AccountType: constants.AccountTypeSuperAdmin})
+	env.addUser(t, model.User{AccountID: "acc-manager", FranchiseID: "fr-1"})
+	env.addUser(t, model.User{AccountID: "acc-locked", Status: constants.AccountStatusLocked})
+}
+
+func TestImpersonate(t *testing.T) {
+	adminToken := func(t *testing.T, env *testEnv) string { return env.login(t, env.users.get("acc-admin")).AccessToken }
+	cases := map[string]struct {
+		token      func(t *testing.T, env *testEnv) string
+		target     string
+		want       codes.Code
+		wantReason string
+	}{
+		"super admin": {token: adminToken, target: "acc-manager", want: codes.OK},
+		"manager": {
+			token:      func(t *testing.T, env *testEnv) string { return env.login(t, env.users.get("acc-manager")).AccessToken },
+			target:     "acc-locked",
+			want:       codes.PermissionDenied,
+			wantReason: constants.ImpersonationNotAllowed,
+		},
+		"service token": {
+			token: func(t *testing.T, env *testEnv) string {
+				return env.serviceToken(t, "support-tool", constants.ScopeAuthZCheck)
+			},
+			target:     "acc-manager",
+			want:       codes.PermissionDenied,
+			wantReason: constants.ImpersonationNotAllowed,
+		},
+		"impersonation token": {
+			token: func(t *testing.T, env *testEnv) string {
+				resp, err := impersonate(env, adminToken(t, env), "acc-manager")
+				require.NoError(t, err)
+				return resp.AccessToken
+			},
+			target:     "acc-manager",
+			want:       codes.PermissionDenied,
+			wantReason: constants.ImpersonationNested,
+		},
+		"another super admin": {
+			token:      adminToken,
+			target:     "acc-admin-2",
+			want:       codes.PermissionDenied,
+			wantReason: constants.ImpersonationOfSuperAdmin,
+		},
+		"locked account":  {token: adminToken, target: "acc-locked", want: codes.PermissionDenied, wantReason: constants.AccountLocked},
+		"unknown account": {token: adminToken, target: "acc-missing", want: codes.NotFound},
+		"missing token":   {token: func(t *testing.T, env *testEnv) string { return "" }, target: "acc-manager", want: codes.Unauthenticated},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			addImpersonationAccounts(t, env)
+
+			resp, err := impersonate(env, tc.token(t, env), tc.target)
+			require.Equal(t, tc.want, status.Code(err), "%v", err)
+			if tc.wantReason != "" {
+				assert.Equal(t, tc.wantReason, status.Convert(err).Message())
+			}
+			if tc.want != codes.OK {
+				return
+			}
+
+			claims, err := env.svc.VerifyAccessToken(context.Background(), &pb.VerifyTokenRequest{AccessToken: resp.AccessToken})
+			require.NoError(t, err)
+			assert.Equal(t, tc.target, claims.RegisteredClaims.Subject)
+			require.NotNil(t, claims.Act)
+			assert.Equal(t, "acc-admin", claims.Act.Subject, "the act claim names the real admin")
+		})
+	}
+}
+
+func TestImpersonationTokenLimits(t *testing.T) {
+	env := newTestEnv(t)
+	addImpersonationAccounts(t, env)
+	resp, err := impersonate(env, env.login(t, env.users.get("acc-admin")).AccessToken, "acc-manager")
+	require.NoError(t, err)
+
+	_, err = refresh(env, resp.AccessToken)
+	requireCode(t, err, codes.Unauthenticated)
+	assert.Equal(t, constants.RefreshNotAllowed, status.Convert(err).Message(), "impersonation tokens are never refreshed")
+
+	_, err = env.svc.SwitchFranchise(context.Background(), &pb.SwitchFranchiseRequest{AccessToken: resp.AccessToken, FranchiseId: "fr-2"})
+	requireCode(t, err, codes.PermissionDenied)
+
+	_, err = env.svc.RevokeAllSessions(context.Background(), &pb.RevokeAllSessionsRequest{AccountId: "acc-manager", AccessToken: resp.AccessToken})
+	requireCode(t, err, codes.PermissionDenied)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkLifecycle refuses tokens to accounts that are locked, inactive, suspended or deleted
+// and to accounts of a franchise that is not active. Every case has its own message so
+// clients can tell the user what happened.
+func (s *authService) checkLifecycle(ctx context.Context, method string, user *model.User) error {
+	reason := accountBlockedReason(user)
+	if reason == "" && user.FranchiseID != "" {
+		franchise, err := s.userRepo.GetFranchiseStatus(ctx, user.FranchiseID)
+		if err != nil {
+			if errors.Is(err, repository.ErrFranchiseNotFound) {
+				reason = constants.FranchiseNotFound
+			} else {
+				logger.Error(constants.FailedToFetchFranchise, err, map[string]interface{}{
+					"method":       method,
+					"franchise_id": user.FranchiseID,
+				})
+				return status.Error(codes.Internal, constants.FailedToFetchFranchise)
+			}
+		} else {
+			reason = franchiseBlockedReason(franchise)
+		}
+	}
+	if reason == "" {
+		return nil
+	}
+
+	logger.Warn(constants.LoginBlocked, map[string]interface{}{
+		constants.SecurityEvent: constants.EventLifecycleLoginDenied,
+		"method":                method,
+		"account_id":            user.AccountID,
+		"franchise_id":          user.FranchiseID,
+		"reason":                reason,
+	})
+	return status.Error(codes.PermissionDenied, reason)
+}
+
+// lifecycleCacheTTL is how long an account that passed checkLifecycle on verify isn't loaded again,
+// a suspension or deactivation made in the account service reaches every token within it
+const lifecycleCacheTTL = 30 * time.Second
+
+// maxLifecycleEntries bounds the accounts remembered, expired ones are dropped once it is reached
+const maxLifecycleEntries = 10000
+
+// checkTokenLifecycle runs checkLifecycle for the account of an access token. Service tokens have no account.
+func (s *authService) checkTokenLifecycle(ctx context.Context, method string, claims *model.AuthClaims) error {
+	if claims.TokenType != constants.TokenTypeAccess {
+		return nil
+	}
+	accountID := claims.RegisteredClaims.Subject
+	if s.lifecycle.fresh(accountID) {
+		return nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, accountID)
+	if err != nil {
+		logger.Error(constants.AuthTokenVeriFailed, err, map[string]interface{}{
+			"method":     method,
+			"account_id": accountID,
+		})
+		return fmt.Errorf(constants.AuthTokenVeriFailed)
+	}
+	if err := s.checkLifecycle(ctx, method, user); err != nil {
+		return err
+	}
+	s.lifecycle.mark(accountID)
+	return nil
+}
+
+// lifecycleCache remembers when accounts last passed checkLifecycle, blocked accounts are never kept
+type lifecycleCache struct {
+	mu      sync.Mutex
+	checked map[string]time.Time
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+func newLifecycleCache(ttl time.Duration) *lifecycleCache {
+	return &lifecycleCache{checked: map[string]time.Time{}, ttl: ttl, now: time.Now}
+}
+
+func (c *lifecycleCache) fresh(accountID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	checkedAt, ok := c.checked[accountID]
+	return ok && c.now().Sub(checkedAt) <= c.ttl
+}
+
+func (c *lifecycleCache) mark(accountID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.checked) >= maxLifecycleEntries {
+		for id, checkedAt := range c.checked {
+			if now.Sub(checkedAt) > c.ttl {
+				delete(c.checked, id)
+			}
+		}
+	}
+	c.checked[accountID] = now
+}
+
+func accountBlockedReason(user *model.User) string {
+	if user.DeletedAt != nil {
+		return constants.AccountDeleted
+	}
+	switch user.Status {
+	case "", constants.AccountStatusActive:
+		return ""
+	case constants.AccountStatusLocked:
+		return constants.AccountLocked
+	case constants.AccountStatusSuspended:
+		return constants.AccountSuspended
+	default:
+		return constants.AccountInactive
+	}
+}
+
+func franchiseBlockedReason(franchise *model.FranchiseStatus) string {
+	if franchise.DeletedAt != nil {
+		return constants.FranchiseDeleted
+	}
+	switch franchise.Status {
+	case constants.FranchiseStatusActive:
+		return ""
+	case constants.FranchiseStatusSuspended:
+		return constants.FranchiseSuspended
+	default:
+		return constants.FranchiseInactive
+	}
+}
+
+// RevokeFranchiseSessions is called by the account service when a franchise stops being active.
+// Access tokens of every account in the franchise are cut off and their sessions deleted.
+func (s *authService) RevokeFranchiseSessions(ctx context.Context, req *pb.RevokeFranchiseSessionsRequest) (*pb.RevokeSessionResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RevokeFranchiseSessionsRequest(req)
+	if input.FranchiseID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.FranchiseIDRequired)
+	}
+	// Only the account service calls this, when it suspends, deactivates or deletes a franchise
+	caller, err := s.authorizeService(ctx, constants.Methods.RevokeFranchiseSessions, input.AccessToken, constants.ScopeRevokeFranchiseSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	// One marker covers all accounts, checkRevoked compares it with the franchise claim of the token
+	err = s.tokenRepo.RevokeTokensBefore(ctx, franchiseRevocationKey(input.FranchiseID), time.Now(), s.accessTTL)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToRevokeFranchise)
+	}
+
+	accountIDs, err := s.userRepo.ListFranchiseAccountIDs(ctx, input.FranchiseID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToRevokeFranchise)
+	}
+	var revoked int
+	for _, accountID := range accountIDs {
+		count, err := s.revokeAllSessions(ctx, accountID)
+		if err != nil {
+			logger.Error(constants.FailedToRevokeFranchise, err, map[string]interface{}{
+				"method":     constants.Methods.RevokeFranchiseSessions,
+				"account_id": accountID,
+			})
+			return nil, status.Error(codes.Internal, constants.FailedToRevokeFranchise)
+		}
+		revoked += count
+	}
+
+	logger.Warn(constants.FranchiseSessionsRevoked, map[string]interface{}{
+		constants.SecurityEvent: constants.EventFranchiseRevoked,
+		"method":                constants.Methods.RevokeFranchiseSessions,
+		"franchise_id":          input.FranchiseID,
+		"reason":                input.Reason,
+		"accounts":              len(accountIDs),
+		"revoked":               revoked,
+		"revoked_by":            caller.RegisteredClaims.Subject,
+	})
+	return mapper.RevokeSessionResponse(true, revoked), nil
+}
+
+func franchiseRevocationKey(franchiseID string) string {
+	return constants.FranchiseRevocationPrefix + ":" + franchiseID
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginLifecycle(t *testing.T) {
+	deletedAt := time.Now().Add(-time.Hour)
+	cases := map[string]struct {
+		accountStatus   string
+		deletedAt       *time.Time
+		franchiseStatus string // empty leaves the franchise out of the franchises table
+		want            codes.Code
+		wantReason      string
+	}{
+		"active":              {franchiseStatus: constants.FranchiseStatusActive, want: codes.OK},
+		"locked account":      {accountStatus: constants.AccountStatusLocked, franchiseStatus: constants.FranchiseStatusActive, want: codes.PermissionDenied, wantReason: constants.AccountLocked},
+		"inactive account":    {accountStatus: constants.AccountStatusInactive, franchiseStatus: constants.FranchiseStatusActive, want: codes.PermissionDenied},
+		"deleted account":     {deletedAt: &deletedAt, franchiseStatus: constants.FranchiseStatusActive, want: codes.PermissionDenied, wantReason: constants.AccountDeleted},
+		"suspended franchise": {franchiseStatus: constants.FranchiseStatusSuspended, want: codes.PermissionDenied},
+		"inactive franchise":  {franchiseStatus: constants.FranchiseStatusInactive, want: codes.PermissionDenied},
+		"unknown franchise":   {want: codes.PermissionDenied, wantReason: constants.FranchiseNotFound},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.addUser(t, model.User{AccountID: "acc-1", FranchiseID: "fr-1", Status: tc.accountStatus, DeletedAt: tc.deletedAt})
+			if tc.franchiseStatus == "" {
+				delete(env.users.franchises, "fr-1")
+			} else {
+				env.users.setFranchise("fr-1", tc.franchiseStatus)
+			}
+
+			_, err := env.svc.Login(context.Background(), &pb.LoginRequest{LoginId: user.LoginID, Password: testPassword, AccountType: user.AccountType})
+			assert.Equal(t, tc.want, status.Code(err))
+			if tc.wantReason != "" {
+				assert.Equal(t, tc.wantReason, status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestRevokeFranchiseSessionsAuthorization(t *testing.T) {
+	cases := map[string]struct {
+		token func(t *testing.T, env *testEnv) string
+		want  codes.Code
+	}{
+		"scoped service token": {func(t *testing.T, env *testEnv) string {
+			return env.serviceToken(t, "account-service", constants.ScopeRevokeFranchiseSessions)
+		}, codes.OK},
+		"service token with another scope": {func(t *testing.T, env *testEnv) string {
+			return env.serviceToken(t, "account-service", constants.ScopeRevokeAccountTokens)
+		}, codes.PermissionDenied},
+		"super admin": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-admin")).AccessToken
+		}, codes.PermissionDenied},
+		"member of the franchise": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-member")).AccessToken
+		}, codes.PermissionDenied},
+		"missing token": {func(t *testing.T, env *testEnv) string { return "" }, codes.Unauthenticated},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			member := env.addUser(t, model.User{AccountID: "acc-member", FranchiseID: "fr-1"})
+			env.addUser(t, model.User{AccountID: "acc-admin", AccountType: constants.AccountTypeSuperAdmin})
+			victim := env.login(t, member)
+			token := tc.token(t, env)
+
+			resp, err := env.svc.RevokeFranchiseSessions(context.Background(), &pb.RevokeFranchiseSessionsRequest{
+				FranchiseId: "fr-1",
+				Reason:      constants.FranchiseStatusSuspended,
+				AccessToken: token,
+			})
+			assert.Equal(t, tc.want, status.Code(err))
+
+			_, err = env.svc.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: victim.RefreshToken})
+			if tc.want == codes.OK {
+				require.NotNil(t, resp)
+				assert.GreaterOrEqual(t, resp.RevokedCount, int32(1))
+				assert.Error(t, err, "sessions of the franchise are gone")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyAccessTokenLifecycle(t *testing.T) {
+	cases := map[string]struct {
+		change     func(t *testing.T, env *testEnv)
+		wantReason string
+	}{
+		"account suspended": {
+			change: func(t *testing.T, env *testEnv) {
+				require.NoError(t, env.users.UpdateAccountStatus(context.Background(), "acc-1", constants.AccountStatusSuspended))
+			},
+			wantReason: constants.AccountSuspended,
+		},
+		"franchise suspended": {
+			change:     func(t *testing.T, env *testEnv) { env.users.setFranchise("fr-1", constants.FranchiseStatusSuspended) },
+			wantReason: constants.FranchiseSuspended,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			login := env.login(t, env.addUser(t, model.User{AccountID: "acc-1", FranchiseID: "fr-1"}))
+			verify := func() error {
+				_, err := env.svc.VerifyAccessToken(context.Background(), &pb.VerifyTokenRequest{AccessToken: login.AccessToken})
+				return err
+			}
+			require.NoError(t, verify())
+
+			// the change is made in the account service, authN revokes nothing
+			tc.change(t, env)
+			env.svc.lifecycle.now = func() time.Time { return time.Now().Add(lifecycleCacheTTL + time.Second) }
+
+			err := verify()
+			requireCode(t, err, codes.PermissionDenied)
+			assert.Equal(t, tc.wantReason, status.Convert(err).Message())
+		})
+	}
+}
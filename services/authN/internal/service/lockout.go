@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// attemptCounter is one of the failure counters a login is checked against
+type attemptCounter struct {
+	scope string
+	key   string
+	max   int
+}
+
+// attemptCounters returns the login_id counter and, when the client address is known, the IP counter
+func (s *authService) attemptCounters(loginID, ip string) []attemptCounter {
+	counters := []attemptCounter{{scope: constants.AttemptScopeLogin, key: loginID, max: s.lockout.MaxAttempts}}
+	if ip != "" {
+		counters = append(counters, attemptCounter{scope: constants.AttemptScopeIP, key: ip, max: s.lockout.MaxAttemptsPerIP})
+	}
+	return counters
+}
+
+// checkLoginAllowed rejects a login while the login_id or client IP is locked out or still backing off.
+// Lockouts use codes.ResourceExhausted so clients can tell them apart from wrong credentials.
+func (s *authService) checkLoginAllowed(ctx context.Context, loginID, ip string) error {
+	now := time.Now()
+	for _, counter := range s.attemptCounters(loginID, ip) {
+		attempts, err := s.attemptRepo.GetAttempts(ctx, counter.scope, counter.key)
+		if err != nil {
+			return status.Error(codes.Internal, constants.FailedToFetchAttempts)
+		}
+		if now.Before(attempts.LockedUntil) {
+			return status.Errorf(codes.ResourceExhausted, constants.LoginTemporarilyLocked, retryAfter(attempts.LockedUntil))
+		}
+		if now.Before(attempts.NextAttemptAt) {
+			return status.Errorf(codes.ResourceExhausted, constants.LoginThrottled, retryAfter(attempts.NextAttemptAt))
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against every counter. Past BackoffAfter failures the next
+// attempt has to wait an exponentially growing delay, at the limit the counter is locked for LockoutDuration.
+// user is nil when the login_id doesn't exist, in that case there is no account to lock permanently.
+func (s *authService) recordLoginFailure(ctx context.Context, loginID, ip string, user *model.User) {
+	now := time.Now()
+	for _, counter := range s.attemptCounters(loginID, ip) {
+		attempts, err := s.attemptRepo.GetAttempts(ctx, counter.scope, counter.key)
+		if err != nil {
+			continue
+		}
+
+		attempts.Failures++
+		attempts.LastFailureAt = now
+		attempts.NextAttemptAt = time.Time{}
+		if attempts.Failures >= counter.max {
+			attempts.Failures = 0
+			attempts.Lockouts++
+			attempts.LockedUntil = now.Add(s.lockout.LockoutDuration)
+
+			logger.Warn(constants.LoginTemporarilyLocked, map[string]interface{}{
+				constants.SecurityEvent: constants.EventLoginLockout,
+				"method":                constants.Methods.Login,
+				"scope":                 counter.scope,
+				"key":                   counter.key,
+				"lockouts":              attempts.Lockouts,
+			})
+
+			if counter.scope == constants.AttemptScopeLogin && user != nil &&
+				s.lockout.PermanentLockAfter > 0 && attempts.Lockouts >= s.lockout.PermanentLockAfter {
+				s.lockAccount(ctx, user)
+			}
+		} else if attempts.Failures >= s.lockout.BackoffAfter {
+			attempts.NextAttemptAt = now.Add(s.backoffDelay(attempts.Failures))
+		}
+
+		// the lockout count has to outlive the lockout itself, otherwise it never escalates
+		expiry := s.lockout.Window
+		if attempts.LockedUntil.After(now) {
+			expiry += attempts.LockedUntil.Sub(now)
+		}
+		_ = s.attemptRepo.SaveAttempts(ctx, counter.scope, counter.key, attempts, expiry)
+	}
+}
+
+// resetLoginFailures clears the login_id counter after a successful login.
+// The IP counter is kept, a valid login of one account must not reset guessing against others.
+func (s *authService) resetLoginFailures(ctx context.Context, loginID string) {
+	_ = s.attemptRepo.ResetAttempts(ctx, constants.AttemptScopeLogin, loginID)
+}
+
+// backoffDelay doubles BaseDelay for every failure past BackoffAfter, capped at MaxDelay
+func (s *authService) backoffDelay(failures int) time.Duration {
+	delay := s.lockout.BaseDelay
+	for i := s.lockout.BackoffAfter; i < failures && delay < s.lockout.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.lockout.MaxDelay {
+		delay = s.lockout.MaxDelay
+	}
+	return delay
+}
+
+// lockAccount stores a permanent lock in team_accounts.status, only UnlockAccount lifts it
+func (s *authService) lockAccount(ctx context.Context, user *model.User) {
+	err := s.userRepo.UpdateAccountStatus(ctx, user.AccountID, constants.AccountStatusLocked)
+	if err != nil {
+		logger.Error(constants.FailedToUpdateStatus, err, map[string]interface{}{
+			"method":     constants.Methods.Login,
+			"account_id": user.AccountID,
+		})
+		return
+	}
+	logger.Warn(constants.AccountLockedPermanent, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAccountLocked,
+		"method":                constants.Methods.Login,
+		"account_id":            user.AccountID,
+	})
+}
+
+func (s *authService) UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.UnlockAccountResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.UnlockAccountRequest(req)
+	if input.LoginID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginIDRequired)
+	}
+	if input.AccountType == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	admin, err := s.authorizeAdmin(ctx, constants.Methods.UnlockAccount, input.AccessToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUser(ctx, input.LoginID, input.AccountType)
+	if err != nil || user == nil {
+		return nil, status.Error(codes.NotFound, constants.ErrUserNotFound)
+	}
+
+	wasLocked := user.Status == constants.AccountStatusLocked
+	if wasLocked {
+		err = s.userRepo.UpdateAccountStatus(ctx, user.AccountID, constants.AccountStatusActive)
+		if err != nil {
+			logger.Error(constants.FailedToUnlockAccount, err, map[string]interface{}{
+				"method":     constants.Methods.UnlockAccount,
+				"account_id": user.AccountID,
+			})
+			return nil, status.Error(codes.Internal, constants.FailedToUnlockAccount)
+		}
+	}
+	if err := s.attemptRepo.ResetAttempts(ctx, constants.AttemptScopeLogin, input.LoginID); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToUnlockAccount)
+	}
+
+	logger.Warn(constants.AccountUnlocked, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAccountUnlocked,
+		"method":                constants.Methods.UnlockAccount,
+		"account_id":            user.AccountID,
+		"reason":                input.Reason,
+		"was_locked":            wasLocked,
+		"unlocked_by":           admin.RegisteredClaims.Subject,
+	})
+	return mapper.UnlockAccountResponse(true, wasLocked), nil
+}
+
+// retryAfter rounds the remaining wait up to whole seconds for the client
+func retryAfter(until time.Time) string {
+	return (time.Until(until) + time.Second - 1).Truncate(time.Second).String()
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// fromIP is a context of a gRPC call from ip
+func fromIP(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+}
+
+func withLockout(lockout config.LockoutConfig) func(*Deps) {
+	return func(d *Deps) { d.Lockout = lockout }
+}
+
+func loginWith(env *testEnv, ctx context.Context, user *model.User, password string) error {
+	_, err := env.svc.Login(ctx, &pb.LoginRequest{LoginId: user.LoginID, Password: password, AccountType: user.AccountType})
+	return err
+}
+
+func TestLoginLockoutThresholds(t *testing.T) {
+	// a nanosecond of backoff keeps the delays out of the way of the thresholds
+	lockout := config.LockoutConfig{
+		MaxAttempts:      3,
+		MaxAttemptsPerIP: 5,
+		BackoffAfter:     100,
+		BaseDelay:        time.Nanosecond,
+		MaxDelay:         time.Nanosecond,
+		LockoutDuration:  time.Minute,
+	}
+
+	cases := map[string]struct {
+		failures  int  // wrong passwords for the account before the real login
+		otherIPs  bool // every failure comes from a different address
+		spread    bool // failures are spread over other login_ids from the same address
+		forged    bool // every failure names another client in x-forwarded-for
+		wantLocks bool
+	}{
+		"below the login_id limit":        {failures: 2},
+		"at the login_id limit":           {failures: 3, wantLocks: true},
+		"login_id limit across addresses": {failures: 3, otherIPs: true, wantLocks: true},
+		"below the ip limit":              {failures: 4, spread: true},
+		"at the ip limit":                 {failures: 5, spread: true, wantLocks: true},
+		"ip limit with forged addresses":  {failures: 5, spread: true, forged: true, wantLocks: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t, withLockout(lockout))
+			user := env.addUser(t, model.User{AccountID: "acc-1"})
+
+			for i := 0; i < tc.failures; i++ {
+				ip := "203.0.113.1"
+				if tc.otherIPs {
+					ip = net.IPv4(198, 51, 100, byte(i+1)).String()
+				}
+				target := user
+				if tc.spread {
+					target = &model.User{LoginID: "unknown-" + string(rune('a'+i)), AccountType: user.AccountType}
+				}
+				ctx := fromIP(ip)
+				if tc.forged {
+					ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", net.IPv4(192, 0, 2, byte(i+1)).String()))
+				}
+				assert.Error(t, loginWith(env, ctx, target, "Wrong-Password-1"))
+			}
+
+			err := loginWith(env, fromIP("203.0.113.1"), user, testPassword)
+			if tc.wantLocks {
+				requireCode(t, err, codes.ResourceExhausted)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoginBackoff(t *testing.T) {
+	env := newTestEnv(t, withLockout(config.LockoutConfig{MaxAttempts: 10, BackoffAfter: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}))
+	user := env.addUser(t, model.User{AccountID: "acc-1"})
+	ctx := fromIP("203.0.113.1")
+
+	assert.Error(t, loginWith(env, ctx, user, "Wrong-Password-1"))
+	assert.Error(t, loginWith(env, ctx, user, "Wrong-Password-1"))
+
+	err := loginWith(env, ctx, user, testPassword)
+	requireCode(t, err, codes.ResourceExhausted)
+	assert.Contains(t, status.Convert(err).Message(), "retry after")
+}
+
+func TestLoginPermanentLock(t *testing.T) {
+	// lockouts expire right away so the second one can follow the first
+	env := newTestEnv(t, withLockout(config.LockoutConfig{
+		MaxAttempts:        2,
+		BackoffAfter:       100,
+		LockoutDuration:    time.Nanosecond,
+		PermanentLockAfter: 2,
+	}))
+	user := env.addUser(t, model.User{AccountID: "acc-1"})
+	ctx := fromIP("203.0.113.1")
+
+	for i := 0; i < 4; i++ {
+		assert.Error(t, loginWith(env, ctx, user, "Wrong-Password-1"))
+	}
+	assert.Equal(t, constants.AccountStatusLocked, env.users.get(user.AccountID).Status)
+
+	// the right password is told about the lock, nobody else is
+	requireCode(t, loginWith(env, ctx, user, testPassword), codes.PermissionDenied)
+}
+
+func TestUnlockAccountAuthorization(t *testing.T) {
+	cases := map[string]struct {
+		token func(t *testing.T, env *testEnv) string
+		want  codes.Code
+	}{
+		"super admin": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-admin")).AccessToken
+		}, codes.OK},
+		"manager": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-manager")).AccessToken
+		}, codes.PermissionDenied},
+		"service token": {func(t *testing.T, env *testEnv) string {
+			return env.serviceToken(t, "account-service", constants.ScopeRevokeAccountTokens)
+		}, codes.PermissionDenied},
+		"missing token": {func(t *testing.T, env *testEnv) string { return "" }, codes.Unauthenticated},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			locked := env.addUser(t, model.User{AccountID: "acc-locked", Status: constants.AccountStatusLocked})
+			env.addUser(t, model.User{AccountID: "acc-admin", AccountType: constants.AccountTypeSuperAdmin})
+			env.addUser(t, model.User{AccountID: "acc-manager"})
+
+			resp, err := env.svc.UnlockAccount(context.Background(), &pb.UnlockAccountRequest{
+				LoginId:     locked.LoginID,
+				AccountType: locked.AccountType,
+				Reason:      "verified by phone",
+				AccessToken: tc.token(t, env),
+			})
+			assert.Equal(t, tc.want, status.Code(err))
+
+			accountStatus := env.users.get(locked.AccountID).Status
+			if tc.want == codes.OK {
+				assert.True(t, resp.WasLocked)
+				assert.Equal(t, constants.AccountStatusActive, accountStatus)
+			} else {
+				assert.Equal(t, constants.AccountStatusLocked, accountStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *authService) RequestLoginOTP(ctx context.Context, req *pb.RequestLoginOTPRequest) (*pb.RequestLoginOTPResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RequestLoginOTPRequest(req)
+	if input.MobileNo == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MobileNoRequired)
+	}
+	if input.AccountType == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	if !s.loginOTP.Allowed(input.AccountType) {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginOTPNotAllowed)
+	}
+
+	// Sends are counted before the lookup, so the limits behave the same for unknown numbers
+	clientIP := s.clientInfo(ctx).IPAddress
+	if err := s.checkOTPSendAllowed(ctx, input.MobileNo, clientIP); err != nil {
+		return nil, err
+	}
+	s.recordOTPSend(ctx, input.MobileNo, clientIP)
+
+	response := mapper.RequestLoginOTPResponse(true, s.loginOTP.TTL, s.loginOTP.ResendAfter)
+	user, err := s.userRepo.GetUserByMobile(ctx, input.MobileNo, input.AccountType)
+	if err != nil || user == nil {
+		logger.Warn(constants.ErrUserNotFound, map[string]interface{}{
+			"method": constants.Methods.RequestLoginOTP,
+		})
+		return response, nil
+	}
+
+	code, err := generateNumericCode(s.loginOTP.Digits)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToSendLoginOTP)
+	}
+	now := time.Now()
+	err = s.otpRepo.SaveLoginOTP(ctx, &model.LoginOTP{
+		AccountID: user.AccountID,
+		CodeHash:  hashOneTimeCode(user.AccountID, code),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.loginOTP.TTL),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToSendLoginOTP)
+	}
+
+	err = s.notifier.Send(ctx, &model.Notification{
+		Channel:   constants.ChannelSMS,
+		To:        user.MobileNo,
+		AccountID: user.AccountID,
+		Body:      fmt.Sprintf(constants.LoginOTPBody, code, int(s.loginOTP.TTL.Minutes())),
+		CreatedAt: now,
+	})
+	if err != nil {
+		logger.Error(constants.FailedToSendLoginOTP, err, map[string]interface{}{
+			"method":     constants.Methods.RequestLoginOTP,
+			"account_id": user.AccountID,
+		})
+		_ = s.otpRepo.DeleteLoginOTP(ctx, user.AccountID)
+		return nil, status.Error(codes.Internal, constants.FailedToSendLoginOTP)
+	}
+
+	logger.Info(constants.LoginOTPSent, map[string]interface{}{
+		"method":     constants.Methods.RequestLoginOTP,
+		"account_id": user.AccountID,
+		"ip_address": clientIP,
+	})
+	return response, nil
+}
+
+func (s *authService) VerifyLoginOTP(ctx context.Context, req *pb.VerifyLoginOTPRequest) (*pb.LoginResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.VerifyLoginOTPRequest(req)
+	if input.MobileNo == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MobileNoRequired)
+	}
+	if input.AccountType == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	if input.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginOTPRequired)
+	}
+	if !s.loginOTP.Allowed(input.AccountType) {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginOTPNotAllowed)
+	}
+
+	user, err := s.userRepo.GetUserByMobile(ctx, input.MobileNo, input.AccountType)
+	if err != nil || user == nil {
+		return nil, status.Error(codes.Unauthenticated, constants.LoginOTPInvalid)
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords
+	clientIP := s.clientInfo(ctx).IPAddress
+	if err := s.checkLoginAllowed(ctx, user.LoginID, clientIP); err != nil {
+		return nil, err
+	}
+	if err := s.consumeLoginOTP(ctx, user.AccountID, input.Code); err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			s.recordLoginFailure(ctx, user.LoginID, clientIP, user)
+			logger.Warn(constants.LoginOTPInvalid, map[string]interface{}{
+				constants.SecurityEvent: constants.EventLoginOTPFailed,
+				"method":                constants.Methods.VerifyLoginOTP,
+				"account_id":            user.AccountID,
+				"ip_address":            clientIP,
+			})
+		}
+		return nil, err
+	}
+
+	if err := s.checkLifecycle(ctx, constants.Methods.VerifyLoginOTP, user); err != nil {
+		return nil, err
+	}
+	s.resetLoginFailures(ctx, user.LoginID)
+
+	logger.Info(constants.LoginOTPVerified, map[string]interface{}{
+		"method":     constants.Methods.VerifyLoginOTP,
+		"account_id": user.AccountID,
+	})
+
+	// The OTP replaces the password, not the second factor
+	if challenge, err := s.startMFAChallenge(ctx, user, nil); err != nil || challenge != nil {
+		return challenge, err
+	}
+	return s.completeLogin(ctx, constants.Methods.VerifyLoginOTP, user)
+}
+
+// consumeLoginOTP checks the code and deletes it, a code works once.
+// Wrong codes are counted and the code is dropped after MaxAttempts.
+func (s *authService) consumeLoginOTP(ctx context.Context, accountID, code string) error {
+	record, err := s.otpRepo.GetLoginOTP(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repository.ErrLoginOTPNotFound) {
+			return status.Error(codes.Unauthenticated, constants.LoginOTPInvalid)
+		}
+		return status.Error(codes.Internal, constants.FailedToFetchLoginOTP)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.CodeHash), []byte(hashOneTimeCode(accountID, code))) != 1 {
+		record.Attempts++
+		if record.Attempts >= s.loginOTP.MaxAttempts {
+			_ = s.otpRepo.DeleteLoginOTP(ctx, accountID)
+		} else {
+			_ = s.otpRepo.SaveLoginOTP(ctx, record)
+		}
+		return status.Error(codes.Unauthenticated, constants.LoginOTPInvalid)
+	}
+
+	// Deleting first means two concurrent logins can't both use the code
+	if err := s.otpRepo.DeleteLoginOTP(ctx, accountID); err != nil {
+		return status.Error(codes.Unauthenticated, constants.LoginOTPInvalid)
+	}
+	return nil
+}
+
+// otpSendCounters limits codes per mobile number and, when the client address is known, per IP
+func (s *authService) otpSendCounters(mobileNo, ip string) []attemptCounter {
+	counters := []attemptCounter{{scope: constants.AttemptScopeOTPMobile, key: mobileNo, max: s.loginOTP.MaxSendsPerMobile}}
+	if ip != "" {
+		counters = append(counters, attemptCounter{scope: constants.AttemptScopeOTPIP, key: ip, max: s.loginOTP.MaxSendsPerIP})
+	}
+	return counters
+}
+
+func (s *authService) checkOTPSendAllowed(ctx context.Context, mobileNo, ip string) error {
+	now := time.Now()
+	for _, counter := range s.otpSendCounters(mobileNo, ip) {
+		sends, err := s.attemptRepo.GetAttempts(ctx, counter.scope, counter.key)
+		if err != nil {
+			return status.Error(codes.Internal, constants.FailedToFetchAttempts)
+		}
+		if now.Before(sends.LockedUntil) {
+			return status.Errorf(codes.ResourceExhausted, constants.LoginOTPRateLimited, retryAfter(sends.LockedUntil))
+		}
+		if now.Before(sends.NextAttemptAt) {
+			return status.Errorf(codes.ResourceExhausted, constants.LoginOTPResendTooSoon, retryAfter(sends.NextAttemptAt))
+		}
+	}
+	return nil
+}
+
+// recordOTPSend counts a sent code. A mobile number waits ResendAfter between codes,
+// a counter that reaches its limit is blocked for SendWindow.
+func (s *authService) recordOTPSend(ctx context.Context, mobileNo, ip string) {
+	now := time.Now()
+	for _, counter := range s.otpSendCounters(mobileNo, ip) {
+		sends, err := s.attemptRepo.GetAttempts(ctx, counter.scope, counter.key)
+		if err != nil {
+			continue
+		}
+
+		sends.Failures++
+		sends.LastFailureAt = now
+		if counter.scope == constants.AttemptScopeOTPMobile {
+			sends.NextAttemptAt = now.Add(s.loginOTP.ResendAfter)
+		}
+		if sends.Failures >= counter.max {
+			sends.Failures = 0
+			sends.LockedUntil = now.Add(s.loginOTP.SendWindow)
+		}
+		_ = s.attemptRepo.SaveAttempts(ctx, counter.scope, counter.key, sends, s.loginOTP.SendWindow)
+	}
+}
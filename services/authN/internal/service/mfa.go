@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/mfa"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startMFAChallenge returns the challenge Login answers with when the user needs a second factor,
+// nil when the password alone is enough
+func (s *authService) startMFAChallenge(ctx context.Context, user *model.User, switchFrom *model.AuthClaims) (*pb.LoginResponse, error) {
+	enrollment, err := s.mfaRepo.GetMFA(ctx, user.AccountID)
+	if err != nil && !errors.Is(err, repository.ErrMFANotEnrolled) {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchMFA)
+	}
+	enrolled := err == nil && enrollment.ConfirmedAt != nil
+	if !enrolled && !s.mfa.Required(user.FranchiseID, user.AccountType) {
+		return nil, nil
+	}
+
+	token, err := generateMFAToken()
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToStoreChallenge)
+	}
+	challenge := &model.MFAChallenge{
+		AccountID:  user.AccountID,
+		LoginID:    user.LoginID,
+		Enrollment: !enrolled,
+		ExpiresAt:  time.Now().Add(s.mfa.ChallengeTTL),
+	}
+	if switchFrom != nil {
+		challenge.SwitchFromAccountID = switchFrom.RegisteredClaims.Subject
+		challenge.SwitchFromSessionID = switchFrom.SessionID
+	}
+	if err := s.challengeRepo.SaveChallenge(ctx, token, challenge); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToStoreChallenge)
+	}
+
+	logger.Info(constants.MFAChallengeIssued, map[string]interface{}{
+		"method":     constants.Methods.Login,
+		"account_id": user.AccountID,
+		"enrollment": challenge.Enrollment,
+	})
+	return mapper.MFAChallengeResponse(user, token, challenge.Enrollment), nil
+}
+
+func (s *authService) EnrollMFA(ctx context.Context, req *pb.EnrollMFARequest) (*pb.EnrollMFAResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.EnrollMFARequest(req)
+
+	// Either a logged in employee enrolls on their own, or Login asked for the enrollment
+	var accountID string
+	switch {
+	case input.AccessToken != "":
+		claims, err := s.mfaCaller(ctx, constants.Methods.EnrollMFA, input.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		accountID = claims.RegisteredClaims.Subject
+	case input.MFAToken != "":
+		challenge, err := s.challengeRepo.GetChallenge(ctx, input.MFAToken)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, constants.MFAChallengeInvalid)
+		}
+		if !challenge.Enrollment {
+			return nil, status.Error(codes.AlreadyExists, constants.MFAAlreadyEnrolled)
+		}
+		accountID = challenge.AccountID
+	default:
+		return nil, status.Error(codes.InvalidArgument, constants.AuthAccessRequired)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, accountID)
+	if err != nil || user == nil {
+		return nil, status.Error(codes.NotFound, constants.ErrUserNotFound)
+	}
+
+	existing, err := s.mfaRepo.GetMFA(ctx, accountID)
+	if err != nil && !errors.Is(err, repository.ErrMFANotEnrolled) {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchMFA)
+	}
+	if err == nil && existing.ConfirmedAt != nil {
+		return nil, status.Error(codes.AlreadyExists, constants.MFAAlreadyEnrolled)
+	}
+
+	// Enrolling again before confirming replaces the pending secret
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToEnrollMFA)
+	}
+	recoveryCodes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToEnrollMFA)
+	}
+	hashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashes[i] = mfa.HashRecoveryCode(code)
+	}
+
+	err = s.mfaRepo.SaveMFA(ctx, &model.MFAEnrollment{
+		AccountID:     accountID,
+		Secret:        secret,
+		RecoveryCodes: hashes,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		logger.Error(constants.FailedToStoreMFA, err, map[string]interface{}{
+			"method":     constants.Methods.EnrollMFA,
+			"account_id": accountID,
+		})
+		return nil, status.Error(codes.Internal, constants.FailedToEnrollMFA)
+	}
+
+	logger.Info(constants.MFAEnrolled, map[string]interface{}{
+		"method":     constants.Methods.EnrollMFA,
+		"account_id": accountID,
+	})
+	return mapper.EnrollMFAResponse(&model.MFASetup{
+		Secret:        secret,
+		URI:           mfa.ProvisioningURI(s.mfa.Issuer, user.LoginID, secret),
+		RecoveryCodes: recoveryCodes,
+	}), nil
+}
+
+// mfaCaller authenticates a plain account access token, the second factor of an account is only
+// managed by the account itself, never by a service or an admin impersonating it
+func (s *authService) mfaCaller(ctx context.Context, method, accessToken string) (*model.AuthClaims, error) {
+	claims, err := s.authenticate(ctx, method, accessToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if claims.TokenType != constants.TokenTypeAccess || claims.Act != nil {
+		return nil, status.Error(codes.PermissionDenied, constants.MFANotAllowed)
+	}
+	return claims, nil
+}
+
+func (s *authService) ConfirmMFA(ctx context.Context, req *pb.ConfirmMFARequest) (*pb.ConfirmMFAResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.ConfirmMFARequest(req)
+	if input.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MFACodeRequired)
+	}
+
+	claims, err := s.mfaCaller(ctx, constants.Methods.ConfirmMFA, input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	accountID := claims.RegisteredClaims.Subject
+
+	enrollment, err := s.mfaRepo.GetMFA(ctx, accountID)
+	if errors.Is(err, repository.ErrMFANotEnrolled) {
+		return nil, status.Error(codes.FailedPrecondition, constants.MFANotEnrolled)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchMFA)
+	}
+	if enrollment.ConfirmedAt != nil {
+		return nil, status.Error(codes.AlreadyExists, constants.MFAAlreadyEnrolled)
+	}
+
+	if _, ok := checkMFACode(enrollment, input.Code); !ok {
+		return nil, status.Error(codes.Unauthenticated, constants.MFACodeInvalid)
+	}
+	if err := s.confirmEnrollment(ctx, constants.Methods.ConfirmMFA, enrollment); err != nil {
+		return nil, err
+	}
+	return mapper.ConfirmMFAResponse(true), nil
+}
+
+func (s *authService) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.LoginResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.VerifyMFARequest(req)
+	if input.MFAToken == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MFATokenRequired)
+	}
+	if input.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.MFACodeRequired)
+	}
+
+	challenge, err := s.challengeRepo.GetChallenge(ctx, input.MFAToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, constants.MFAChallengeInvalid)
+	}
+
+	// Wrong codes count towards the login lockout like wrong passwords
+	clientIP := s.clientInfo(ctx).IPAddress
+	if err := s.checkLoginAllowed(ctx, challenge.LoginID, clientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, challenge.AccountID)
+	if err != nil || user == nil {
+		return nil, status.Error(codes.Unauthenticated, constants.MFAChallengeInvalid)
+	}
+
+	enrollment, err := s.mfaRepo.GetMFA(ctx, challenge.AccountID)
+	if errors.Is(err, repository.ErrMFANotEnrolled) {
+		return nil, status.Error(codes.FailedPrecondition, constants.MFAEnrollmentRequired)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchMFA)
+	}
+
+	usedRecoveryCode, ok := checkMFACode(enrollment, input.Code)
+	if !ok {
+		challenge.Attempts++
+		if challenge.Attempts >= s.mfa.MaxAttempts {
+			_ = s.challengeRepo.DeleteChallenge(ctx, input.MFAToken)
+		} else {
+			_ = s.challengeRepo.SaveChallenge(ctx, input.MFAToken, challenge)
+		}
+		s.recordLoginFailure(ctx, challenge.LoginID, clientIP, user)
+
+		logger.Warn(constants.MFACodeInvalid, map[string]interface{}{
+			constants.SecurityEvent: constants.EventMFAFailed,
+			"method":                constants.Methods.VerifyMFA,
+			"account_id":            user.AccountID,
+			"ip_address":            clientIP,
+		})
+		return nil, status.Error(codes.Unauthenticated, constants.MFACodeInvalid)
+	}
+
+	// Deleting first means the same challenge can't complete two logins
+	if err := s.challengeRepo.DeleteChallenge(ctx, input.MFAToken); err != nil {
+		return nil, status.Error(codes.Unauthenticated, constants.MFAChallengeInvalid)
+	}
+
+	if enrollment.ConfirmedAt == nil {
+		if err := s.confirmEnrollment(ctx, constants.Methods.VerifyMFA, enrollment); err != nil {
+			return nil, err
+		}
+	} else if err := s.mfaRepo.SaveMFA(ctx, enrollment); err != nil {
+		// without the used step or recovery code stored the code could be replayed
+		return nil, status.Error(codes.Internal, constants.FailedToStoreMFA)
+	}
+
+	if usedRecoveryCode {
+		logger.Warn(constants.MFARecoveryCodeUsed, map[string]interface{}{
+			constants.SecurityEvent: constants.EventRecoveryCodeUsed,
+			"method":                constants.Methods.VerifyMFA,
+			"account_id":            user.AccountID,
+			"remaining":             len(enrollment.RecoveryCodes),
+		})
+	}
+
+	if err := s.checkLifecycle(ctx, constants.Methods.VerifyMFA, user); err != nil {
+		return nil, err
+	}
+	// A switch only completes while the session it started from is still open
+	if challenge.SwitchFromSessionID != "" {
+		if _, err := s.sessionRepo.GetSession(ctx, challenge.SwitchFromAccountID, challenge.SwitchFromSessionID); err != nil {
+			return nil, status.Error(codes.Unauthenticated, constants.SessionNotFound)
+		}
+	}
+	s.resetLoginFailures(ctx, challenge.LoginID)
+
+	logger.Info(constants.MFAVerified, map[string]interface{}{
+		"method":     constants.Methods.VerifyMFA,
+		"account_id": user.AccountID,
+	})
+	resp, err := s.completeLogin(ctx, constants.Methods.VerifyMFA, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.SwitchFromSessionID != "" {
+		s.endSwitchedSession(ctx, constants.Methods.VerifyMFA, challenge.SwitchFromAccountID, challenge.SwitchFromSessionID)
+	}
+	return resp, nil
+}
+
+func (s *authService) confirmEnrollment(ctx context.Context, method string, enrollment *model.MFAEnrollment) error {
+	now := time.Now()
+	enrollment.ConfirmedAt = &now
+	if err := s.mfaRepo.SaveMFA(ctx, enrollment); err != nil {
+		logger.Error(constants.FailedToStoreMFA, err, map[string]interface{}{
+			"method":     method,
+			"account_id": enrollment.AccountID,
+		})
+		return status.Error(codes.Internal, constants.FailedToStoreMFA)
+	}
+
+	logger.Warn(constants.MFAConfirmed, map[string]interface{}{
+		constants.SecurityEvent: constants.EventMFAEnrolled,
+		"method":                method,
+		"account_id":            enrollment.AccountID,
+	})
+	return nil
+}
+
+// checkMFACode accepts a TOTP code, or a recovery code once the enrollment is confirmed.
+// The enrollment is updated in place (last used step, consumed recovery code) and has to be saved.
+func checkMFACode(enrollment *model.MFAEnrollment, code string) (recovery bool, ok bool) {
+	code = strings.TrimSpace(code)
+	if len(code) == mfa.Digits {
+		step, ok := mfa.Validate(enrollment.Secret, code, time.Now(), enrollment.LastUsedStep)
+		if ok {
+			enrollment.LastUsedStep = step
+		}
+		return false, ok
+	}
+	if enrollment.ConfirmedAt == nil {
+		return false, false
+	}
+
+	hash := mfa.HashRecoveryCode(code)
+	for i, stored := range enrollment.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			enrollment.RecoveryCodes = append(enrollment.RecoveryCodes[:i], enrollment.RecoveryCodes[i+1:]...)
+			return true, true
+		}
+	}
+	return false, false
+}
+
+func generateMFAToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// loginChallenge signs user in and expects an MFA challenge instead of tokens
+func loginChallenge(t *testing.T, env *testEnv, user *model.User) *pb.LoginResponse {
+	t.Helper()
+	resp := env.login(t, user)
+	require.True(t, resp.MfaRequired)
+	require.NotEmpty(t, resp.MfaToken)
+	require.Empty(t, resp.AccessToken, "no tokens before the second factor")
+	require.Empty(t, resp.RefreshToken, "no tokens before the second factor")
+	return resp
+}
+
+func verifyMFA(env *testEnv, mfaToken, code string) (*pb.LoginResponse, error) {
+	return env.svc.VerifyMFA(context.Background(), &pb.VerifyMFARequest{MfaToken: mfaToken, Code: code})
+}
+
+func TestVerifyMFA(t *testing.T) {
+	cases := map[string]struct {
+		code       func(t *testing.T, secret string) string
+		want       codes.Code
+		wantReason string
+	}{
+		"current code": {code: mfaCode, want: codes.OK},
+		"wrong code": {
+			code:       func(t *testing.T, secret string) string { return "000000" },
+			want:       codes.Unauthenticated,
+			wantReason: constants.MFACodeInvalid,
+		},
+		"unknown recovery code": {
+			code:       func(t *testing.T, secret string) string { return "not-a-recovery-code" },
+			want:       codes.Unauthenticated,
+			wantReason: constants.MFACodeInvalid,
+		},
+		"missing code": {
+			code:       func(t *testing.T, secret string) string { return "" },
+			want:       codes.InvalidArgument,
+			wantReason: constants.MFACodeRequired,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.addUser(t, model.User{AccountID: "acc-1"})
+			secret := env.enrollMFA(t, user.AccountID)
+			challenge := loginChallenge(t, env, user)
+
+			resp, err := verifyMFA(env, challenge.MfaToken, tc.code(t, secret))
+			require.Equal(t, tc.want, status.Code(err), "%v", err)
+			if tc.wantReason != "" {
+				assert.Equal(t, tc.wantReason, status.Convert(err).Message())
+				return
+			}
+
+			assert.Equal(t, user.AccountID, resp.AccountId)
+			assert.NotEmpty(t, resp.AccessToken)
+			_, err = refresh(env, resp.RefreshToken)
+			assert.NoError(t, err, "the session of the completed login works")
+
+			// a challenge completes one login
+			_, err = verifyMFA(env, challenge.MfaToken, tc.code(t, secret))
+			requireCode(t, err, codes.Unauthenticated)
+			assert.Equal(t, constants.MFAChallengeInvalid, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestVerifyMFAReplayedCode(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, model.User{AccountID: "acc-1"})
+	secret := env.enrollMFA(t, user.AccountID)
+	first := loginChallenge(t, env, user)
+	second := loginChallenge(t, env, user)
+	code := mfaCode(t, secret)
+
+	_, err := verifyMFA(env, first.MfaToken, code)
+	require.NoError(t, err)
+
+	_, err = verifyMFA(env, second.MfaToken, code)
+	requireCode(t, err, codes.Unauthenticated)
+	assert.Equal(t, constants.MFACodeInvalid, status.Convert(err).Message())
+}
+
+func TestVerifyMFAMaxAttempts(t *testing.T) {
+	env := newTestEnv(t, func(d *Deps) {
+		d.MFA = config.MFAConfig{RequiredAccountTypes: []string{}, MaxAttempts: 2}
+	})
+	user := env.addUser(t, model.User{AccountID: "acc-1"})
+	secret := env.enrollMFA(t, user.AccountID)
+	challenge := loginChallenge(t, env, user)
+
+	for i := 0; i < 2; i++ {
+		_, err := verifyMFA(env, challenge.MfaToken, "000000")
+		requireCode(t, err, codes.Unauthenticated)
+	}
+
+	// the challenge is gone, the right code comes too late
+	_, err := verifyMFA(env, challenge.MfaToken, mfaCode(t, secret))
+	requireCode(t, err, codes.Unauthenticated)
+	assert.Equal(t, constants.MFAChallengeInvalid, status.Convert(err).Message())
+}
+
+func TestRequiredMFAEnrollment(t *testing.T) {
+	env := newTestEnv(t, func(d *Deps) {
+		d.MFA = config.MFAConfig{RequiredAccountTypes: []string{"manager"}}
+	})
+	user := env.addUser(t, model.User{AccountID: "acc-1"})
+
+	challenge := loginChallenge(t, env, user)
+	require.True(t, challenge.MfaEnrollmentRequired)
+
+	_, err := verifyMFA(env, challenge.MfaToken, "000000")
+	requireCode(t, err, codes.FailedPrecondition)
+
+	setup, err := env.svc.EnrollMFA(context.Background(), &pb.EnrollMFARequest{MfaToken: challenge.MfaToken})
+	require.NoError(t, err)
+	require.NotEmpty(t, setup.RecoveryCodes)
+
+	resp, err := verifyMFA(env, challenge.MfaToken, mfaCode(t, setup.Secret))
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	require.NotNil(t, env.mfa.enrollments[user.AccountID].ConfirmedAt, "the first code confirms the enrollment")
+
+	// the next login is asked for a code, a recovery code works once
+	next := loginChallenge(t, env, user)
+	assert.False(t, next.MfaEnrollmentRequired)
+	_, err = verifyMFA(env, next.MfaToken, setup.RecoveryCodes[0])
+	require.NoError(t, err)
+
+	again := loginChallenge(t, env, user)
+	_, err = verifyMFA(env, again.MfaToken, setup.RecoveryCodes[0])
+	requireCode(t, err, codes.Unauthenticated)
+}
+
+func TestMFAEnrollmentRefusedTokens(t *testing.T) {
+	cases := map[string]struct {
+		token func(t *testing.T, env *testEnv) string
+	}{
+		"impersonation token": {token: func(t *testing.T, env *testEnv) string {
+			resp, err := impersonate(env, env.login(t, env.users.get("acc-admin")).AccessToken, "acc-manager")
+			require.NoError(t, err)
+			return resp.AccessToken
+		}},
+		"service token": {token: func(t *testing.T, env *testEnv) string {
+			return env.serviceToken(t, "support-tool", constants.ScopeAuthZCheck)
+		}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			addImpersonationAccounts(t, env)
+			token := tc.token(t, env)
+
+			_, err := env.svc.EnrollMFA(context.Background(), &pb.EnrollMFARequest{AccessToken: token})
+			requireCode(t, err, codes.PermissionDenied)
+			assert.Equal(t, constants.MFANotAllowed, status.Convert(err).Message())
+			assert.NotContains(t, env.mfa.enrollments, "acc-manager", "nothing is enrolled for the target")
+
+			_, err = env.svc.ConfirmMFA(context.Background(), &pb.ConfirmMFARequest{AccessToken: token, Code: "000000"})
+			requireCode(t, err, codes.PermissionDenied)
+			assert.Equal(t, constants.MFANotAllowed, status.Convert(err).Message())
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+)
+
+func (s *authService) verifyPassword(hashedPassword, password string) bool {
+	match, err := s.hasher.Verify(hashedPassword, password)
+	if err != nil {
+		logger.Error(constants.InvalidPasswordHash, err, map[string]interface{}{
+			"method": constants.Methods.VerifyPassword,
+		})
+		return false
+	}
+	if !match {
+		logger.Warn(constants.ErrInvalidPassword, map[string]interface{}{
+			"method": constants.Methods.VerifyPassword,
+		})
+		return false
+	}
+	return true
+}
+
+// upgradePasswordHash rehashes the password with the configured algorithm after a successful login
+// when the stored hash is older or weaker. The plain password is only known at login, so this is
+// the one chance to upgrade without a reset. A failure keeps the old hash, which still works.
+func (s *authService) upgradePasswordHash(ctx context.Context, user *model.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		logger.Error(constants.FailedToHashPassword, err, map[string]interface{}{
+			"method":     constants.Methods.UpgradePasswordHash,
+			"account_id": user.AccountID,
+		})
+		return
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.AccountID, hash); err != nil {
+		logger.Error(constants.FailedToUpgradeHash, err, map[string]interface{}{
+			"method":     constants.Methods.UpgradePasswordHash,
+			"account_id": user.AccountID,
+		})
+		return
+	}
+	user.Password = hash
+	logger.Info(constants.PasswordHashUpgraded, map[string]interface{}{
+		"method":     constants.Methods.UpgradePasswordHash,
+		"account_id": user.AccountID,
+	})
+}
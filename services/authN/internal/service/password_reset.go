@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	resetCodeDigits      = 6
+	resetCodeTTL         = 10 * time.Minute
+	resetCodeMaxAttempts = 5           // wrong codes before the code is thrown away
+	resetCodeResendAfter = time.Minute // a new code isn't sent more often than this
+)
+
+func (s *authService) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.PasswordResetResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RequestPasswordResetRequest(req)
+	if input.LoginID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginIDRequired)
+	}
+	if input.AccountType == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+
+	// Unknown login ids get the same answer, the response must not reveal who has an account
+	user, err := s.userRepo.GetUser(ctx, input.LoginID, input.AccountType)
+	if err != nil || user == nil {
+		logger.Warn(constants.ErrUserNotFound, map[string]interface{}{
+			"method": constants.Methods.RequestPasswordReset,
+		})
+		return mapper.PasswordResetResponse(true), nil
+	}
+
+	existing, err := s.resetRepo.GetResetCode(ctx, user.AccountID)
+	if err == nil && time.Since(existing.CreatedAt) < resetCodeResendAfter {
+		logger.Warn(constants.ResetCodeResendTooSoon, map[string]interface{}{
+			"method":     constants.Methods.RequestPasswordReset,
+			"account_id": user.AccountID,
+		})
+		return mapper.PasswordResetResponse(true), nil
+	}
+
+	code, err := generateNumericCode(resetCodeDigits)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToSendResetCode)
+	}
+	now := time.Now()
+	err = s.resetRepo.SaveResetCode(ctx, &model.PasswordResetCode{
+		AccountID: user.AccountID,
+		CodeHash:  hashOneTimeCode(user.AccountID, code),
+		CreatedAt: now,
+		ExpiresAt: now.Add(resetCodeTTL),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToSendResetCode)
+	}
+
+	err = s.notifier.Send(ctx, resetCodeNotification(user, input.Channel, code, now))
+	if err != nil {
+		logger.Error(constants.FailedToSendResetCode, err, map[string]interface{}{
+			"method":     constants.Methods.RequestPasswordReset,
+			"account_id": user.AccountID,
+		})
+		_ = s.resetRepo.DeleteResetCode(ctx, user.AccountID)
+		return nil, status.Error(codes.Internal, constants.FailedToSendResetCode)
+	}
+
+	info := s.clientInfo(ctx)
+	logger.Warn(constants.ResetCodeIssued, map[string]interface{}{
+		constants.SecurityEvent: constants.EventPasswordResetIssued,
+		"method":                constants.Methods.RequestPasswordReset,
+		"account_id":            user.AccountID,
+		"ip_address":            info.IPAddress,
+	})
+	return mapper.PasswordResetResponse(true), nil
+}
+
+func (s *authService) ConfirmPasswordReset(ctx context.Context, req *pb.ConfirmPasswordResetRequest) (*pb.PasswordResetResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.ConfirmPasswordResetRequest(req)
+	if input.LoginID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.LoginIDRequired)
+	}
+	if input.AccountType == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountTypeRequired)
+	}
+	if input.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.ResetCodeRequired)
+	}
+	if input.NewPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.NewPasswordRequired)
+	}
+
+	user, err := s.userRepo.GetUser(ctx, input.LoginID, input.AccountType)
+	if err != nil || user == nil {
+		return nil, status.Error(codes.Unauthenticated, constants.ResetCodeInvalid)
+	}
+
+	// The code is checked before the password, the policy and history answers are only for its holder.
+	// A rejected password keeps the code valid, it is deleted once the new password is accepted.
+	if err := s.verifyResetCode(ctx, user.AccountID, input.Code); err != nil {
+		return nil, err
+	}
+	if err := s.checkNewPassword(ctx, user, input.NewPassword); err != nil {
+		return nil, err
+	}
+	// Deleting before the update means two concurrent confirms can't both use the code
+	if err := s.resetRepo.DeleteResetCode(ctx, user.AccountID); err != nil {
+		return nil, status.Error(codes.Unauthenticated, constants.ResetCodeInvalid)
+	}
+
+	if err := s.setPassword(ctx, constants.Methods.ConfirmPasswordReset, user, input.NewPassword); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToResetPassword)
+	}
+
+	// Whoever knew the old password is logged out everywhere
+	if err := s.tokenRepo.RevokeTokensBefore(ctx, user.AccountID, time.Now(), s.accessTTL); err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToRevokeAccount)
+	}
+	revoked, err := s.revokeAllSessions(ctx, user.AccountID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToRevokeAccount)
+	}
+	s.resetLoginFailures(ctx, input.LoginID)
+
+	info := s.clientInfo(ctx)
+	logger.Warn(constants.PasswordResetSuccessful, map[string]interface{}{
+		constants.SecurityEvent: constants.EventPasswordReset,
+		"method":                constants.Methods.ConfirmPasswordReset,
+		"account_id":            user.AccountID,
+		"revoked":               revoked,
+		"ip_address":            info.IPAddress,
+	})
+	return mapper.PasswordResetResponse(true), nil
+}
+
+// verifyResetCode checks the code without using it up.
+// Wrong codes are counted and the code is dropped after resetCodeMaxAttempts.
+func (s *authService) verifyResetCode(ctx context.Context, accountID, code string) error {
+	record, err := s.resetRepo.GetResetCode(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repository.ErrResetCodeNotFound) {
+			return status.Error(codes.Unauthenticated, constants.ResetCodeInvalid)
+		}
+		return status.Error(codes.Internal, constants.FailedToFetchResetCode)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.CodeHash), []byte(hashOneTimeCode(accountID, code))) != 1 {
+		record.Attempts++
+		if record.Attempts >= resetCodeMaxAttempts {
+			_ = s.resetRepo.DeleteResetCode(ctx, accountID)
+		} else {
+			_ = s.resetRepo.SaveResetCode(ctx, record)
+		}
+		return status.Error(codes.Unauthenticated, constants.ResetCodeInvalid)
+	}
+	return nil
+}
+
+// resetCodeNotification sends the code by SMS unless email was asked for and the account has one
+func resetCodeNotification(user *model.User, channel, code string, now time.Time) *model.Notification {
+	msg := &model.Notification{
+		Channel:   constants.ChannelSMS,
+		To:        user.MobileNo,
+		AccountID: user.AccountID,
+		Subject:   constants.ResetCodeSubject,
+		Body:      fmt.Sprintf(constants.ResetCodeBody, code, int(resetCodeTTL.Minutes())),
+		CreatedAt: now,
+	}
+	if channel == constants.ChannelEmail && user.Email != "" {
+		msg.Channel = constants.ChannelEmail
+		msg.To = user.Email
+	}
+	return msg
+}
+
+func generateNumericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// hashOneTimeCode binds a reset or login code to the account, so the stored hash is useless for any other account
+func hashOneTimeCode(accountID, code string) string {
+	sum := sha256.Sum256([]byte(accountID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
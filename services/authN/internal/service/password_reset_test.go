@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testResetCode = "123456"
+
+func saveTestResetCode(t *testing.T, env *testEnv, accountID string) {
+	t.Helper()
+	now := time.Now()
+	require.NoError(t, env.svc.resetRepo.SaveResetCode(context.Background(), &model.PasswordResetCode{
+		AccountID: accountID,
+		CodeHash:  hashOneTimeCode(accountID, testResetCode),
+		CreatedAt: now,
+		ExpiresAt: now.Add(resetCodeTTL),
+	}))
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	cases := map[string]struct {
+		code        string
+		newPassword string
+		wantCode    codes.Code
+		wantMessage string
+		codeKept    bool
+		attempts    int
+	}{
+		"wrong code and reused password": {
+			code:        "000000",
+			newPassword: testPassword,
+			wantCode:    codes.Unauthenticated,
+			wantMessage: constants.ResetCodeInvalid,
+			codeKept:    true,
+			attempts:    1,
+		},
+		"wrong code and weak password": {
+			code:        "000000",
+			newPassword: "weak",
+			wantCode:    codes.Unauthenticated,
+			wantMessage: constants.ResetCodeInvalid,
+			codeKept:    true,
+			attempts:    1,
+		},
+		"right code and reused password": {
+			code:        testResetCode,
+			newPassword: testPassword,
+			wantCode:    codes.InvalidArgument,
+			codeKept:    true,
+		},
+		"right code and new password": {
+			code:        testResetCode,
+			newPassword: "Battery-Staple-7",
+			wantCode:    codes.OK,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.addUser(t, model.User{AccountID: "acc-1"})
+			saveTestResetCode(t, env, user.AccountID)
+
+			_, err := env.svc.ConfirmPasswordReset(context.Background(), &pb.ConfirmPasswordResetRequest{
+				LoginId:     user.LoginID,
+				AccountType: user.AccountType,
+				Code:        tc.code,
+				NewPassword: tc.newPassword,
+			})
+			assert.Equal(t, tc.wantCode, status.Code(err))
+			if tc.wantMessage != "" {
+				assert.Equal(t, tc.wantMessage, status.Convert(err).Message())
+			}
+
+			record, err := env.svc.resetRepo.GetResetCode(context.Background(), user.AccountID)
+			if !tc.codeKept {
+				assert.Error(t, err, "a used code is deleted")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.attempts, record.Attempts)
+		})
+	}
+}
+
+func TestConfirmPasswordResetLogsOutEverywhere(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, model.User{AccountID: "acc-1"})
+	login := env.login(t, user)
+	saveTestResetCode(t, env, user.AccountID)
+
+	_, err := env.svc.ConfirmPasswordReset(context.Background(), &pb.ConfirmPasswordResetRequest{
+		LoginId:     user.LoginID,
+		AccountType: user.AccountType,
+		Code:        testResetCode,
+		NewPassword: "Battery-Staple-7",
+	})
+	require.NoError(t, err)
+
+	_, err = env.svc.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	assert.Error(t, err)
+
+	// the code works once
+	_, err = env.svc.ConfirmPasswordReset(context.Background(), &pb.ConfirmPasswordResetRequest{
+		LoginId:     user.LoginID,
+		AccountType: user.AccountType,
+		Code:        testResetCode,
+		NewPassword: "Another-Staple-8",
+	})
+	requireCode(t, err, codes.Unauthenticated)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func refresh(env *testEnv, refreshToken string) (*pb.LoginResponse, error) {
+	return env.svc.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: refreshToken})
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	cases := map[string]struct {
+		token      func(t *testing.T, env *testEnv, login *pb.LoginResponse) string
+		wantReason string
+	}{
+		"access token": {
+			token:      func(t *testing.T, _ *testEnv, login *pb.LoginResponse) string { return login.AccessToken },
+			wantReason: constants.RefreshNotAllowed,
+		},
+		"malformed token": {
+			token:      func(t *testing.T, _ *testEnv, _ *pb.LoginResponse) string { return "not-a-jwt" },
+			wantReason: constants.AuthRshTokenInvalid,
+		},
+		"logged out session": {
+			token: func(t *testing.T, env *testEnv, login *pb.LoginResponse) string {
+				_, err := env.svc.Logout(context.Background(), &pb.LogoutRequest{RefreshToken: login.RefreshToken})
+				require.NoError(t, err)
+				return login.RefreshToken
+			},
+			wantReason: constants.SessionTokenMismatch,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			login := env.login(t, env.addUser(t, model.User{AccountID: "acc-1"}))
+
+			_, err := refresh(env, tc.token(t, env, login))
+			requireCode(t, err, codes.Unauthenticated)
+			assert.Equal(t, tc.wantReason, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser(t, model.User{AccountID: "acc-1"})
+	stolen := env.login(t, user)
+	otherDevice := env.login(t, user)
+
+	rotated, err := refresh(env, stolen.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, stolen.SessionId, rotated.SessionId, "rotation keeps the session")
+	assert.NotEqual(t, stolen.RefreshToken, rotated.RefreshToken)
+
+	// the rotated token coming back means one of the two holders isn't the user
+	_, err = refresh(env, stolen.RefreshToken)
+	requireCode(t, err, codes.Unauthenticated)
+	assert.Equal(t, constants.RefreshTokenReuseDetected, status.Convert(err).Message())
+
+	_, err = refresh(env, rotated.RefreshToken)
+	assert.Error(t, err, "the whole family is revoked, the latest token included")
+
+	_, err = refresh(env, otherDevice.RefreshToken)
+	assert.NoError(t, err, "sessions of other devices stay")
+}
+
+// refreshBarrier holds the first callers refreshing until all of them got past the reuse checks,
+// so they race for the rotation instead of finishing one after another
+type refreshBarrier struct {
+	repository.TokenRepository
+	arrived sync.WaitGroup
+	pending atomic.Int32
+}
+
+func newRefreshBarrier(repo repository.TokenRepository, callers int) *refreshBarrier {
+	b := &refreshBarrier{TokenRepository: repo}
+	b.arrived.Add(callers)
+	b.pending.Store(int32(callers))
+	return b
+}
+
+func (b *refreshBarrier) CheckToken(ctx context.Context, keyName, owner, token string) (bool, error) {
+	exists, err := b.TokenRepository.CheckToken(ctx, keyName, owner, token)
+	if keyName == constants.Refresh_token && b.pending.Add(-1) >= 0 {
+		b.arrived.Done()
+		b.arrived.Wait()
+	}
+	return exists, err
+}
+
+func TestRefreshTokenConcurrentReuse(t *testing.T) {
+	// the same refresh token presented in parallel, e.g. by the user and whoever copied it
+	const callers = 10
+	env := newTestEnv(t, func(d *Deps) { d.TokenRepo = newRefreshBarrier(d.TokenRepo, callers) })
+	login := env.login(t, env.addUser(t, model.User{AccountID: "acc-1"}))
+
+	type result struct {
+		resp *pb.LoginResponse
+		err  error
+	}
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make(chan result, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			resp, err := refresh(env, login.RefreshToken)
+			results <- result{resp, err}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	var rotated []*pb.LoginResponse
+	for res := range results {
+		if res.err == nil {
+			rotated = append(rotated, res.resp)
+			continue
+		}
+		requireCode(t, res.err, codes.Unauthenticated)
+	}
+	// the winner can still lose to a revocation that lands before it finishes, never more than one rotates
+	require.LessOrEqual(t, len(rotated), 1)
+
+	// losing the race is reuse, the family is revoked whichever request won
+	for _, resp := range rotated {
+		_, err := refresh(env, resp.RefreshToken)
+		assert.Error(t, err)
+	}
+}
@@ -8,14 +8,20 @@ import (
 	"time"
 
 	"github.com/ashish19912009/zrms/services/authN/internal/client"
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
 	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/helper"
 	"github.com/ashish19912009/zrms/services/authN/internal/logger"
 	"github.com/ashish19912009/zrms/services/authN/internal/mapper"
 	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/notifier"
 	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
 	"github.com/ashish19912009/zrms/services/authN/internal/token"
 	"github.com/ashish19912009/zrms/services/authN/pb"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -25,53 +31,129 @@ type AuthService interface {
 	RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginResponse, error)
 	VerifyAccessToken(ctx context.Context, req *pb.VerifyTokenRequest) (*pb.AuthClaims, error)
 	Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error)
+	ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error)
+	RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error)
+	RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeSessionResponse, error)
+	RevokeAccountTokens(ctx context.Context, req *pb.RevokeAccountTokensRequest) (*pb.RevokeSessionResponse, error)
+	UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.UnlockAccountResponse, error)
+	RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.PasswordResetResponse, error)
+	ConfirmPasswordReset(ctx context.Context, req *pb.ConfirmPasswordResetRequest) (*pb.PasswordResetResponse, error)
+	ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error)
+	EnrollMFA(ctx context.Context, req *pb.EnrollMFARequest) (*pb.EnrollMFAResponse, error)
+	ConfirmMFA(ctx context.Context, req *pb.ConfirmMFARequest) (*pb.ConfirmMFAResponse, error)
+	VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.LoginResponse, error)
+	RequestLoginOTP(ctx context.Context, req *pb.RequestLoginOTPRequest) (*pb.RequestLoginOTPResponse, error)
+	VerifyLoginOTP(ctx context.Context, req *pb.VerifyLoginOTPRequest) (*pb.LoginResponse, error)
+	ClientCredentials(ctx context.Context, req *pb.ClientCredentialsRequest) (*pb.ClientCredentialsResponse, error)
+	RevokeFranchiseSessions(ctx context.Context, req *pb.RevokeFranchiseSessionsRequest) (*pb.RevokeSessionResponse, error)
+	Impersonate(ctx context.Context, req *pb.ImpersonateRequest) (*pb.ImpersonateResponse, error)
+	ListMyFranchises(ctx context.Context, req *pb.ListMyFranchisesRequest) (*pb.ListMyFranchisesResponse, error)
+	SwitchFranchise(ctx context.Context, req *pb.SwitchFranchiseRequest) (*pb.LoginResponse, error)
+	CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error)
+	RotateAPIKey(ctx context.Context, req *pb.RotateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.APIKey, error)
+	VerifyAPIKey(ctx context.Context, req *pb.VerifyAPIKeyRequest) (*pb.AuthClaims, error)
 }
 
 type authService struct {
-	tokenManager token.TokenManager
-	tokenRepo    repository.TokenRepository
-	userRepo     repository.UserRepository
-	accessTTL    time.Duration
-	refreshTTL   time.Duration
-	client       client.AuthZClient
+	tokenManager  token.TokenManager
+	tokenRepo     repository.TokenRepository
+	userRepo      repository.UserRepository
+	sessionRepo   repository.SessionRepository
+	attemptRepo   repository.LoginAttemptRepository
+	resetRepo     repository.PasswordResetRepository
+	mfaRepo       repository.MFARepository
+	challengeRepo repository.MFAChallengeRepository
+	otpRepo       repository.LoginOTPRepository
+	apiKeyRepo    repository.APIKeyRepository
+	notifier      notifier.Notifier
+	lockout       config.LockoutConfig
+	mfa           config.MFAConfig
+	loginOTP      config.LoginOTPConfig
+	clientCreds   config.ClientCredentialsConfig
+	tokenPerms    config.TokenPermissionsConfig
+	impersonation config.ImpersonationConfig
+	apiKeys       config.APIKeysConfig
+	idleTimeout   config.IdleTimeoutConfig
+	policy        passwordpolicy.Policy
+	hasher        *passwordhash.Hasher
+	proxies       helper.TrustedProxies
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+	client        client.AuthZClient
+	lifecycle     *lifecycleCache
 }
 
-func NewAuthService(tokenManager token.TokenManager, tokenRepo repository.TokenRepository, userRepo repository.UserRepository) AuthService {
+// Deps holds what the auth service is built from. Repositories, the token manager, the hasher
+// and the authZ client are required, the config sections fall back to their defaults when left empty.
+type Deps struct {
+	TokenManager  token.TokenManager
+	TokenRepo     repository.TokenRepository
+	UserRepo      repository.UserRepository
+	SessionRepo   repository.SessionRepository
+	AttemptRepo   repository.LoginAttemptRepository
+	ResetRepo     repository.PasswordResetRepository
+	MFARepo       repository.MFARepository
+	ChallengeRepo repository.MFAChallengeRepository
+	OTPRepo       repository.LoginOTPRepository
+	APIKeyRepo    repository.APIKeyRepository
+	Notifier      notifier.Notifier
+	Hasher        *passwordhash.Hasher
+	AuthZClient   client.AuthZClient
+
+	Lockout           config.LockoutConfig
+	MFA               config.MFAConfig
+	LoginOTP          config.LoginOTPConfig
+	ClientCredentials config.ClientCredentialsConfig
+	TokenPermissions  config.TokenPermissionsConfig
+	Impersonation     config.ImpersonationConfig
+	APIKeys           config.APIKeysConfig
+	IdleTimeout       config.IdleTimeoutConfig
+	PasswordPolicy    passwordpolicy.Policy
+	TrustedProxies    helper.TrustedProxies // empty takes every client at its connection address
+}
+
+func NewAuthService(deps Deps) AuthService {
 	accessTokenTime, refreshTokenTime := getTokenTimer(constants.EnvVariable.ACCESS_TOKEN_TTL, constants.EnvVariable.REFRESH_TOKEN_TTL)
-	return &authService{
-		tokenManager: tokenManager,
-		tokenRepo:    tokenRepo,
-		userRepo:     userRepo,
-		accessTTL:    accessTokenTime,
-		refreshTTL:   refreshTokenTime,
-	}
+	return NewAuthServiceWithTTL(deps, accessTokenTime, refreshTokenTime)
 }
 
 // Constructor for testing and flexibility
-func NewAuthServiceWithTTL(
-	tm token.TokenManager,
-	tr repository.TokenRepository,
-	ur repository.UserRepository,
-	accessTTL, refreshTTL time.Duration,
-) AuthService {
+func NewAuthServiceWithTTL(deps Deps, accessTTL, refreshTTL time.Duration) AuthService {
 	return &authService{
-		tokenManager: tm,
-		tokenRepo:    tr,
-		userRepo:     ur,
-		accessTTL:    accessTTL,
-		refreshTTL:   refreshTTL,
+		tokenManager:  deps.TokenManager,
+		tokenRepo:     deps.TokenRepo,
+		userRepo:      deps.UserRepo,
+		sessionRepo:   deps.SessionRepo,
+		attemptRepo:   deps.AttemptRepo,
+		resetRepo:     deps.ResetRepo,
+		mfaRepo:       deps.MFARepo,
+		challengeRepo: deps.ChallengeRepo,
+		otpRepo:       deps.OTPRepo,
+		apiKeyRepo:    deps.APIKeyRepo,
+		notifier:      deps.Notifier,
+		lockout:       deps.Lockout.WithDefaults(),
+		mfa:           deps.MFA.WithDefaults(),
+		loginOTP:      deps.LoginOTP.WithDefaults(),
+		clientCreds:   deps.ClientCredentials.WithDefaults(),
+		tokenPerms:    deps.TokenPermissions.WithDefaults(),
+		impersonation: deps.Impersonation.WithDefaults(),
+		apiKeys:       deps.APIKeys.WithDefaults(),
+		idleTimeout:   deps.IdleTimeout.WithDefaults(),
+		policy:        deps.PasswordPolicy,
+		hasher:        deps.Hasher,
+		proxies:       deps.TrustedProxies,
+		accessTTL:     accessTTL,
+		refreshTTL:    refreshTTL,
+		client:        deps.AuthZClient,
+		lifecycle:     newLifecycleCache(lifecycleCacheTTL),
 	}
 }
 
-func verifyPassword(hashedPassword, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	if err != nil {
-		logger.Warn(constants.ErrInvalidPassword, map[string]interface{}{
-			"method": constants.Methods.VerifyPassword,
-		})
-		return false
-	}
-	return true
+// clientInfo describes the caller, see helper.GetClientInfo
+func (s *authService) clientInfo(ctx context.Context) *model.ClientInfo {
+	return helper.GetClientInfo(ctx, s.proxies)
 }
 
 func getTokenTimer(ACCESS_TOKEN_TTL string, REFRESH_TOKEN_TTL string) (time.Duration, time.Duration) {
@@ -110,62 +192,39 @@ func (s *authService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Logi
 		return nil, errors.New(constants.ValidationMissingCredentials)
 	}
 
+	// Repeated failures for the login_id or the client IP back off and then lock out
+	clientIP := s.clientInfo(ctx).IPAddress
+	if err := s.checkLoginAllowed(ctx, input.LoginID, clientIP); err != nil {
+		return nil, err
+	}
+
 	// Getting user information from database
 	var userDetails *model.User
 	userDetails, err := s.userRepo.GetUser(ctx, input.LoginID, input.AccountType)
 	if err != nil {
+		s.recordLoginFailure(ctx, input.LoginID, clientIP, nil)
 		return nil, errors.New(constants.WrongUsernamePassword)
 	}
 	if userDetails == nil {
 		return nil, status.Error(codes.Internal, constants.UserDataMissing)
 	}
 	if userDetails.Password != "" {
-		isCorrectPassword := verifyPassword(userDetails.Password, input.Password)
+		isCorrectPassword := s.verifyPassword(userDetails.Password, input.Password)
 		if isCorrectPassword {
-			//allResources, err := s.userRepo.GetFranchiseRolePermissions(ctx, userDetails.FranchiseID)
-			//getBatchPermission, err := s.client.BatchCheckAccess(ctx, userDetails.AccountID, userDetails.FranchiseID, allResources)
-			accessToken, err := s.tokenManager.GenerateAccessToken(userDetails.EmployeeID, userDetails.FranchiseID, userDetails.AccountID, userDetails.MobileNo, userDetails.AccountType, userDetails.Name, s.accessTTL)
-			if err != nil {
-				logger.Error(constants.FailedToGenerateAct, err, map[string]interface{}{
-					"method": constants.Methods.Login,
-					"step":   constants.GenerateAccessToken,
-				})
-				return nil, fmt.Errorf(constants.FailedToGenerateAct, err)
+			// A permanent lock or a blocked franchise is only revealed to someone who knows the password
+			if err := s.checkLifecycle(ctx, constants.Methods.Login, userDetails); err != nil {
+				return nil, err
 			}
+			s.resetLoginFailures(ctx, input.LoginID)
+			s.upgradePasswordHash(ctx, userDetails, input.Password)
 
-			refreshToken, err := s.tokenManager.GenerateRefreshToken(userDetails.AccountID, userDetails.AccountType, s.refreshTTL)
-			if err != nil {
-				logger.Error(constants.FailedToGenerateRsh, err, map[string]interface{}{
-					"method": constants.Methods.Login,
-					"step":   constants.GenerateRefreshToken,
-				})
-				return nil, fmt.Errorf(constants.FailedToGenerateRsh, err)
+			// Enrolled accounts and account types that require MFA get a challenge instead of tokens
+			if challenge, err := s.startMFAChallenge(ctx, userDetails, nil); err != nil || challenge != nil {
+				return challenge, err
 			}
-
-			// Store refresh token and access token in "in memory DB"
-
-			err = s.tokenRepo.StoreToken(ctx, constants.Access_token, userDetails.AccountID, accessToken, s.accessTTL)
-			if err != nil {
-				logger.Error(constants.FailedToStoreRshToken, err, map[string]interface{}{
-					"method": constants.Methods.Login,
-				})
-				return nil, fmt.Errorf("%s: %w", constants.FailedToStoreRshToken, err)
-			}
-			err = s.tokenRepo.StoreToken(ctx, constants.Refresh_token, userDetails.AccountID, refreshToken, s.refreshTTL)
-			if err != nil {
-				logger.Error(constants.FailedToStoreRshToken, err, map[string]interface{}{
-					"method": constants.Methods.Login,
-				})
-				return nil, fmt.Errorf("%s: %w", constants.FailedToStoreRshToken, err)
-			}
-
-			logger.Info(constants.SuccessfulLogin, map[string]interface{}{
-				"method":  constants.Methods.Login,
-				"user_id": userDetails.AccountID + "_" + userDetails.EmployeeID,
-			})
-
-			return mapper.LoginResponse(userDetails, accessToken, refreshToken), nil
+			return s.completeLogin(ctx, constants.Methods.Login, userDetails)
 		}
+		s.recordLoginFailure(ctx, input.LoginID, clientIP, userDetails)
 		return nil, fmt.Errorf(constants.WrongUsernamePassword)
 	}
 	logger.Fatal(constants.PasswordMissingFromServer, nil, map[string]interface{}{
@@ -175,6 +234,32 @@ func (s *authService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Logi
 	return nil, fmt.Errorf(constants.WrongUsernamePassword)
 }
 
+// completeLogin opens a new session for a user whose credentials were checked and issues its tokens
+func (s *authService) completeLogin(ctx context.Context, method string, user *model.User) (*pb.LoginResponse, error) {
+	session := s.newSession(ctx, user)
+	accessToken, refreshToken, err := s.issueTokens(ctx, method, user, session.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Every login gets its own session so a second device doesn't overwrite the first one
+	err = s.sessionRepo.CreateSession(ctx, session, s.refreshTTL)
+	if err != nil {
+		logger.Error(constants.FailedToStoreSession, err, map[string]interface{}{
+			"method": method,
+		})
+		return nil, fmt.Errorf("%s: %w", constants.FailedToStoreSession, err)
+	}
+
+	logger.Info(constants.SuccessfulLogin, map[string]interface{}{
+		"method":     method,
+		"user_id":    user.AccountID + "_" + user.EmployeeID,
+		"session_id": session.ID,
+	})
+
+	return mapper.LoginResponse(user, accessToken, refreshToken, session.ID), nil
+}
+
 func (s *authService) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginResponse, error) {
 	select {
 	case <-ctx.Done():
@@ -194,68 +279,133 @@ func (s *authService) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequ
 		logger.Error(constants.AuthRshTokenInvalid, err, map[string]interface{}{
 			"method": constants.Methods.RefreshToken,
 		})
-		return nil, fmt.Errorf(constants.AuthRshTokenInvalid, err)
+		return nil, status.Error(codes.Unauthenticated, constants.AuthRshTokenInvalid)
+	}
+
+	// Only refresh tokens refresh, an access or impersonation token with a valid signature must not
+	if claims.TokenType != constants.TokenTypeRefresh || claims.Act != nil {
+		logger.Error(constants.RefreshNotAllowed, nil, map[string]interface{}{
+			"method":     constants.Methods.RefreshToken,
+			"token_type": claims.TokenType,
+		})
+		return nil, status.Error(codes.Unauthenticated, constants.RefreshNotAllowed)
+	}
+
+	// Refresh tokens are bound to the session they were issued for
+	if claims.SessionID == "" {
+		logger.Error(constants.SessionTokenMismatch, nil, map[string]interface{}{
+			"method": constants.Methods.RefreshToken,
+		})
+		return nil, status.Error(codes.Unauthenticated, constants.SessionTokenMismatch)
+	}
+	session, err := s.sessionRepo.GetSession(ctx, claims.RegisteredClaims.Subject, claims.SessionID)
+	if err != nil {
+		logger.Error(constants.SessionTokenMismatch, err, map[string]interface{}{
+			"method":     constants.Methods.RefreshToken,
+			"session_id": claims.SessionID,
+		})
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, status.Error(codes.Unauthenticated, constants.SessionTokenMismatch)
+		}
+		return nil, status.Error(codes.Internal, constants.FailedToFetchSession)
+	}
+	// A long valid refresh token doesn't keep an abandoned device logged in
+	if err := s.checkIdle(ctx, constants.Methods.RefreshToken, session); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	// A refresh token that was already rotated should never come back, if it does it has leaked
+	record, err := s.tokenRepo.GetRefreshRecord(ctx, session.ID, claims.RegisteredClaims.ID)
+	if err != nil {
+		logger.Error(constants.AuthRshTokenInvalid, err, map[string]interface{}{
+			"method":     constants.Methods.RefreshToken,
+			"session_id": session.ID,
+		})
+		if errors.Is(err, repository.ErrRefreshRecordNotFound) {
+			return nil, status.Error(codes.Unauthenticated, constants.AuthRshTokenInvalid)
+		}
+		return nil, status.Error(codes.Internal, constants.AuthRshTokenInvalid)
+	}
+	if record.RotatedTo != "" {
+		s.handleRefreshTokenReuse(ctx, record)
+		return nil, status.Error(codes.Unauthenticated, constants.RefreshTokenReuseDetected)
 	}
 
 	// Check if refresh token exists in in_memory
-	exists, err := s.tokenRepo.CheckToken(ctx, constants.Refresh_token, claims.RegisteredClaims.Subject, input.RefreshToken)
+	exists, err := s.tokenRepo.CheckToken(ctx, constants.Refresh_token, sessionTokenOwner(session.AccountID, session.ID), input.RefreshToken)
 	if err != nil || !exists {
 		logger.Error(constants.AuthRshTokenInvalid, err, map[string]interface{}{
 			"method": constants.Methods.RefreshToken,
 			"check":  constants.RefreshTokenExistence,
 		})
-		return nil, fmt.Errorf("%s: %w", constants.AuthRshTokenInvalid, err)
+		if err != nil {
+			return nil, status.Error(codes.Internal, constants.AuthRshTokenInvalid)
+		}
+		return nil, status.Error(codes.Unauthenticated, constants.AuthRshTokenInvalid)
 	}
 	if exists {
 		// Getting user information from database
 		var userDetails *model.User
-		userDetails, err = s.userRepo.GetUser(ctx, claims.RegisteredClaims.Subject, claims.AccountType)
+		userDetails, err = s.userRepo.GetUserByID(ctx, claims.RegisteredClaims.Subject)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", constants.WrongUsernamePassword, err)
 		}
 		if userDetails == nil {
 			return nil, status.Error(codes.Internal, constants.UserDataMissing)
 		}
-		accessToken, err := s.tokenManager.GenerateAccessToken(userDetails.AccountID, userDetails.FranchiseID, userDetails.EmployeeID, userDetails.MobileNo, userDetails.AccountType, userDetails.Name, s.accessTTL)
-		if err != nil {
-			logger.Error(constants.FailedToGenerateAct, err, map[string]interface{}{
-				"method": constants.Methods.RefreshToken,
-				"step":   constants.GenerateAccessToken,
-			})
-			return nil, fmt.Errorf(constants.FailedToGenerateAct, err)
+		// An account or franchise blocked since login can't keep its session alive
+		if err := s.checkLifecycle(ctx, constants.Methods.RefreshToken, userDetails); err != nil {
+			return nil, err
 		}
-
-		newRefreshToken, err := s.tokenManager.GenerateRefreshToken(userDetails.AccountID, userDetails.AccountType, s.refreshTTL)
+		// The RotatedTo check above races with a concurrent refresh of the same token,
+		// only the request that claims the record may rotate it
+		if err := s.tokenRepo.ClaimRefreshRecord(ctx, record); err != nil {
+			if errors.Is(err, repository.ErrRefreshRecordClaimed) {
+				// tell the request holding the claim before revoking, it may still be writing the rotation
+				if err := s.tokenRepo.MarkRefreshRecordReused(ctx, record); err != nil {
+					logger.Error(constants.FailedToRevokeFamily, err, map[string]interface{}{
+						"method":    constants.Methods.RefreshToken,
+						"family_id": record.FamilyID,
+					})
+				}
+				s.handleRefreshTokenReuse(ctx, record)
+				return nil, status.Error(codes.Unauthenticated, constants.RefreshTokenReuseDetected)
+			}
+			return nil, status.Error(codes.Internal, constants.AuthRshTokenInvalid)
+		}
+		accessToken, newRefreshToken, err := s.issueTokens(ctx, constants.Methods.RefreshToken, userDetails, session.ID, record.JTI)
 		if err != nil {
-			logger.Error(constants.FailedToGenerateRsh, err, map[string]interface{}{
-				"method": constants.Methods.RefreshToken,
-			})
-			return nil, fmt.Errorf(constants.FailedToGenerateRsh, err)
+			if revoked, _ := s.rotationRevoked(ctx, record, session); revoked {
+				return nil, status.Error(codes.Unauthenticated, constants.RefreshTokenReuseDetected)
+			}
+			return nil, err
 		}
 
-		// Update refresh token in Redis
-		// make a abstraction layer to store token in any in memory DB i.e. Redis, memchached, Dragonfly
-		err = s.tokenRepo.StoreToken(ctx, constants.Access_token, userDetails.AccountID, accessToken, s.accessTTL)
+		// Rotation extends the session for another refresh window
+		session.ExpiresAt = time.Now().Add(s.refreshTTL)
+		err = s.sessionRepo.CreateSession(ctx, session, s.refreshTTL)
 		if err != nil {
-			logger.Error(constants.FailedToStoreRshToken, err, map[string]interface{}{
+			logger.Error(constants.FailedToStoreSession, err, map[string]interface{}{
 				"method": constants.Methods.RefreshToken,
 			})
 			return nil, err
 		}
-		err = s.tokenRepo.StoreToken(ctx, constants.Refresh_token, userDetails.AccountID, newRefreshToken, s.refreshTTL)
+		// the session saved above must not outlive a revocation that raced with it
+		revoked, err := s.rotationRevoked(ctx, record, session)
 		if err != nil {
-			logger.Error(constants.FailedToStoreRshToken, err, map[string]interface{}{
-				"method": constants.Methods.RefreshToken,
-			})
-			return nil, err
+			return nil, status.Error(codes.Internal, constants.AuthRshTokenInvalid)
+		}
+		if revoked {
+			return nil, status.Error(codes.Unauthenticated, constants.RefreshTokenReuseDetected)
 		}
 
 		logger.Info(constants.SuccessfulRefreshToken, map[string]interface{}{
-			"method": constants.Methods.RefreshToken,
-			"user":   claims.EmployeeID,
+			"method":     constants.Methods.RefreshToken,
+			"user":       claims.EmployeeID,
+			"session_id": session.ID,
 		})
 
-		return mapper.LoginResponse(userDetails, accessToken, newRefreshToken), nil
+		return mapper.LoginResponse(userDetails, accessToken, newRefreshToken, session.ID), nil
 	}
 	return nil, errors.New("couldn't refresh token")
 }
@@ -267,27 +417,96 @@ func (s *authService) VerifyAccessToken(ctx context.Context, req *pb.VerifyToken
 	default:
 	}
 	input := mapper.VerifyTokenRequest(req)
-	if input.AccessToken == "" {
+	claims, err := s.authenticate(ctx, constants.Methods.VerifyToken, input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(constants.SuccessfulTokenValidation, map[string]interface{}{
+		"method": constants.Methods.VerifyToken,
+		"user":   claims.EmployeeID,
+	})
+
+	return mapper.VerifyTokenResponse(claims), nil
+}
+
+// authenticate verifies an access token and checks it wasn't revoked and its session still exists
+func (s *authService) authenticate(ctx context.Context, method string, accessToken string) (*model.AuthClaims, error) {
+	if accessToken == "" {
 		logger.Error(constants.AuthAccessRequired, nil, map[string]interface{}{
-			"method": constants.Methods.VerifyToken,
+			"method": method,
 		})
 		return nil, fmt.Errorf(constants.AuthAccessRequired)
 	}
 
-	claims, err := s.tokenManager.VerifyAccessToken(input.AccessToken)
+	claims, err := s.tokenManager.VerifyAccessToken(accessToken)
 	if err != nil {
 		logger.Error(constants.AuthTokenVeriFailed, err, map[string]interface{}{
-			"method": constants.Methods.VerifyToken,
+			"method": method,
 		})
 		return nil, fmt.Errorf(constants.AuthTokenVeriFailed)
 	}
 
-	logger.Info(constants.SuccessfulTokenValidation, map[string]interface{}{
-		"method": constants.Methods.VerifyToken,
-		"user":   claims.EmployeeID,
-	})
+	// A refresh token has a valid signature too, it must not work as a bearer token
+	if claims.TokenType != constants.TokenTypeAccess && claims.TokenType != constants.TokenTypeService {
+		logger.Error(fmt.Sprintf(constants.WrongTokenType, claims.TokenType), nil, map[string]interface{}{
+			"method": method,
+			"jti":    claims.RegisteredClaims.ID,
+		})
+		return nil, fmt.Errorf(constants.AuthTokenVeriFailed)
+	}
 
-	return mapper.VerifyTokenResponse(claims), nil
+	// Logged out tokens and tokens of a revoked account are rejected before they expire
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		logger.Error(constants.AuthTokenRevoked, err, map[string]interface{}{
+			"method": method,
+			"jti":    claims.RegisteredClaims.ID,
+		})
+		return nil, fmt.Errorf(constants.AuthTokenRevoked)
+	}
+
+	// A token outlives a suspension or deactivation made in the account service, the account is checked again
+	if err := s.checkTokenLifecycle(ctx, method, claims); err != nil {
+		return nil, err
+	}
+
+	// An impersonation token lives as long as the admin's session, every use of it is flagged
+	if claims.Act != nil {
+		actorSession, err := s.sessionRepo.GetSession(ctx, claims.Act.Subject, claims.Act.SessionID)
+		if err != nil {
+			logger.Error(constants.AuthTokenVeriFailed, err, map[string]interface{}{
+				"method":          method,
+				"impersonated_by": claims.Act.Subject,
+			})
+			return nil, fmt.Errorf(constants.AuthTokenVeriFailed)
+		}
+		if err := s.checkIdle(ctx, method, actorSession); err != nil {
+			return nil, err
+		}
+		logger.Warn(constants.ImpersonatedRequest, map[string]interface{}{
+			"method":          method,
+			"account_id":      claims.RegisteredClaims.Subject,
+			"impersonated_by": claims.Act.Subject,
+			"jti":             claims.RegisteredClaims.ID,
+		})
+	}
+
+	// Tokens of a revoked session stop working even before they expire
+	if claims.SessionID != "" {
+		session, err := s.sessionRepo.GetSession(ctx, claims.RegisteredClaims.Subject, claims.SessionID)
+		if err != nil {
+			logger.Error(constants.AuthTokenVeriFailed, err, map[string]interface{}{
+				"method":     method,
+				"session_id": claims.SessionID,
+			})
+			return nil, fmt.Errorf(constants.AuthTokenVeriFailed)
+		}
+		// Every verified request counts as activity, an idle session is rejected before its JWT expires
+		if err := s.checkIdle(ctx, method, session); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
 }
 
 func (s *authService) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
@@ -312,21 +531,41 @@ func (s *authService) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Lo
 		return nil, fmt.Errorf(constants.AuthRshTokenInvalid, err)
 	}
 
-	// Remove refresh token from Redis
-	err = s.tokenRepo.DeleteToken(ctx, constants.Access_token, claims.RegisteredClaims.Subject)
-	if err != nil {
-		logger.Error(constants.ErrTokenDeletionFailed, err, map[string]interface{}{
-			"method": constants.Methods.Logout,
-		})
-		return nil, err
+	if claims.SessionID != "" {
+		err = s.revokeSession(ctx, claims.RegisteredClaims.Subject, claims.SessionID)
+		if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			logger.Error(constants.ErrTokenDeletionFailed, err, map[string]interface{}{
+				"method": constants.Methods.Logout,
+			})
+			return nil, err
+		}
+	} else {
+		// Remove refresh token from Redis
+		err = s.tokenRepo.DeleteToken(ctx, constants.Access_token, claims.RegisteredClaims.Subject)
+		if err != nil {
+			logger.Error(constants.ErrTokenDeletionFailed, err, map[string]interface{}{
+				"method": constants.Methods.Logout,
+			})
+			return nil, err
+		}
+
+		err = s.tokenRepo.DeleteToken(ctx, constants.Refresh_token, claims.RegisteredClaims.Subject)
+		if err != nil {
+			logger.Error(constants.ErrTokenDeletionFailed, err, map[string]interface{}{
+				"method": constants.Methods.Logout,
+			})
+			return nil, err
+		}
 	}
 
-	err = s.tokenRepo.DeleteToken(ctx, constants.Refresh_token, claims.RegisteredClaims.Subject)
-	if err != nil {
-		logger.Error(constants.ErrTokenDeletionFailed, err, map[string]interface{}{
-			"method": constants.Methods.Logout,
-		})
-		return nil, err
+	// The access token would otherwise stay valid until it expires
+	if input.AccessToken != "" {
+		accessClaims, err := s.tokenManager.VerifyAccessToken(input.AccessToken)
+		if err == nil && accessClaims.RegisteredClaims.Subject == claims.RegisteredClaims.Subject {
+			if err := s.denyToken(ctx, accessClaims); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	logger.Info(constants.MsgLogoutSuccess, map[string]interface{}{
@@ -336,3 +575,414 @@ func (s *authService) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Lo
 
 	return mapper.LogoutResponse(true), nil
 }
+
+func (s *authService) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.ListSessionsRequest(req)
+	if input.AccountID == "" {
+		logger.Error(constants.AccountIDRequired, nil, map[string]interface{}{
+			"method": constants.Methods.ListSessions,
+		})
+		return nil, status.Error(codes.InvalidArgument, constants.AccountIDRequired)
+	}
+	if _, err := s.authorizeSessions(ctx, constants.Methods.ListSessions, input.AccessToken, input.AccountID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListSessions(ctx, input.AccountID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToFetchSession)
+	}
+	return mapper.ListSessionsResponse(sessions), nil
+}
+
+func (s *authService) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RevokeSessionRequest(req)
+	if input.AccountID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountIDRequired)
+	}
+	if input.SessionID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.SessionIDRequired)
+	}
+	caller, err := s.authorizeSessions(ctx, constants.Methods.RevokeSession, input.AccessToken, input.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.revokeSession(ctx, input.AccountID, input.SessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, constants.SessionNotFound)
+		}
+		return nil, status.Error(codes.Internal, constants.FailedToDeleteSession)
+	}
+
+	logger.Info(constants.SessionRevoked, map[string]interface{}{
+		"method":     constants.Methods.RevokeSession,
+		"account_id": input.AccountID,
+		"session_id": input.SessionID,
+		"revoked_by": caller.RegisteredClaims.Subject,
+	})
+	return mapper.RevokeSessionResponse(true, 1), nil
+}
+
+func (s *authService) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeSessionResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RevokeAllSessionsRequest(req)
+	if input.AccountID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountIDRequired)
+	}
+	caller, err := s.authorizeSessions(ctx, constants.Methods.RevokeAllSessions, input.AccessToken, input.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.revokeAllSessions(ctx, input.AccountID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToDeleteSession)
+	}
+
+	logger.Info(constants.AllSessionsRevoked, map[string]interface{}{
+		"method":     constants.Methods.RevokeAllSessions,
+		"account_id": input.AccountID,
+		"revoked":    revoked,
+		"revoked_by": caller.RegisteredClaims.Subject,
+	})
+	return mapper.RevokeSessionResponse(true, revoked), nil
+}
+
+func (s *authService) RevokeAccountTokens(ctx context.Context, req *pb.RevokeAccountTokensRequest) (*pb.RevokeSessionResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	input := mapper.RevokeAccountTokensRequest(req)
+	if input.AccountID == "" {
+		return nil, status.Error(codes.InvalidArgument, constants.AccountIDRequired)
+	}
+	caller, err := s.authorizeAdmin(ctx, constants.Methods.RevokeAccountTokens, input.AccessToken, constants.ScopeRevokeAccountTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	// Access tokens can't be enumerated, so everything issued up to now is cut off
+	// for as long as the longest lived access token could still be valid
+	err = s.tokenRepo.RevokeTokensBefore(ctx, input.AccountID, time.Now(), s.accessTTL)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToRevokeAccount)
+	}
+	revoked, err := s.revokeAllSessions(ctx, input.AccountID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, constants.FailedToRevokeAccount)
+	}
+
+	logger.Warn(constants.AccountTokensRevoked, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAccountRevoked,
+		"method":                constants.Methods.RevokeAccountTokens,
+		"account_id":            input.AccountID,
+		"reason":                input.Reason,
+		"revoked":               revoked,
+		"revoked_by":            caller.RegisteredClaims.Subject,
+	})
+	return mapper.RevokeSessionResponse(true, revoked), nil
+}
+
+// authorizeSessions lets an account manage its own sessions and a super admin those of every account
+func (s *authService) authorizeSessions(ctx context.Context, method, accessToken, accountID string) (*model.AuthClaims, error) {
+	caller, err := s.authenticate(ctx, method, accessToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	// An impersonation token looks at the account, it doesn't sign it out of its devices
+	if isSuperAdmin(caller) || (caller.TokenType == constants.TokenTypeAccess && caller.Act == nil && caller.RegisteredClaims.Subject == accountID) {
+		return caller, nil
+	}
+	logger.Warn(constants.SessionAccessDenied, map[string]interface{}{
+		constants.SecurityEvent: constants.EventSessionDenied,
+		"method":                method,
+		"account_id":            accountID,
+		"caller":                caller.RegisteredClaims.Subject,
+		"token_type":            caller.TokenType,
+		"ip_address":            s.clientInfo(ctx).IPAddress,
+	})
+	return nil, status.Error(codes.PermissionDenied, constants.SessionAccessDenied)
+}
+
+// authorizeAdmin accepts a super admin's access token, and a service token granted scope when scope is set
+func (s *authService) authorizeAdmin(ctx context.Context, method, accessToken, scope string) (*model.AuthClaims, error) {
+	caller, err := s.authenticate(ctx, method, accessToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if isSuperAdmin(caller) || (scope != "" && hasScope(caller, scope)) {
+		return caller, nil
+	}
+	s.logAdminDenied(ctx, method, constants.AdminAccessDenied, caller)
+	return nil, status.Error(codes.PermissionDenied, constants.AdminAccessDenied)
+}
+
+// authorizeService accepts only a service token granted scope, user tokens are refused even for super admins
+func (s *authService) authorizeService(ctx context.Context, method, accessToken, scope string) (*model.AuthClaims, error) {
+	caller, err := s.authenticate(ctx, method, accessToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if hasScope(caller, scope) {
+		return caller, nil
+	}
+	s.logAdminDenied(ctx, method, constants.ServiceAccessDenied, caller)
+	return nil, status.Error(codes.PermissionDenied, constants.ServiceAccessDenied)
+}
+
+func (s *authService) logAdminDenied(ctx context.Context, method, reason string, caller *model.AuthClaims) {
+	logger.Warn(reason, map[string]interface{}{
+		constants.SecurityEvent: constants.EventAdminDenied,
+		"method":                method,
+		"caller":                caller.RegisteredClaims.Subject,
+		"token_type":            caller.TokenType,
+		"ip_address":            s.clientInfo(ctx).IPAddress,
+	})
+}
+
+// isSuperAdmin is true for the own access token of a super admin, never for an impersonation or service token
+func isSuperAdmin(claims *model.AuthClaims) bool {
+	return claims.TokenType == constants.TokenTypeAccess && claims.Act == nil && claims.AccountType == constants.AccountTypeSuperAdmin
+}
+
+// hasScope is true for a service token that was granted scope
+func hasScope(claims *model.AuthClaims, scope string) bool {
+	return claims.TokenType == constants.TokenTypeService && containsString(claims.Scopes, scope)
+}
+
+// checkRevoked rejects denylisted tokens and tokens issued before the account was revoked
+func (s *authService) checkRevoked(ctx context.Context, claims *model.AuthClaims) error {
+	denied, err := s.tokenRepo.IsTokenDenied(ctx, claims.RegisteredClaims.ID)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return errors.New(constants.AuthTokenRevoked)
+	}
+
+	revokedBefore, err := s.tokenRepo.GetRevokedBefore(ctx, claims.RegisteredClaims.Subject)
+	if err != nil {
+		return err
+	}
+	if !revokedBefore.IsZero() && claims.RegisteredClaims.IssuedAt != nil && !claims.RegisteredClaims.IssuedAt.After(revokedBefore) {
+		return errors.New(constants.AuthTokenRevoked)
+	}
+
+	// Revoking the admin ends the impersonations they started
+	if claims.Act != nil {
+		actorRevokedBefore, err := s.tokenRepo.GetRevokedBefore(ctx, claims.Act.Subject)
+		if err != nil {
+			return err
+		}
+		if !actorRevokedBefore.IsZero() && claims.RegisteredClaims.IssuedAt != nil && !claims.RegisteredClaims.IssuedAt.After(actorRevokedBefore) {
+			return errors.New(constants.AuthTokenRevoked)
+		}
+	}
+
+	// Suspending or deactivating a franchise revokes the tokens of all its accounts at once
+	if claims.FranchiseID != "" {
+		franchiseRevokedBefore, err := s.tokenRepo.GetRevokedBefore(ctx, franchiseRevocationKey(claims.FranchiseID))
+		if err != nil {
+			return err
+		}
+		if !franchiseRevokedBefore.IsZero() && claims.RegisteredClaims.IssuedAt != nil && !claims.RegisteredClaims.IssuedAt.After(franchiseRevokedBefore) {
+			return errors.New(constants.AuthTokenRevoked)
+		}
+	}
+	return nil
+}
+
+// denyToken denylists the jti for the rest of the token's lifetime
+func (s *authService) denyToken(ctx context.Context, claims *model.AuthClaims) error {
+	if claims.RegisteredClaims.ExpiresAt == nil {
+		return nil
+	}
+	return s.tokenRepo.DenyToken(ctx, claims.RegisteredClaims.ID, time.Until(claims.RegisteredClaims.ExpiresAt.Time))
+}
+
+// issueTokens generates an access and refresh token pair for the session and stores both.
+// parentJTI links the new refresh token to the one it replaces, it is empty at login.
+func (s *authService) issueTokens(ctx context.Context, method string, user *model.User, sessionID, parentJTI string) (string, string, error) {
+	// Permissions are computed again on every refresh, so a rotated token picks up role changes
+	perms := s.effectivePermissions(ctx, method, user)
+	accessToken, err := s.tokenManager.GenerateAccessToken(user.EmployeeID, user.FranchiseID, user.AccountID, user.MobileNo, user.AccountType, user.Name, sessionID, perms, s.accessTTL)
+	if err != nil {
+		logger.Error(constants.FailedToGenerateAct, err, map[string]interface{}{
+			"method": method,
+			"step":   constants.GenerateAccessToken,
+		})
+		return "", "", fmt.Errorf(constants.FailedToGenerateAct, err)
+	}
+
+	refreshToken, jti, err := s.tokenManager.GenerateRefreshToken(user.AccountID, user.AccountType, sessionID, s.refreshTTL)
+	if err != nil {
+		logger.Error(constants.FailedToGenerateRsh, err, map[string]interface{}{
+			"method": method,
+			"step":   constants.GenerateRefreshToken,
+		})
+		return "", "", fmt.Errorf(constants.FailedToGenerateRsh, err)
+	}
+
+	// Store refresh token and access token in "in memory DB"
+	owner := sessionTokenOwner(user.AccountID, sessionID)
+	err = s.tokenRepo.StoreToken(ctx, constants.Access_token, owner, accessToken, s.accessTTL)
+	if err != nil {
+		logger.Error(constants.FailedToStoreRshToken, err, map[string]interface{}{
+			"method": method,
+		})
+		return "", "", fmt.Errorf("%s: %w", constants.FailedToStoreRshToken, err)
+	}
+	err = s.tokenRepo.StoreToken(ctx, constants.Refresh_token, owner, refreshToken, s.refreshTTL)
+	if err != nil {
+		logger.Error(constants.FailedToStoreRshToken, err, map[string]interface{}{
+			"method": method,
+		})
+		return "", "", fmt.Errorf("%s: %w", constants.FailedToStoreRshToken, err)
+	}
+
+	now := time.Now()
+	err = s.tokenRepo.StoreRefreshRecord(ctx, &model.RefreshTokenRecord{
+		JTI:       jti,
+		ParentJTI: parentJTI,
+		FamilyID:  sessionID,
+		AccountID: user.AccountID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", constants.FailedToStoreRshRecord, err)
+	}
+
+	if parentJTI != "" {
+		parent, err := s.tokenRepo.GetRefreshRecord(ctx, sessionID, parentJTI)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", constants.FailedToStoreRshRecord, err)
+		}
+		parent.RotatedTo = jti
+		if err := s.tokenRepo.StoreRefreshRecord(ctx, parent); err != nil {
+			return "", "", fmt.Errorf("%s: %w", constants.FailedToStoreRshRecord, err)
+		}
+	}
+	return accessToken, refreshToken, nil
+}
+
+// rotationRevoked reports whether a concurrent reuse of the refresh token was detected while it
+// was being rotated. The reuse is marked before the family is revoked, so a rotation that sees
+// the mark revokes the session again and one that doesn't was fully written before the revocation.
+func (s *authService) rotationRevoked(ctx context.Context, record *model.RefreshTokenRecord, session *model.Session) (bool, error) {
+	reused, err := s.tokenRepo.IsRefreshRecordReused(ctx, record)
+	if err != nil || !reused {
+		return false, err
+	}
+	if err := s.revokeSession(ctx, session.AccountID, session.ID); err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+		logger.Error(constants.FailedToRevokeFamily, err, map[string]interface{}{
+			"method":    constants.Methods.RefreshToken,
+			"family_id": session.ID,
+		})
+	}
+	return true, nil
+}
+
+// handleRefreshTokenReuse revokes the whole family of a refresh token that was presented after rotation
+func (s *authService) handleRefreshTokenReuse(ctx context.Context, record *model.RefreshTokenRecord) {
+	info := s.clientInfo(ctx)
+	logger.Warn(constants.RefreshTokenReuseDetected, map[string]interface{}{
+		constants.SecurityEvent: constants.EventRefreshTokenReuse,
+		"method":                constants.Methods.RefreshToken,
+		"account_id":            record.AccountID,
+		"family_id":             record.FamilyID,
+		"jti":                   record.JTI,
+		"rotated_to":            record.RotatedTo,
+		"ip_address":            info.IPAddress,
+		"user_agent":            info.UserAgent,
+	})
+
+	err := s.revokeSession(ctx, record.AccountID, record.FamilyID)
+	if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+		logger.Error(constants.FailedToRevokeFamily, err, map[string]interface{}{
+			"method":    constants.Methods.RefreshToken,
+			"family_id": record.FamilyID,
+		})
+	}
+}
+
+func (s *authService) newSession(ctx context.Context, user *model.User) *model.Session {
+	info := s.clientInfo(ctx)
+	now := time.Now()
+	return &model.Session{
+		ID:             uuid.New().String(),
+		AccountID:      user.AccountID,
+		AccountType:    user.AccountType,
+		DeviceLabel:    info.DeviceLabel,
+		IPAddress:      info.IPAddress,
+		UserAgent:      info.UserAgent,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.refreshTTL),
+		LastActivityAt: now,
+	}
+}
+
+// revokeSession removes the session, the tokens issued for it and its refresh token family
+func (s *authService) revokeSession(ctx context.Context, accountID, sessionID string) error {
+	sessionErr := s.sessionRepo.DeleteSession(ctx, accountID, sessionID)
+	if sessionErr != nil && !errors.Is(sessionErr, repository.ErrSessionNotFound) {
+		return sessionErr
+	}
+	if err := s.tokenRepo.RevokeFamily(ctx, sessionID); err != nil {
+		return err
+	}
+	owner := sessionTokenOwner(accountID, sessionID)
+	for _, keyName := range []string{constants.Access_token, constants.Refresh_token} {
+		if err := s.tokenRepo.DeleteToken(ctx, keyName, owner); err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+			return err
+		}
+	}
+	return sessionErr
+}
+
+func (s *authService) revokeAllSessions(ctx context.Context, accountID string) (int, error) {
+	return s.revokeOtherSessions(ctx, accountID, "")
+}
+
+// revokeOtherSessions revokes every session of the account except keepSessionID
+func (s *authService) revokeOtherSessions(ctx context.Context, accountID, keepSessionID string) (int, error) {
+	sessions, err := s.sessionRepo.ListSessions(ctx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == keepSessionID {
+			continue
+		}
+		err := s.revokeSession(ctx, accountID, session.ID)
+		if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// sessionTokenOwner scopes stored tokens to a single session of the account
+func sessionTokenOwner(accountID, sessionID string) string {
+	return fmt.Sprintf("%s:%s", accountID, sessionID)
+}
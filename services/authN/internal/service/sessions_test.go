@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSessionRPCsAuthorization(t *testing.T) {
+	env := newTestEnv(t)
+	owner := env.addUser(t, model.User{AccountID: "acc-owner"})
+	other := env.addUser(t, model.User{AccountID: "acc-other"})
+	admin := env.addUser(t, model.User{AccountID: "acc-admin", AccountType: constants.AccountTypeSuperAdmin})
+
+	otherLogin := env.login(t, other)
+	adminLogin := env.login(t, admin)
+
+	cases := map[string]struct {
+		token func(t *testing.T) string
+		want  codes.Code
+	}{
+		"own access token":  {func(t *testing.T) string { return env.login(t, owner).AccessToken }, codes.OK},
+		"super admin":       {func(t *testing.T) string { return adminLogin.AccessToken }, codes.OK},
+		"other account":     {func(t *testing.T) string { return otherLogin.AccessToken }, codes.PermissionDenied},
+		"service token":     {func(t *testing.T) string { return env.serviceToken(t, "billing", constants.ScopeAuthZCheck) }, codes.PermissionDenied},
+		"own refresh token": {func(t *testing.T) string { return env.login(t, owner).RefreshToken }, codes.Unauthenticated},
+		"missing token":     {func(t *testing.T) string { return "" }, codes.Unauthenticated},
+		"malformed token":   {func(t *testing.T) string { return "not-a-jwt" }, codes.Unauthenticated},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			victim := env.login(t, owner)
+			token := tc.token(t)
+			ctx := context.Background()
+
+			_, err := env.svc.ListSessions(ctx, &pb.ListSessionsRequest{AccountId: owner.AccountID, AccessToken: token})
+			assert.Equal(t, tc.want, status.Code(err), "ListSessions")
+
+			_, err = env.svc.RevokeSession(ctx, &pb.RevokeSessionRequest{AccountId: owner.AccountID, SessionId: victim.SessionId, AccessToken: token})
+			assert.Equal(t, tc.want, status.Code(err), "RevokeSession")
+
+			_, err = env.svc.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{AccountId: owner.AccountID, AccessToken: token})
+			assert.Equal(t, tc.want, status.Code(err), "RevokeAllSessions")
+
+			// a denied caller leaves the sessions of the account alone
+			_, err = env.svc.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: victim.RefreshToken})
+			if tc.want == codes.OK {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRevokeAccountTokensAuthorization(t *testing.T) {
+	cases := map[string]struct {
+		token func(t *testing.T, env *testEnv) string
+		want  codes.Code
+	}{
+		"super admin": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-admin")).AccessToken
+		}, codes.OK},
+		"scoped service token": {func(t *testing.T, env *testEnv) string {
+			return env.serviceToken(t, "account-service", constants.ScopeRevokeAccountTokens)
+		}, codes.OK},
+		"unscoped service token": {func(t *testing.T, env *testEnv) string {
+			return env.serviceToken(t, "account-service", constants.ScopeAuthZCheck)
+		}, codes.PermissionDenied},
+		"other account": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-manager")).AccessToken
+		}, codes.PermissionDenied},
+		"account itself": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-target")).AccessToken
+		}, codes.PermissionDenied},
+		"super admin's refresh token": {func(t *testing.T, env *testEnv) string {
+			return env.login(t, env.users.get("acc-admin")).RefreshToken
+		}, codes.Unauthenticated},
+		"missing token": {func(t *testing.T, env *testEnv) string { return "" }, codes.Unauthenticated},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// a revocation cuts off everything issued in the same second, every case starts over
+			env := newTestEnv(t)
+			target := env.addUser(t, model.User{AccountID: "acc-target"})
+			env.addUser(t, model.User{AccountID: "acc-admin", AccountType: constants.AccountTypeSuperAdmin})
+			env.addUser(t, model.User{AccountID: "acc-manager"})
+
+			victim := env.login(t, target)
+			token := tc.token(t, env)
+
+			_, err := env.svc.RevokeAccountTokens(context.Background(), &pb.RevokeAccountTokensRequest{AccountId: target.AccountID, Reason: "suspended", AccessToken: token})
+			assert.Equal(t, tc.want, status.Code(err))
+
+			_, err = env.svc.VerifyAccessToken(context.Background(), &pb.VerifyTokenRequest{AccessToken: victim.AccessToken})
+			if tc.want == codes.OK {
+				assert.Error(t, err, "tokens issued before the revocation stop working")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/config"
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/jwtkeys"
+	"github.com/ashish19912009/zrms/services/authN/internal/mfa"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"github.com/ashish19912009/zrms/services/authN/internal/repository"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+	"github.com/ashish19912009/zrms/services/authN/internal/token"
+	"github.com/ashish19912009/zrms/services/authN/pb"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testPassword = "Correct-Horse-9"
+
+// testEnv is an auth service on LightningDB backed repositories, the tables of Postgres are faked
+type testEnv struct {
+	svc      *authService
+	tokens   token.TokenManager
+	users    *fakeUserRepo
+	mfa      *fakeMFARepo
+	notifier *fakeNotifier
+}
+
+// newTestEnv builds the service, configure can change the deps before it is created
+func newTestEnv(t *testing.T, configure ...func(*Deps)) *testEnv {
+	t.Helper()
+	mem := store.NewLightningDB(nil)
+	t.Cleanup(func() { _ = mem.Close() })
+
+	hasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.AlgBcrypt, BcryptCost: 4})
+	require.NoError(t, err)
+
+	env := &testEnv{
+		tokens:   newTestTokenManager(t),
+		users:    newFakeUserRepo(),
+		mfa:      &fakeMFARepo{enrollments: map[string]*model.MFAEnrollment{}},
+		notifier: &fakeNotifier{},
+	}
+	deps := Deps{
+		TokenManager:   env.tokens,
+		TokenRepo:      repository.NewTokenRepository(mem),
+		UserRepo:       env.users,
+		SessionRepo:    repository.NewSessionRepository(mem),
+		AttemptRepo:    repository.NewLoginAttemptRepository(mem),
+		ResetRepo:      repository.NewPasswordResetRepository(mem),
+		MFARepo:        env.mfa,
+		ChallengeRepo:  repository.NewMFAChallengeRepository(mem),
+		OTPRepo:        repository.NewLoginOTPRepository(mem),
+		Notifier:       env.notifier,
+		Hasher:         hasher,
+		PasswordPolicy: passwordpolicy.Default(),
+		// MFA tests turn it back on, everyone else logs in with the password alone
+		MFA: config.MFAConfig{RequiredAccountTypes: []string{}},
+	}
+	for _, c := range configure {
+		c(&deps)
+	}
+	env.svc = NewAuthServiceWithTTL(deps, 15*time.Minute, 24*time.Hour).(*authService)
+	return env
+}
+
+// newTestTokenManager signs with a fresh Ed25519 key written to a temp dir
+func newTestTokenManager(t *testing.T) token.TokenManager {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePEM, err := jwtkeys.MarshalPrivateKey(private)
+	require.NoError(t, err)
+	publicPEM, err := jwtkeys.MarshalPublicKey(public)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath, publicPath := filepath.Join(dir, "private.pem"), filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privatePath, privatePEM, 0o600))
+	require.NoError(t, os.WriteFile(publicPath, publicPEM, 0o600))
+
+	tm, err := token.NewjwtManager(privatePath, publicPath, "", config.JWTHeaderConfig{Kid: "test"})
+	require.NoError(t, err)
+	return tm
+}
+
+// addUser stores an active account with testPassword, fields of user override the defaults
+func (e *testEnv) addUser(t *testing.T, user model.User) *model.User {
+	t.Helper()
+	if user.LoginID == "" {
+		user.LoginID = user.AccountID + "@example.com"
+	}
+	if user.AccountType == "" {
+		user.AccountType = "manager"
+	}
+	if user.EmployeeID == "" {
+		user.EmployeeID = "emp-" + user.AccountID
+	}
+	if user.Name == "" {
+		user.Name = "Test " + user.AccountID
+	}
+	if user.MobileNo == "" {
+		user.MobileNo = "+910000000000"
+	}
+	if user.Status == "" {
+		user.Status = constants.AccountStatusActive
+	}
+	hash, err := e.svc.hasher.Hash(testPassword)
+	require.NoError(t, err)
+	user.Password = hash
+	if user.FranchiseID != "" {
+		e.users.setFranchise(user.FranchiseID, constants.FranchiseStatusActive)
+	}
+	e.users.add(&user)
+	return &user
+}
+
+// login signs user in with testPassword
+func (e *testEnv) login(t *testing.T, user *model.User) *pb.LoginResponse {
+	t.Helper()
+	resp, err := e.svc.Login(context.Background(), &pb.LoginRequest{LoginId: user.LoginID, Password: testPassword, AccountType: user.AccountType})
+	require.NoError(t, err)
+	return resp
+}
+
+// serviceToken issues a service token for clientID with scopes
+func (e *testEnv) serviceToken(t *testing.T, clientID string, scopes ...string) string {
+	t.Helper()
+	tok, _, err := e.tokens.GenerateServiceToken(clientID, scopes, time.Minute)
+	require.NoError(t, err)
+	return tok
+}
+
+// enrollMFA gives the account a confirmed TOTP enrollment and returns its secret
+func (e *testEnv) enrollMFA(t *testing.T, accountID string) string {
+	t.Helper()
+	secret, err := mfa.GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, e.mfa.SaveMFA(context.Background(), &model.MFAEnrollment{
+		AccountID:   accountID,
+		Secret:      secret,
+		ConfirmedAt: &now,
+		CreatedAt:   now,
+	}))
+	return secret
+}
+
+// mfaCode is the TOTP code of secret for the current step
+func mfaCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := mfa.Code(secret, mfa.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func requireCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	require.Error(t, err)
+	require.Equal(t, code, status.Code(err), err.Error())
+}
+
+type fakeUserRepo struct {
+	mu         sync.Mutex
+	users      map[string]*model.User
+	history    map[string][]string
+	franchises map[string]*model.FranchiseStatus
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{
+		users:      map[string]*model.User{},
+		history:    map[string][]string{},
+		franchises: map[string]*model.FranchiseStatus{},
+	}
+}
+
+func (r *fakeUserRepo) add(user *model.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.AccountID] = user
+}
+
+// get returns the stored account
+func (r *fakeUserRepo) get(accountID string) *model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.users[accountID]
+}
+
+func (r *fakeUserRepo) setFranchise(franchiseID, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.franchises[franchiseID] = &model.FranchiseStatus{ID: franchiseID, Status: status}
+}
+
+func (r *fakeUserRepo) find(match func(*model.User) bool) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, errors.New(constants.ErrUserNotFound)
+}
+
+func (r *fakeUserRepo) GetUser(ctx context.Context, loginID, accountType string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.LoginID == loginID && u.AccountType == accountType })
+}
+
+func (r *fakeUserRepo) GetUserByID(ctx context.Context, accountID string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.AccountID == accountID })
+}
+
+func (r *fakeUserRepo) GetUserByMobile(ctx context.Context, mobileNo, accountType string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.MobileNo == mobileNo && u.AccountType == accountType })
+}
+
+func (r *fakeUserRepo) GetFranchiseRolePermissions(ctx context.Context, franchiseID string) ([]*model.ResourceAction, error) {
+	return nil, nil
+}
+
+func (r *fakeUserRepo) UpdateAccountStatus(ctx context.Context, accountID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[accountID]; ok {
+		u.Status = status
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) UpdatePassword(ctx context.Context, accountID, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[accountID]; ok {
+		u.Password = passwordHash
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) AddPasswordHistory(ctx context.Context, accountID, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.history[accountID] = append([]string{passwordHash}, r.history[accountID]...)
+	return nil
+}
+
+func (r *fakeUserRepo) GetPasswordHistory(ctx context.Context, accountID string, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	history := r.history[accountID]
+	if len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+func (r *fakeUserRepo) GetFranchiseStatus(ctx context.Context, franchiseID string) (*model.FranchiseStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	franchise, ok := r.franchises[franchiseID]
+	if !ok {
+		return nil, repository.ErrFranchiseNotFound
+	}
+	return franchise, nil
+}
+
+func (r *fakeUserRepo) ListFranchiseAccountIDs(ctx context.Context, franchiseID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for _, u := range r.users {
+		if u.FranchiseID == franchiseID {
+			ids = append(ids, u.AccountID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeUserRepo) ListFranchiseMemberships(ctx context.Context, accountID, ownerID string) ([]*model.FranchiseMembership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var memberships []*model.FranchiseMembership
+	for _, u := range r.users {
+		if u.AccountID != accountID && (ownerID == "" || u.OwnerID != ownerID) {
+			continue
+		}
+		membership := &model.FranchiseMembership{FranchiseID: u.FranchiseID, AccountID: u.AccountID}
+		if franchise, ok := r.franchises[u.FranchiseID]; ok {
+			membership.FranchiseStatus = franchise.Status
+		}
+		memberships = append(memberships, membership)
+	}
+	return memberships, nil
+}
+
+type fakeMFARepo struct {
+	mu          sync.Mutex
+	enrollments map[string]*model.MFAEnrollment
+}
+
+func (r *fakeMFARepo) GetMFA(ctx context.Context, accountID string) (*model.MFAEnrollment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	enrollment, ok := r.enrollments[accountID]
+	if !ok {
+		return nil, repository.ErrMFANotEnrolled
+	}
+	copied := *enrollment
+	return &copied, nil
+}
+
+func (r *fakeMFARepo) SaveMFA(ctx context.Context, enrollment *model.MFAEnrollment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *enrollment
+	r.enrollments[enrollment.AccountID] = &copied
+	return nil
+}
+
+// fakeNotifier keeps the messages so tests can read the codes that were sent
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []*model.Notification
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, msg *model.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, msg)
+	return nil
+}
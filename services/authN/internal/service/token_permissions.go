@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+	"github.com/ashish19912009/zrms/services/authN/internal/model"
+	"google.golang.org/grpc/metadata"
+)
+
+// effectivePermissions asks AuthZ which of the franchise's role permissions the account holds.
+// Any failure only costs the embedded set, downstream services then fall back to CheckAccess.
+func (s *authService) effectivePermissions(ctx context.Context, method string, user *model.User) *model.TokenPermissions {
+	if !s.tokenPerms.Enabled || s.client == nil || user.FranchiseID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.tokenPerms.Timeout)
+	defer cancel()
+
+	resources, err := s.userRepo.GetFranchiseRolePermissions(ctx, user.FranchiseID)
+	if err != nil || len(resources) == 0 {
+		logger.Warn(constants.PermissionsNotEmbedded, map[string]interface{}{
+			"method":     method,
+			"account_id": user.AccountID,
+			"step":       constants.Methods.GetFranchiseRolePermissions,
+		})
+		return nil
+	}
+
+	// AuthZ only answers authenticated callers, authN presents a short lived service token of its own
+	serviceToken, _, err := s.tokenManager.GenerateServiceToken(s.tokenPerms.ClientID, []string{constants.ScopeAuthZCheck}, s.tokenPerms.Timeout)
+	if err != nil {
+		logger.Error(constants.PermissionsNotEmbedded, err, map[string]interface{}{
+			"method":     method,
+			"account_id": user.AccountID,
+		})
+		return nil
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+serviceToken)
+
+	decisions, err := s.client.BatchCheckAccess(ctx, user.AccountID, user.FranchiseID, resources)
+	if err != nil {
+		logger.Warn(constants.PermissionsNotEmbedded, map[string]interface{}{
+			"method":     method,
+			"account_id": user.AccountID,
+			"step":       constants.Methods.BatchCheckAccess,
+		})
+		return nil
+	}
+
+	var allowed []string
+	var version string
+	for _, result := range decisions.Results {
+		if result.Decision == nil || result.ResAct == nil {
+			continue
+		}
+		if version == "" {
+			version = result.Decision.PolicyVersion
+		}
+		if result.Decision.Allowed {
+			allowed = append(allowed, result.ResAct.Resource+":"+result.ResAct.Action)
+		}
+	}
+	return compactPermissions(allowed, version, s.tokenPerms.MaxEmbedded)
+}
+
+// compactPermissions sorts and dedups the pairs and drops them in favour of the hash when there are too many
+func compactPermissions(allowed []string, version string, maxEmbedded int) *model.TokenPermissions {
+	sort.Strings(allowed)
+	unique := allowed[:0]
+	for i, p := range allowed {
+		if i == 0 || p != allowed[i-1] {
+			unique = append(unique, p)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(unique, "\n")))
+	perms := &model.TokenPermissions{
+		Hash:    hex.EncodeToString(sum[:]),
+		Version: version,
+	}
+	if len(unique) <= maxEmbedded {
+		perms.Permissions = unique
+	}
+	return perms
+}
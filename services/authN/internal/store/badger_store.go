@@ -25,6 +25,9 @@ func NewBadgerStore(config *BadgerConfig) (*BadgerStore, error) {
 
 	opts := badger.DefaultOptions(config.Dir)
 	opts.Logger = nil // Disable internal logging unless configured
+	if config.InMemory {
+		opts = opts.WithDir("").WithValueDir("").WithInMemory(true)
+	}
 
 	if !config.SyncWrites {
 		opts.SyncWrites = false
@@ -65,16 +68,52 @@ func (b *BadgerStore) SetWithTTL(key string, value interface{}, ttl time.Duratio
 	})
 }
 
+// SetIfNotExists reads and writes the key in one transaction. Badger aborts the later of two
+// conflicting transactions, it is retried and then sees the key the other one wrote.
+func (b *BadgerStore) SetIfNotExists(key string, value interface{}, ttl time.Duration) error {
+	var val []byte
+	switch v := value.(type) {
+	case []byte:
+		val = v
+	case string:
+		val = []byte(v)
+	default:
+		return fmt.Errorf("%w: unsupported value type", ErrBadgerOperation)
+	}
+
+	for {
+		err := b.db.Update(func(txn *badger.Txn) error {
+			_, err := txn.Get([]byte(key))
+			if err == nil {
+				return ErrKeyAlreadyExists
+			}
+			if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+
+			e := badger.NewEntry([]byte(key), val)
+			if ttl > 0 {
+				e.WithTTL(ttl)
+			}
+			return txn.SetEntry(e)
+		})
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+}
+
 func (b *BadgerStore) Get(key string) (interface{}, error) {
-	var valCopy []byte
+	var value string
 	err := b.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte(key))
 		if err != nil {
 			return err
 		}
 
+		// the conversion copies, val is only valid inside the transaction
 		return item.Value(func(val []byte) error {
-			valCopy = append([]byte{}, val...)
+			value = string(val)
 			return nil
 		})
 	})
@@ -85,13 +124,20 @@ func (b *BadgerStore) Get(key string) (interface{}, error) {
 		}
 		return nil, fmt.Errorf("%w: %v", ErrBadgerOperation, err)
 	}
-	return valCopy, nil
+	return value, nil
 }
 
 func (b *BadgerStore) Delete(key string) error {
-	return b.db.Update(func(txn *badger.Txn) error {
+	err := b.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get([]byte(key)); err != nil {
+			return err
+		}
 		return txn.Delete([]byte(key))
 	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return ErrKeyNotFound
+	}
+	return err
 }
 
 func (b *BadgerStore) Exists(key string) (bool, error) {
@@ -109,16 +155,20 @@ func (b *BadgerStore) Exists(key string) (bool, error) {
 	return false, fmt.Errorf("%w: %v", ErrBadgerOperation, err)
 }
 
-func (b *BadgerStore) Keys(prefix string) ([]string, error) {
+// Keys scans the literal prefix of the pattern and matches the rest like Redis does
+func (b *BadgerStore) Keys(pattern string) ([]string, error) {
 	var keys []string
 	err := b.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte(prefix)
+		opts.Prefix = []byte(literalPrefix(pattern))
+		opts.PrefetchValues = false
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
 		for it.Rewind(); it.Valid(); it.Next() {
-			keys = append(keys, string(it.Item().Key()))
+			if key := string(it.Item().Key()); matchPattern(pattern, key) {
+				keys = append(keys, key)
+			}
 		}
 		return nil
 	})
@@ -0,0 +1,81 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+	"github.com/ashish19912009/zrms/services/authN/internal/store/storetest"
+)
+
+// Every backend runs against an in-process fake so the suite needs no servers in CI
+
+func TestConformanceLightningDB(t *testing.T) {
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			return store.NewLightningDB(&store.LightningConfig{InitialCapacity: 16})
+		},
+	})
+}
+
+func TestConformanceRedis(t *testing.T) {
+	var mr *miniredis.Miniredis
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			mr = miniredis.RunT(t)
+			s, err := store.NewRedisStore(&store.RedisConfig{Address: mr.Addr()})
+			if err != nil {
+				t.Fatalf("redis store: %v", err)
+			}
+			return s
+		},
+		Advance: func(d time.Duration) { mr.FastForward(d) },
+	})
+}
+
+func TestConformanceDragonfly(t *testing.T) {
+	var mr *miniredis.Miniredis
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			mr = miniredis.RunT(t)
+			s, err := store.NewDragonflyStore(&store.DragonflyConfig{Address: mr.Addr()})
+			if err != nil {
+				t.Fatalf("dragonfly store: %v", err)
+			}
+			return s
+		},
+		Advance: func(d time.Duration) { mr.FastForward(d) },
+	})
+}
+
+func TestConformanceMemcached(t *testing.T) {
+	var server *storetest.MemcachedServer
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			server = storetest.NewMemcachedServer(t)
+			s, err := store.NewMemcachedStore(&store.MemcachedConfig{Addresses: []string{server.Addr()}, Timeout: time.Second})
+			if err != nil {
+				t.Fatalf("memcached store: %v", err)
+			}
+			return s
+		},
+		Advance: func(d time.Duration) { server.FastForward(d) },
+		NoKeys:  true,
+	})
+}
+
+func TestConformanceBadger(t *testing.T) {
+	if testing.Short() {
+		t.Skip("badger expires by the wall clock, the TTL cases take a few seconds")
+	}
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			s, err := store.NewBadgerStore(&store.BadgerConfig{InMemory: true})
+			if err != nil {
+				t.Fatalf("badger store: %v", err)
+			}
+			return s
+		},
+	})
+}
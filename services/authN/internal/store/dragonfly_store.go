@@ -53,9 +53,26 @@ func (d *DragonflyStore) Set(key string, value interface{}) error {
 }
 
 func (d *DragonflyStore) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0 // go-redis treats -1 as KEEPTTL
+	}
 	return d.client.Set(context.Background(), key, value, ttl).Err()
 }
 
+func (d *DragonflyStore) SetIfNotExists(key string, value interface{}, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	set, err := d.client.SetNX(context.Background(), key, value, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !set {
+		return ErrKeyAlreadyExists
+	}
+	return nil
+}
+
 func (d *DragonflyStore) Get(key string) (interface{}, error) {
 	val, err := d.client.Get(context.Background(), key).Result()
 	if err == redis.Nil {
@@ -65,7 +82,14 @@ func (d *DragonflyStore) Get(key string) (interface{}, error) {
 }
 
 func (d *DragonflyStore) Delete(key string) error {
-	return d.client.Del(context.Background(), key).Err()
+	deleted, err := d.client.Del(context.Background(), key).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
 }
 
 func (d *DragonflyStore) Exists(key string) (bool, error) {
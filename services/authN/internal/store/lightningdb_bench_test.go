@@ -0,0 +1,40 @@
+package store
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// BenchmarkLightningDBDecisionCache mimics authZ's hot path, a Get followed by a SetWithTTL
+// per decision from many goroutines. Compare shards=1 (one lock, the old layout) with the default.
+//
+//	go test ./internal/store -run '^$' -bench LightningDB -cpu 1,4,16
+func BenchmarkLightningDBDecisionCache(b *testing.B) {
+	for _, shards := range []int{1, 8, defaultShards} {
+		for _, policy := range []string{"none", "lru"} {
+			b.Run("shards="+strconv.Itoa(shards)+"/policy="+policy, func(b *testing.B) {
+				db := NewLightningDB(&LightningConfig{Shards: shards, InitialCapacity: 100000, MaxItems: 100000, EvictionPolicy: policy})
+				defer db.Close()
+				keys := make([]string, 50000)
+				for i := range keys {
+					keys[i] = "decision:" + strconv.Itoa(i)
+				}
+				var next atomic.Uint64
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					i := next.Add(7919)
+					for pb.Next() {
+						key := keys[i%uint64(len(keys))]
+						_, _ = db.Get(key)
+						_ = db.SetWithTTL(key, "allow", time.Minute)
+						i++
+					}
+				})
+			})
+		}
+	}
+}
@@ -2,15 +2,20 @@ package store
 
 import (
 	"errors"
+	"hash/maphash"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	ErrKeyAlreadyExists = errors.New("key already exists")
-	metricsHitCount     = prometheus.NewCounter(prometheus.CounterOpts{
+	ErrCapacityReached = errors.New(constants.CapacityReached)
+	ErrValueTooLarge   = errors.New(constants.ValueExceedsMaxBytes)
+	metricsHitCount    = prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "lightningdb_hits_total",
 		Help: "Total cache hits",
 	})
@@ -22,22 +27,53 @@ var (
 		Name: "lightningdb_items_current",
 		Help: "Current number of cached items",
 	})
+	metricsBytesCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lightningdb_bytes_current",
+		Help: "Estimated size of the cached items in bytes",
+	})
+	metricsEvictionCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lightningdb_evictions_total",
+		Help: "Total items evicted to make room, by eviction policy",
+	}, []string{"policy"})
 )
 
-// LightningDB implements InMemoryStore with a simple in-memory cache
+// entryOverhead approximates the map entry, item and eviction bookkeeping of one key
+const entryOverhead = 96
+
+const defaultShards = 32
+
+// LightningDB implements InMemoryStore with an in-memory cache split into shards, each with its
+// own lock, map and eviction policy, so lookups of different keys rarely wait on each other.
+// max_items and max_bytes apply to the whole store, eviction picks the victim in the shard being
+// written to. A shard with nothing to evict lets the write through, so the limits can be exceeded
+// by at most one item per shard until the next write to a populated shard evicts again.
 type LightningDB struct {
-	store  map[string]item
+	shards     []*lightningShard
+	mask       uint64
+	seed       maphash.Seed
+	config     *LightningConfig
+	evicting   bool // an eviction policy is set, fixed for the lifetime of the store
+	items      atomic.Int64
+	bytes      atomic.Int64
+	stop       chan struct{}
+	closeOnce  sync.Once
+	snapshotMu sync.Mutex // one snapshot file write at a time
+}
+
+type lightningShard struct {
 	mu     sync.RWMutex
-	config *LightningConfig
+	store  map[string]item
+	policy evictionPolicy // nil keeps the old behavior, FallbackStore or ErrCapacityReached
 }
 
 type item struct {
 	value      interface{}
 	expiration time.Time
+	size       int64
 }
 
 func init() {
-	prometheus.MustRegister(metricsHitCount, metricsMissCount, metricsItemCount)
+	prometheus.MustRegister(metricsHitCount, metricsMissCount, metricsItemCount, metricsBytesCount, metricsEvictionCount)
 }
 
 // NewLightningDB creates a new in-memory store
@@ -49,9 +85,27 @@ func NewLightningDB(config *LightningConfig) *LightningDB {
 			CleanupInterval: 5 * time.Minute,
 		}
 	}
+	policy, ok := newEvictionPolicy(config.EvictionPolicy)
+	if !ok {
+		logger.Error(constants.UnsupportedEvictionPolicy, nil, map[string]interface{}{"eviction_policy": config.EvictionPolicy})
+	}
+	count := shardCount(config.Shards)
 	db := &LightningDB{
-		store:  make(map[string]item, config.InitialCapacity),
-		config: config,
+		shards:   make([]*lightningShard, count),
+		mask:     uint64(count - 1),
+		seed:     maphash.MakeSeed(),
+		config:   config,
+		evicting: policy != nil,
+		stop:     make(chan struct{}),
+	}
+	for i := range db.shards {
+		db.shards[i] = db.newShard()
+	}
+	if config.SnapshotPath != "" {
+		db.loadSnapshot()
+		if config.SnapshotInterval > 0 {
+			go db.startSnapshots(config.SnapshotInterval)
+		}
 	}
 	// Start background cleanup if interval is set
 	if config.CleanupInterval > 0 {
@@ -60,48 +114,87 @@ func NewLightningDB(config *LightningConfig) *LightningDB {
 	return db
 }
 
-// Set stores a value with optional TTL (0 means no expiration)
+// shardCount rounds up to a power of two so a key picks its shard with a mask
+func shardCount(n int) int {
+	if n <= 0 {
+		n = defaultShards
+	}
+	count := 1
+	for count < n {
+		count <<= 1
+	}
+	return count
+}
+
+func (l *LightningDB) newShard() *lightningShard {
+	policy, _ := newEvictionPolicy(l.config.EvictionPolicy)
+	return &lightningShard{
+		store:  make(map[string]item, l.config.InitialCapacity/len(l.shards)),
+		policy: policy,
+	}
+}
+
+func (l *LightningDB) shard(key string) *lightningShard {
+	return l.shards[maphash.String(l.seed, key)&l.mask]
+}
+
+// Set stores a value without expiration
 func (l *LightningDB) Set(key string, value interface{}) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if l.config.MaxItems > 0 && len(l.store) >= l.config.MaxItems {
+	size := itemSize(key, value)
+	if !l.makeRoom(s, key, size) {
 		if l.config.FallbackStore != nil {
 			return l.config.FallbackStore.Set(key, value)
 		}
-		return errors.New("cache capacity reached")
+		return l.capacityError(size)
 	}
 
-	l.store[key] = item{value: value, expiration: time.Time{}}
-	metricsItemCount.Inc()
+	l.put(s, key, item{value: value, size: size})
 	return nil
 }
 
-// SetWithTTL stores a value with time-to-live in seconds
+// SetWithTTL stores a value with time-to-live, a ttl <= 0 never expires
 func (l *LightningDB) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if l.config.MaxItems > 0 && len(l.store) >= l.config.MaxItems {
+	size := itemSize(key, value)
+	if !l.makeRoom(s, key, size) {
 		if l.config.FallbackStore != nil {
 			return l.config.FallbackStore.SetWithTTL(key, value, ttl)
 		}
-		return errors.New("cache capacity reached")
+		return l.capacityError(size)
 	}
 
-	l.store[key] = item{
-		value:      value,
-		expiration: time.Now().Add(ttl),
+	var expiration time.Time
+	if ttl > 0 {
+		expiration = time.Now().Add(ttl)
 	}
+	l.put(s, key, item{
+		value:      value,
+		expiration: expiration,
+		size:       size,
+	})
 	return nil
 }
 
 // Get retrieves a value if it exists and isn't expired
 func (l *LightningDB) Get(key string) (interface{}, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+	s := l.shard(key)
+	// lru and lfu record every read, which needs the write lock
+	if l.evicting {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	} else {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	}
 
-	item, found := l.store[key]
+	item, found := s.store[key]
 	if !found {
 		metricsMissCount.Inc()
 		return nil, ErrKeyNotFound
@@ -109,33 +202,46 @@ func (l *LightningDB) Get(key string) (interface{}, error) {
 
 	if !item.expiration.IsZero() && time.Now().After(item.expiration) {
 		metricsMissCount.Inc()
-		go l.Delete(key) // Async cleanup
+		go l.deleteExpired(key) // Async cleanup
 		return nil, ErrKeyNotFound
 	}
 
+	if s.policy != nil {
+		s.policy.access(key)
+	}
 	metricsHitCount.Inc()
+	// []byte stays as set for the snapshot, Get hands out a string like the other backends
+	if b, ok := item.value.([]byte); ok {
+		return string(b), nil
+	}
 	return item.value, nil
 }
 
 // Delete removes a key
 func (l *LightningDB) Delete(key string) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if _, exists := l.store[key]; !exists {
+	item, exists := s.store[key]
+	if !exists {
 		return ErrKeyNotFound
 	}
 
-	delete(l.store, key)
+	l.drop(s, key, item)
+	if !item.expiration.IsZero() && time.Now().After(item.expiration) {
+		return ErrKeyNotFound
+	}
 	return nil
 }
 
 // Exists checks if a key exists and isn't expired
 func (l *LightningDB) Exists(key string) (bool, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+	s := l.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	item, found := l.store[key]
+	item, found := s.store[key]
 	if !found {
 		return false, nil
 	}
@@ -147,66 +253,204 @@ func (l *LightningDB) Exists(key string) (bool, error) {
 	return true, nil
 }
 
-// Keys returns all non-expired keys (warning: not scalable for large datasets)
+// Keys returns all non-expired keys matching the pattern (warning: not scalable for large datasets).
+// Shards are read one after another, so it is not a snapshot of the whole store.
 func (l *LightningDB) Keys(pattern string) ([]string, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-
 	var keys []string
 	now := time.Now()
 
-	for k, item := range l.store {
-		if !item.expiration.IsZero() && now.After(item.expiration) {
-			continue // Skip expired items
+	for _, s := range l.shards {
+		s.mu.RLock()
+		for k, item := range s.store {
+			if !item.expiration.IsZero() && now.After(item.expiration) {
+				continue // Skip expired items
+			}
+			if matchPattern(pattern, k) {
+				keys = append(keys, k)
+			}
 		}
-		keys = append(keys, k)
+		s.mu.RUnlock()
 	}
 
 	return keys, nil
 }
 
-// Close cleans up resources (no-op for in-memory store)
+// Close stops the background cleanup and snapshots and writes the last snapshot, the data stays readable
 func (l *LightningDB) Close() error {
-	return nil
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.stop)
+		err = l.Snapshot()
+	})
+	return err
 }
 
-// SetIfNotExists only sets the key if it doesn't already exist
-func (l *LightningDB) SetIfNotExists(key string, value interface{}) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// SetIfNotExists only sets the key if it doesn't exist or has expired, a ttl <= 0 never expires
+func (l *LightningDB) SetIfNotExists(key string, value interface{}, ttl time.Duration) error {
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if _, exists := l.store[key]; exists {
+	now := time.Now()
+	if it, exists := s.store[key]; exists && (it.expiration.IsZero() || now.Before(it.expiration)) {
 		return ErrKeyAlreadyExists
 	}
 
-	l.store[key] = item{value: value}
+	size := itemSize(key, value)
+	if !l.makeRoom(s, key, size) {
+		// the fallback only sees keys that didn't fit here, so it can decide on its own
+		if l.config.FallbackStore != nil {
+			return l.config.FallbackStore.SetIfNotExists(key, value, ttl)
+		}
+		return l.capacityError(size)
+	}
+
+	var expiration time.Time
+	if ttl > 0 {
+		expiration = now.Add(ttl)
+	}
+	l.put(s, key, item{
+		value:      value,
+		expiration: expiration,
+		size:       size,
+	})
 	return nil
 }
 
+// deleteExpired only removes the key while it is still expired, it may have been set again meanwhile
+func (l *LightningDB) deleteExpired(key string) {
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, found := s.store[key]; found && !item.expiration.IsZero() && time.Now().After(item.expiration) {
+		l.drop(s, key, item)
+	}
+}
+
+// startCleanup sweeps one shard at a time, the others keep serving meanwhile
 func (l *LightningDB) startCleanup(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		l.mu.Lock()
-		now := time.Now()
-		for k, item := range l.store {
-			if !item.expiration.IsZero() && now.After(item.expiration) {
-				delete(l.store, k)
-			}
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		for _, s := range l.shards {
+			l.cleanupShard(s)
 		}
-		l.mu.Unlock()
 	}
 }
 
-func (l *LightningDB) FlushAll() error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+func (l *LightningDB) cleanupShard(s *lightningShard) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	l.store = make(map[string]item, l.config.InitialCapacity)
-	metricsItemCount.Set(0)
+	now := time.Now()
+	for k, item := range s.store {
+		if !item.expiration.IsZero() && now.After(item.expiration) {
+			l.drop(s, k, item)
+		}
+	}
+}
+
+func (l *LightningDB) FlushAll() error {
+	for _, s := range l.shards {
+		s.mu.Lock()
+		var bytes int64
+		for _, item := range s.store {
+			bytes += item.size
+		}
+		l.items.Add(-int64(len(s.store)))
+		l.bytes.Add(-bytes)
+		fresh := l.newShard()
+		s.store, s.policy = fresh.store, fresh.policy
+		s.mu.Unlock()
+	}
+	l.reportSize()
 	return nil
 }
 
+// makeRoom evicts from s until key fits within max_items and max_bytes, an overwrite only needs
+// room for the size difference. Without an eviction policy it only reports whether the key fits.
+func (l *LightningDB) makeRoom(s *lightningShard, key string, size int64) bool {
+	maxBytes := l.config.MaxBytes
+	if maxBytes > 0 && size > maxBytes {
+		return false
+	}
+	for {
+		count, bytes := l.items.Load(), l.bytes.Load()
+		if old, exists := s.store[key]; exists {
+			count--
+			bytes -= old.size
+		}
+		itemsFull := l.config.MaxItems > 0 && count >= int64(l.config.MaxItems)
+		bytesFull := maxBytes > 0 && bytes+size > maxBytes
+		if !itemsFull && !bytesFull {
+			return true
+		}
+		if s.policy == nil {
+			return false
+		}
+		victim, ok := s.policy.victim()
+		if !ok {
+			return true // the other shards hold the items, one of their next writes evicts
+		}
+		l.drop(s, victim, s.store[victim])
+		metricsEvictionCount.WithLabelValues(l.config.EvictionPolicy).Inc()
+	}
+}
+
+func (l *LightningDB) put(s *lightningShard, key string, it item) {
+	if old, exists := s.store[key]; exists {
+		l.bytes.Add(-old.size)
+	} else {
+		l.items.Add(1)
+	}
+	s.store[key] = it
+	l.bytes.Add(it.size)
+	if s.policy != nil {
+		s.policy.add(key, it.expiration)
+	}
+	l.reportSize()
+}
+
+func (l *LightningDB) drop(s *lightningShard, key string, it item) {
+	delete(s.store, key)
+	l.items.Add(-1)
+	l.bytes.Add(-it.size)
+	if s.policy != nil {
+		s.policy.remove(key)
+	}
+	l.reportSize()
+}
+
+func (l *LightningDB) reportSize() {
+	metricsItemCount.Set(float64(l.items.Load()))
+	metricsBytesCount.Set(float64(l.bytes.Load()))
+}
+
+func (l *LightningDB) capacityError(size int64) error {
+	if l.config.MaxBytes > 0 && size > l.config.MaxBytes {
+		return ErrValueTooLarge
+	}
+	return ErrCapacityReached
+}
+
+// itemSize estimates the memory a key takes, values other than strings and byte slices count as the overhead only
+func itemSize(key string, value interface{}) int64 {
+	size := int64(len(key) + entryOverhead)
+	switch v := value.(type) {
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(len(v))
+	}
+	return size
+}
+
 // Interface compliance check
 var _ InMemoryStore = (*LightningDB)(nil)
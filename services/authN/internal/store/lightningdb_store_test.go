@@ -1,9 +1,13 @@
 package store
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -50,3 +54,158 @@ func (s *LightningDBTestSuite) TestFallback() {
 	_, err = store.Get("key2")
 	assert.NoError(s.T(), err)
 }
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	var m dto.Metric
+	assert.NoError(t, c.Write(&m))
+	return m.GetCounter().GetValue()
+}
+
+func newEvictingDB(policy string, maxItems int) *LightningDB {
+	// one shard, so the victim is chosen among all keys
+	return NewLightningDB(&LightningConfig{Shards: 1, InitialCapacity: maxItems, MaxItems: maxItems, EvictionPolicy: policy})
+}
+
+func TestLightningDBEvictionLRU(t *testing.T) {
+	db := newEvictingDB("lru", 2)
+	before := counterValue(t, metricsEvictionCount.WithLabelValues("lru"))
+
+	assert.NoError(t, db.Set("a", "1"))
+	assert.NoError(t, db.Set("b", "2"))
+	_, _ = db.Get("a") // b is now the least recently used
+	assert.NoError(t, db.Set("c", "3"))
+
+	_, err := db.Get("b")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	for _, key := range []string{"a", "c"} {
+		_, err := db.Get(key)
+		assert.NoError(t, err, key)
+	}
+	assert.Equal(t, before+1, counterValue(t, metricsEvictionCount.WithLabelValues("lru")))
+
+	// overwriting a key must not evict anything
+	assert.NoError(t, db.Set("a", "4"))
+	assert.Equal(t, int64(2), db.items.Load())
+}
+
+func TestLightningDBEvictionLFU(t *testing.T) {
+	db := newEvictingDB("lfu", 3)
+
+	assert.NoError(t, db.Set("a", "1"))
+	assert.NoError(t, db.Set("b", "2"))
+	assert.NoError(t, db.Set("c", "3"))
+	for i := 0; i < 3; i++ {
+		_, _ = db.Get("a")
+	}
+	_, _ = db.Get("c")
+	assert.NoError(t, db.Set("d", "4")) // b has the fewest uses
+
+	_, err := db.Get("b")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.NoError(t, db.Set("e", "5")) // d has one use, fewer than a and c
+	_, err = db.Get("d")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	for _, key := range []string{"a", "c", "e"} {
+		_, err := db.Get(key)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestLightningDBEvictionTTL(t *testing.T) {
+	db := newEvictingDB("ttl", 3)
+
+	assert.NoError(t, db.Set("forever", "1"))
+	assert.NoError(t, db.SetWithTTL("hour", "2", time.Hour))
+	assert.NoError(t, db.SetWithTTL("minute", "3", time.Minute))
+	assert.NoError(t, db.SetWithTTL("day", "4", 24*time.Hour))
+
+	_, err := db.Get("minute")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.NoError(t, db.Set("forever2", "5"))
+	_, err = db.Get("hour")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = db.Get("forever")
+	assert.NoError(t, err)
+}
+
+func TestLightningDBMaxBytes(t *testing.T) {
+	db := NewLightningDB(&LightningConfig{Shards: 1, MaxBytes: 3 * (entryOverhead + 11), EvictionPolicy: "lru"})
+
+	// every item is 1 byte of key, 10 bytes of value and the overhead
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, db.Set(key, "0123456789"))
+	}
+	assert.Equal(t, int64(3), db.items.Load())
+	assert.Equal(t, int64(3*(entryOverhead+11)), db.bytes.Load())
+	_, err := db.Get("a")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.ErrorIs(t, db.Set("big", make([]byte, 4*entryOverhead)), ErrValueTooLarge)
+
+	assert.NoError(t, db.Delete("b"))
+	assert.Equal(t, int64(2*(entryOverhead+11)), db.bytes.Load())
+}
+
+func TestLightningDBNoEvictionPolicy(t *testing.T) {
+	db := newEvictingDB("", 1)
+
+	assert.NoError(t, db.Set("a", "1"))
+	assert.ErrorIs(t, db.Set("b", "2"), ErrCapacityReached)
+	assert.NoError(t, db.Set("a", "3"), "overwriting needs no extra room")
+}
+
+func TestLightningDBShards(t *testing.T) {
+	db := NewLightningDB(&LightningConfig{Shards: 5, MaxItems: 100})
+	assert.Len(t, db.shards, 8, "shard count is rounded up to a power of two")
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, db.Set(fmt.Sprintf("key-%d", i), "v"))
+	}
+	used := 0
+	for _, s := range db.shards {
+		if len(s.store) > 0 {
+			used++
+		}
+	}
+	assert.Greater(t, used, 1, "keys should spread over the shards")
+
+	// the limit holds for the whole store, not per shard
+	assert.ErrorIs(t, db.Set("one-too-many", "v"), ErrCapacityReached)
+
+	assert.NoError(t, db.FlushAll())
+	assert.Equal(t, int64(0), db.items.Load())
+	keys, err := db.Keys("*")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestLightningDBConcurrentAccess(t *testing.T) {
+	db := NewLightningDB(&LightningConfig{MaxItems: 500, EvictionPolicy: "lru"})
+	defer db.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("key-%d-%d", g, i%300)
+				_ = db.SetWithTTL(key, "v", time.Minute)
+				_, _ = db.Get(key)
+				if i%7 == 0 {
+					_ = db.Delete(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	var count int64
+	for _, s := range db.shards {
+		count += int64(len(s.store))
+	}
+	assert.Equal(t, count, db.items.Load())
+	assert.LessOrEqual(t, count, int64(500+len(db.shards)))
+}
@@ -81,19 +81,41 @@ func (m *MemcachedStore) SetWithTTL(key string, value interface{}, ttl time.Dura
 		return fmt.Errorf("%w: unsupported value type", ErrMemcachedOperation)
 	}
 
-	// Memcached expects TTL in seconds (as int32)
-	expiration := int32(ttl.Seconds())
-	if expiration <= 0 {
-		expiration = int32(m.timeout)
+	item := &memcache.Item{
+		Key:        key,
+		Value:      valueBytes,
+		Expiration: memcachedExpiration(ttl, time.Now()),
+	}
+
+	return m.client.Set(item)
+}
+
+// SetIfNotExists uses the add command, which memcached only carries out for an absent key
+func (m *MemcachedStore) SetIfNotExists(key string, value interface{}, ttl time.Duration) error {
+	var valueBytes []byte
+
+	switch v := value.(type) {
+	case []byte:
+		valueBytes = v
+	case string:
+		valueBytes = []byte(v)
+	default:
+		return fmt.Errorf("%w: unsupported value type", ErrMemcachedOperation)
 	}
 
 	item := &memcache.Item{
 		Key:        key,
 		Value:      valueBytes,
-		Expiration: expiration,
+		Expiration: memcachedExpiration(ttl, time.Now()),
 	}
 
-	return m.client.Set(item)
+	if err := m.client.Add(item); err != nil {
+		if err == memcache.ErrNotStored {
+			return ErrKeyAlreadyExists
+		}
+		return fmt.Errorf("%w: %v", ErrMemcachedOperation, err)
+	}
+	return nil
 }
 
 func (m *MemcachedStore) Get(key string) (interface{}, error) {
@@ -107,14 +129,17 @@ func (m *MemcachedStore) Get(key string) (interface{}, error) {
 		}
 		return nil, fmt.Errorf("%w: %v", ErrMemcachedOperation, err)
 	}
-	return item.Value, nil
+	return string(item.Value), nil
 }
 
 func (m *MemcachedStore) Delete(key string) error {
 	_, cancel := context.WithTimeout(context.Background(), m.timeout)
 	defer cancel()
 
-	if err := m.client.Delete(key); err != nil && err != memcache.ErrCacheMiss {
+	if err := m.client.Delete(key); err != nil {
+		if err == memcache.ErrCacheMiss {
+			return ErrKeyNotFound
+		}
 		return fmt.Errorf("%w: %v", ErrMemcachedOperation, err)
 	}
 	return nil
@@ -137,7 +162,7 @@ func (m *MemcachedStore) Exists(key string) (bool, error) {
 func (m *MemcachedStore) Keys(pattern string) ([]string, error) {
 	// Memcached doesn't natively support key pattern matching
 	// This is a limitation compared to Redis
-	return nil, fmt.Errorf("%w: key pattern matching not supported", ErrMemcachedOperation)
+	return nil, ErrKeysNotSupported
 }
 
 func (m *MemcachedStore) Close() error {
@@ -156,5 +181,18 @@ func (m *MemcachedStore) FlushAll() error {
 	return nil
 }
 
+// memcachedExpiration converts ttl to the protocol's expiration: 0 never expires, up to 30 days
+// it is relative seconds, anything longer has to be an absolute unix time
+func memcachedExpiration(ttl time.Duration, now time.Time) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > 30*24*time.Hour {
+		return int32(now.Add(ttl).Unix())
+	}
+	// round up, a ttl under a second must not turn into 0 and never expire
+	return int32((ttl + time.Second - 1) / time.Second)
+}
+
 // Interface compliance check
 var _ InMemoryStore = (*MemcachedStore)(nil)
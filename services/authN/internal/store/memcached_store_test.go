@@ -3,6 +3,7 @@ package store
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -16,9 +17,9 @@ func TestMemcachedStore(t *testing.T) {
 		t.Skip("Set INTEGRATION=true to run Memcached tests")
 	}
 
-	store, err := NewRedisStore(&RedisConfig{
-		Address: "localhost:11211",
-		DB:      1, // Use separate DB for tests
+	store, err := NewMemcachedStore(&MemcachedConfig{
+		Addresses: []string{"localhost:11211"},
+		Timeout:   time.Second,
 	})
 	if err != nil {
 		t.Fatalf("Failed to create memcached store: %v", err)
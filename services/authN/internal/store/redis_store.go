@@ -60,18 +60,39 @@ func (r *RedisStore) Set(key string, value interface{}) error {
 	return nil
 }
 
+// SetWithTTL stores a key-value pair that expires after ttl, a ttl <= 0 never expires
 func (r *RedisStore) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
 	ctx, cancel := context.WithTimeout(r.ctx, r.ttl)
 	defer cancel()
+	if ttl < 0 {
+		ttl = 0 // go-redis treats -1 as KEEPTTL
+	}
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
+// SetIfNotExists stores the key only if it is absent (SET NX), a ttl <= 0 never expires
+func (r *RedisStore) SetIfNotExists(key string, value interface{}, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(r.ctx, r.ttl)
+	defer cancel()
+	if ttl < 0 {
+		ttl = 0
+	}
+	set, err := r.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("redis setnx failed: %w", err)
+	}
+	if !set {
+		return ErrKeyAlreadyExists
+	}
+	return nil
+}
+
 // Get retrieves a value from Redis
 func (r *RedisStore) Get(key string) (interface{}, error) {
 
 	value, err := r.client.Get(r.ctx, key).Result()
 	if err == redis.Nil {
-		return nil, errors.New("key not found")
+		return nil, ErrKeyNotFound
 	}
 	if err != nil {
 		return nil, errors.New("error in redis operation")
@@ -81,9 +102,13 @@ func (r *RedisStore) Get(key string) (interface{}, error) {
 
 // Delete removes a key from Redis
 func (r *RedisStore) Delete(key string) error {
-	if err := r.client.Del(r.ctx, key).Err(); err != nil {
+	deleted, err := r.client.Del(r.ctx, key).Result()
+	if err != nil {
 		return errors.New("error in redis operation")
 	}
+	if deleted == 0 {
+		return ErrKeyNotFound
+	}
 	return nil
 }
 
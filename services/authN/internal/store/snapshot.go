@@ -0,0 +1,290 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/constants"
+	"github.com/ashish19912009/zrms/services/authN/internal/logger"
+)
+
+var (
+	ErrSnapshotCorrupt = errors.New(constants.SnapshotCorrupt)
+	ErrSnapshotVersion = errors.New(constants.SnapshotVersionUnsupported)
+)
+
+// Snapshot file layout, integers are little endian, lengths and expirations varints:
+//
+//	magic "LDBS" | version uint16
+//	records: kind byte | key length | key | value length | value | expiration unix nanos, 0 = none
+//	kind 0 ends the records and is followed by the CRC-32 (IEEE) of everything before it
+//
+// A new version is needed for any change to this layout, older files are then ignored at startup.
+const (
+	snapshotMagic   = "LDBS"
+	snapshotVersion = 1
+
+	snapshotEnd    byte = 0
+	snapshotString byte = 1
+	snapshotBytes  byte = 2
+)
+
+// Snapshot writes every unexpired string and []byte value with its expiration to config.SnapshotPath.
+// The file is written next to the old one and renamed over it, a crash mid-write leaves the previous
+// snapshot in place. Shards are copied one after another, so like Keys it is not a point in time view.
+func (l *LightningDB) Snapshot() error {
+	if l.config.SnapshotPath == "" {
+		return nil
+	}
+	l.snapshotMu.Lock()
+	defer l.snapshotMu.Unlock()
+
+	start := time.Now()
+	written, skipped, err := l.writeSnapshot(l.config.SnapshotPath)
+	if err != nil {
+		logger.Error(constants.SnapshotFailed, err, map[string]interface{}{"path": l.config.SnapshotPath})
+		return err
+	}
+	logger.Info(constants.SnapshotWritten, map[string]interface{}{
+		"path":     l.config.SnapshotPath,
+		"items":    written,
+		"skipped":  skipped,
+		"duration": time.Since(start).String(),
+	})
+	return nil
+}
+
+func (l *LightningDB) writeSnapshot(path string) (written, skipped int, err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, 0, err
+	}
+	// CreateTemp makes the file 0600, sessions and refresh tokens must not be readable by others
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	sum := crc32.NewIEEE()
+	w := bufio.NewWriter(io.MultiWriter(tmp, sum))
+	header := binary.LittleEndian.AppendUint16([]byte(snapshotMagic), snapshotVersion)
+	if _, err = w.Write(header); err != nil {
+		return 0, 0, err
+	}
+
+	var buf []byte
+	for _, s := range l.shards {
+		for key, it := range l.shardItems(s) {
+			kind, value, ok := snapshotValue(it.value)
+			if !ok {
+				skipped++
+				continue
+			}
+			var expiration int64
+			if !it.expiration.IsZero() {
+				expiration = it.expiration.UnixNano()
+			}
+			buf = append(buf[:0], kind)
+			buf = binary.AppendUvarint(buf, uint64(len(key)))
+			buf = append(buf, key...)
+			buf = binary.AppendUvarint(buf, uint64(len(value)))
+			buf = append(buf, value...)
+			buf = binary.AppendVarint(buf, expiration)
+			if _, err = w.Write(buf); err != nil {
+				return 0, 0, err
+			}
+			written++
+		}
+	}
+
+	if err = w.WriteByte(snapshotEnd); err != nil {
+		return 0, 0, err
+	}
+	if err = w.Flush(); err != nil {
+		return 0, 0, err
+	}
+	if _, err = tmp.Write(binary.LittleEndian.AppendUint32(nil, sum.Sum32())); err != nil {
+		return 0, 0, err
+	}
+	if err = tmp.Sync(); err != nil {
+		return 0, 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, 0, err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return 0, 0, err
+	}
+	// the rename only survives a power loss once the directory is synced, not every platform allows it
+	if dir, dirErr := os.Open(filepath.Dir(path)); dirErr == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+	return written, skipped, nil
+}
+
+// shardItems copies the unexpired items of s so the file is written without holding the lock
+func (l *LightningDB) shardItems(s *lightningShard) map[string]item {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	items := make(map[string]item, len(s.store))
+	for k, it := range s.store {
+		if !it.expiration.IsZero() && now.After(it.expiration) {
+			continue
+		}
+		items[k] = it
+	}
+	return items
+}
+
+// snapshotValue returns the encoding of the value types the repositories store, strings and byte slices
+func snapshotValue(value interface{}) (byte, []byte, bool) {
+	switch v := value.(type) {
+	case string:
+		return snapshotString, []byte(v), true
+	case []byte:
+		return snapshotBytes, v, true
+	default:
+		return 0, nil, false
+	}
+}
+
+// loadSnapshot restores config.SnapshotPath into the empty store. A missing file is a first start,
+// a damaged or unknown file is logged and skipped so the service still comes up, just without the data.
+func (l *LightningDB) loadSnapshot() {
+	path := l.config.SnapshotPath
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = l.restoreSnapshot(data)
+	}
+	if err != nil {
+		logger.Error(constants.SnapshotLoadFailed, err, map[string]interface{}{"path": path})
+		return
+	}
+	logger.Info(constants.SnapshotLoaded, map[string]interface{}{"path": path, "items": l.items.Load()})
+}
+
+// restoreSnapshot checks the whole file before storing anything, a damaged snapshot loads nothing.
+// Items that expired while the service was down are dropped, the limits and eviction policy apply as on Set.
+func (l *LightningDB) restoreSnapshot(data []byte) error {
+	header := len(snapshotMagic) + 2
+	if len(data) < header+1+crc32.Size || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return ErrSnapshotCorrupt
+	}
+	if version := binary.LittleEndian.Uint16(data[len(snapshotMagic):header]); version != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	body, checksum := data[:len(data)-crc32.Size], data[len(data)-crc32.Size:]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(checksum) {
+		return ErrSnapshotCorrupt
+	}
+
+	type record struct {
+		key string
+		it  item
+	}
+	var records []record
+	r := bytes.NewReader(body[header:])
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return ErrSnapshotCorrupt
+		}
+		if kind == snapshotEnd {
+			break
+		}
+		key, err := readSnapshotBytes(r)
+		if err != nil {
+			return err
+		}
+		raw, err := readSnapshotBytes(r)
+		if err != nil {
+			return err
+		}
+		expiration, err := binary.ReadVarint(r)
+		if err != nil {
+			return ErrSnapshotCorrupt
+		}
+
+		var value interface{}
+		switch kind {
+		case snapshotString:
+			value = string(raw)
+		case snapshotBytes:
+			value = raw
+		default:
+			return ErrSnapshotCorrupt
+		}
+		it := item{value: value, size: itemSize(string(key), value)}
+		if expiration != 0 {
+			it.expiration = time.Unix(0, expiration)
+		}
+		records = append(records, record{key: string(key), it: it})
+	}
+	if r.Len() != 0 {
+		return ErrSnapshotCorrupt
+	}
+
+	now := time.Now()
+	for _, rec := range records {
+		if !rec.it.expiration.IsZero() && now.After(rec.it.expiration) {
+			continue
+		}
+		l.restore(rec.key, rec.it)
+	}
+	return nil
+}
+
+func (l *LightningDB) restore(key string, it item) {
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l.makeRoom(s, key, it.size) {
+		l.put(s, key, it)
+	}
+}
+
+func readSnapshotBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(r.Len()) {
+		return nil, ErrSnapshotCorrupt
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, ErrSnapshotCorrupt
+	}
+	return b, nil
+}
+
+// startSnapshots writes a snapshot every interval until Close, which writes the last one
+func (l *LightningDB) startSnapshots(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			_ = l.Snapshot() // logged, the next tick tries again
+		}
+	}
+}
@@ -14,13 +14,22 @@ import (
 var (
 	ErrUnsupportedDatabase = errors.New(constants.ErrUnsupportedDatabase)
 	ErrKeyNotFound         = errors.New(constants.ErrKeyNotFound)
+	ErrKeyAlreadyExists    = errors.New(constants.ErrKeyAlreadyExists)
 	ErrInvalidConfig       = errors.New(constants.ErrInvalidConfig)
+	ErrKeysNotSupported    = errors.New(constants.ErrKeysNotSupported)
 )
 
-// InMemoryStore defines the interface for all in-memory databases
+// InMemoryStore defines the interface for all in-memory databases.
+// Every backend has to pass the conformance suite in storetest, in short:
+//   - Get, and Delete of a missing or expired key return ErrKeyNotFound
+//   - Get returns the stored bytes as a string, whether they were set as a string or a []byte
+//   - SetWithTTL with a ttl <= 0 stores the value without expiration
+//   - SetIfNotExists is atomic, of concurrent calls for a key only one succeeds, the others get ErrKeyAlreadyExists
+//   - Keys takes a Redis glob pattern (*, ?, [abc], [a-z], \x), backends that can't list keys return ErrKeysNotSupported
 type InMemoryStore interface {
 	Set(key string, value interface{}) error
 	SetWithTTL(key string, value interface{}, ttl time.Duration) error
+	SetIfNotExists(key string, value interface{}, ttl time.Duration) error
 	Get(key string) (interface{}, error)
 	Delete(key string) error
 	Exists(key string) (bool, error)
@@ -41,9 +50,16 @@ type Config struct {
 
 type LightningConfig struct {
 	InitialCapacity int           `yaml:"initial_capacity"`
-	MaxItems        int           `yaml:"max_items"` // 0 = unlimited
+	Shards          int           `yaml:"shards"`          // rounded up to a power of two, 0 = 32
+	MaxItems        int           `yaml:"max_items"`       // 0 = unlimited
+	MaxBytes        int64         `yaml:"max_bytes"`       // estimated size of keys and values, 0 = unlimited
+	EvictionPolicy  string        `yaml:"eviction_policy"` // none (default), lru, lfu or ttl
 	CleanupInterval time.Duration `yaml:"cleanup_interval"`
-	FallbackStore   InMemoryStore `yaml:"-"` // For runtime fallback
+	// SnapshotPath is loaded at startup and written on Close, empty disables snapshots.
+	// SnapshotInterval adds periodic snapshots so a crash loses at most one interval, 0 = only on Close.
+	SnapshotPath     string        `yaml:"snapshot_path"`
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"`
+	FallbackStore    InMemoryStore `yaml:"-"` // For runtime fallback, only used without an eviction policy
 }
 
 type RedisConfig struct {
@@ -76,6 +92,7 @@ type BadgerConfig struct {
 	Dir        string `yaml:"dir"`
 	SyncWrites bool   `yaml:"sync_writes"`
 	Logger     bool   `yaml:"logger"`
+	InMemory   bool   `yaml:"in_memory"` // nothing is written to Dir, mostly for tests
 }
 
 // LoadConfig reads the YAML file and returns the config
@@ -122,6 +139,10 @@ func (c *Config) Validate() error {
 		if c.Lightning == nil {
 			c.Lightning = &LightningConfig{InitialCapacity: 1000, MaxItems: 0}
 		}
+		if _, ok := newEvictionPolicy(c.Lightning.EvictionPolicy); !ok {
+			logger.Error(constants.UnsupportedEvictionPolicy, nil, map[string]interface{}{"eviction_policy": c.Lightning.EvictionPolicy})
+			return ErrInvalidConfig
+		}
 	case constants.RedisType:
 		if c.Redis == nil {
 			logger.Error(constants.InvalidRedisConfig, nil, map[string]interface{}{constants.TypeKey: c.Type})
@@ -0,0 +1,184 @@
+package storetest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// MemcachedServer speaks the part of the memcached text protocol gomemcache uses
+// (get, gets, set, add, delete, flush_all, version) so MemcachedStore can be tested offline
+type MemcachedServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	items    map[string]memcachedItem
+	offset   time.Duration
+	cas      uint64
+}
+
+type memcachedItem struct {
+	value   []byte
+	flags   uint32
+	expires time.Time
+	cas     uint64
+}
+
+// NewMemcachedServer listens on a random local port and stops when the test ends
+func NewMemcachedServer(t *testing.T) *MemcachedServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("memcached stub: %v", err)
+	}
+	m := &MemcachedServer{listener: l, items: make(map[string]memcachedItem)}
+	go m.serve()
+	t.Cleanup(func() { _ = l.Close() })
+	return m
+}
+
+func (m *MemcachedServer) Addr() string {
+	return m.listener.Addr().String()
+}
+
+// FastForward moves the server clock, items expire as if d had passed
+func (m *MemcachedServer) FastForward(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offset += d
+}
+
+func (m *MemcachedServer) now() time.Time {
+	return time.Now().Add(m.offset)
+}
+
+func (m *MemcachedServer) serve() {
+	for {
+		conn, err := m.listener.Accept()
+		if err != nil {
+			return
+		}
+		go m.handle(conn)
+	}
+}
+
+func (m *MemcachedServer) handle(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := m.command(rw, fields); err != nil {
+			return
+		}
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (m *MemcachedServer) command(rw *bufio.ReadWriter, fields []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch fields[0] {
+	case "version":
+		_, err := rw.WriteString("VERSION 1.6.0-stub\r\n")
+		return err
+	case "get", "gets":
+		for _, key := range fields[1:] {
+			it, ok := m.lookup(key)
+			if !ok {
+				continue
+			}
+			if fields[0] == "gets" {
+				fmt.Fprintf(rw, "VALUE %s %d %d %d\r\n", key, it.flags, len(it.value), it.cas)
+			} else {
+				fmt.Fprintf(rw, "VALUE %s %d %d\r\n", key, it.flags, len(it.value))
+			}
+			rw.Write(it.value)
+			rw.WriteString("\r\n")
+		}
+		_, err := rw.WriteString("END\r\n")
+		return err
+	case "set", "add":
+		// set|add <key> <flags> <exptime> <bytes> [noreply]
+		if len(fields) < 5 {
+			_, err := rw.WriteString("ERROR\r\n")
+			return err
+		}
+		flags, _ := strconv.ParseUint(fields[2], 10, 32)
+		exptime, _ := strconv.ParseInt(fields[3], 10, 64)
+		size, err := strconv.Atoi(fields[4])
+		if err != nil {
+			_, err = rw.WriteString("CLIENT_ERROR bad data chunk\r\n")
+			return err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(rw, data); err != nil {
+			return err
+		}
+		if _, ok := m.lookup(fields[1]); ok && fields[0] == "add" {
+			_, err = rw.WriteString("NOT_STORED\r\n")
+			return err
+		}
+		m.cas++
+		m.items[fields[1]] = memcachedItem{
+			value:   data[:size],
+			flags:   uint32(flags),
+			expires: m.expiry(exptime),
+			cas:     m.cas,
+		}
+		_, err = rw.WriteString("STORED\r\n")
+		return err
+	case "delete":
+		if _, ok := m.lookup(fields[1]); !ok {
+			_, err := rw.WriteString("NOT_FOUND\r\n")
+			return err
+		}
+		delete(m.items, fields[1])
+		_, err := rw.WriteString("DELETED\r\n")
+		return err
+	case "flush_all":
+		m.items = make(map[string]memcachedItem)
+		_, err := rw.WriteString("OK\r\n")
+		return err
+	default:
+		_, err := rw.WriteString("ERROR\r\n")
+		return err
+	}
+}
+
+func (m *MemcachedServer) lookup(key string) (memcachedItem, bool) {
+	it, ok := m.items[key]
+	if ok && !it.expires.IsZero() && !m.now().Before(it.expires) {
+		delete(m.items, key)
+		return memcachedItem{}, false
+	}
+	return it, ok
+}
+
+// expiry follows memcached: 0 never expires, up to 30 days is relative, above that a unix time
+func (m *MemcachedServer) expiry(exptime int64) time.Time {
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return m.now()
+	case exptime <= 30*24*60*60:
+		return m.now().Add(time.Duration(exptime) * time.Second)
+	default:
+		return time.Unix(exptime, 0)
+	}
+}
@@ -0,0 +1,200 @@
+// Package storetest is the conformance suite every store.InMemoryStore backend has to pass.
+// Switching IN_MEMORY_STORE_TYPE must not change what the repositories see, so the suite pins
+// down missing keys, value types, TTLs, SetIfNotExists and the Keys pattern syntax.
+package storetest
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Backend describes the store under test
+type Backend struct {
+	// New returns an empty store, the suite closes it when the subtest ends
+	New func(t *testing.T) store.InMemoryStore
+	// Advance moves the backend clock forward, fakes with their own clock skip the wait. Defaults to time.Sleep.
+	Advance func(d time.Duration)
+	// NoKeys is set for backends that can't list keys, Keys must then return store.ErrKeysNotSupported
+	NoKeys bool
+}
+
+// ttl is whole seconds, Redis, Memcached and Badger all round expirations to seconds
+const ttl = 2 * time.Second
+
+// Run runs the suite, every subtest gets a fresh store
+func Run(t *testing.T, b Backend) {
+	if b.Advance == nil {
+		b.Advance = time.Sleep
+	}
+	run := func(name string, fn func(t *testing.T, s store.InMemoryStore)) {
+		t.Run(name, func(t *testing.T) {
+			s := b.New(t)
+			t.Cleanup(func() { _ = s.Close() })
+			fn(t, s)
+		})
+	}
+
+	run("GetMissing", func(t *testing.T, s store.InMemoryStore) {
+		_, err := s.Get("missing")
+		assert.ErrorIs(t, err, store.ErrKeyNotFound)
+
+		exists, err := s.Exists("missing")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	run("SetGet", func(t *testing.T, s store.InMemoryStore) {
+		require.NoError(t, s.Set("string", "value"))
+		require.NoError(t, s.Set("bytes", []byte{'v', 0, 0xff}))
+
+		assertValue(t, s, "string", "value")
+		assertValue(t, s, "bytes", string([]byte{'v', 0, 0xff}))
+
+		exists, err := s.Exists("string")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	run("Overwrite", func(t *testing.T, s store.InMemoryStore) {
+		require.NoError(t, s.Set("key", "first"))
+		require.NoError(t, s.SetWithTTL("key", "second", time.Minute))
+		assertValue(t, s, "key", "second")
+	})
+
+	run("Delete", func(t *testing.T, s store.InMemoryStore) {
+		require.NoError(t, s.Set("key", "value"))
+		require.NoError(t, s.Delete("key"))
+
+		_, err := s.Get("key")
+		assert.ErrorIs(t, err, store.ErrKeyNotFound)
+		exists, err := s.Exists("key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+
+		// repositories rely on this to tell "already gone" from a failure
+		assert.ErrorIs(t, s.Delete("key"), store.ErrKeyNotFound)
+	})
+
+	run("TTL", func(t *testing.T, s store.InMemoryStore) {
+		require.NoError(t, s.SetWithTTL("temp", "data", ttl))
+		assertValue(t, s, "temp", "data")
+
+		b.Advance(ttl + time.Second)
+
+		_, err := s.Get("temp")
+		assert.ErrorIs(t, err, store.ErrKeyNotFound)
+		exists, err := s.Exists("temp")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		assert.ErrorIs(t, s.Delete("temp"), store.ErrKeyNotFound)
+		if !b.NoKeys {
+			keys, err := s.Keys("*")
+			assert.NoError(t, err)
+			assert.Empty(t, keys)
+		}
+	})
+
+	run("NoExpiration", func(t *testing.T, s store.InMemoryStore) {
+		require.NoError(t, s.SetWithTTL("zero", "data", 0))
+		require.NoError(t, s.SetWithTTL("negative", "data", -time.Second))
+
+		b.Advance(ttl + time.Second)
+
+		assertValue(t, s, "zero", "data")
+		assertValue(t, s, "negative", "data")
+	})
+
+	run("SetIfNotExists", func(t *testing.T, s store.InMemoryStore) {
+		require.NoError(t, s.SetIfNotExists("key", "first", 0))
+		assert.ErrorIs(t, s.SetIfNotExists("key", "second", 0), store.ErrKeyAlreadyExists)
+		assertValue(t, s, "key", "first")
+
+		// an expired key is absent
+		require.NoError(t, s.SetIfNotExists("temp", "first", ttl))
+		b.Advance(ttl + time.Second)
+		require.NoError(t, s.SetIfNotExists("temp", "second", 0))
+		assertValue(t, s, "temp", "second")
+
+		// repositories claim keys with it, racing callers must not both win
+		const callers = 8
+		var wins atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := s.SetIfNotExists("claim", "v", time.Minute)
+				if err == nil {
+					wins.Add(1)
+					return
+				}
+				assert.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), wins.Load())
+	})
+
+	run("Keys", func(t *testing.T, s store.InMemoryStore) {
+		for _, key := range []string{"session:a:1", "session:a:2", "session:b:1", "token:x"} {
+			require.NoError(t, s.Set(key, "v"))
+		}
+		if b.NoKeys {
+			_, err := s.Keys("*")
+			assert.ErrorIs(t, err, store.ErrKeysNotSupported)
+			return
+		}
+
+		cases := map[string][]string{
+			"*":              {"session:a:1", "session:a:2", "session:b:1", "token:x"},
+			"session:a:*":    {"session:a:1", "session:a:2"},
+			"session:?:1":    {"session:a:1", "session:b:1"},
+			"session:[b-c]*": {"session:b:1"},
+			"session:[^a]:*": {"session:b:1"},
+			"*:x":            {"token:x"},
+			"token:x":        {"token:x"},
+			"session:*:3":    nil,
+			"nomatch*":       nil,
+		}
+		for pattern, want := range cases {
+			keys, err := s.Keys(pattern)
+			assert.NoError(t, err, pattern)
+			assert.ElementsMatch(t, want, keys, pattern)
+		}
+	})
+
+	run("FlushAll", func(t *testing.T, s store.InMemoryStore) {
+		require.NoError(t, s.Set("a", "1"))
+		require.NoError(t, s.SetWithTTL("b", "2", time.Minute))
+		require.NoError(t, s.FlushAll())
+
+		for _, key := range []string{"a", "b"} {
+			_, err := s.Get(key)
+			assert.ErrorIs(t, err, store.ErrKeyNotFound)
+		}
+		require.NoError(t, s.Set("a", "3"))
+		assertValue(t, s, "a", "3")
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		assert.NoError(t, b.New(t).Close())
+	})
+}
+
+// assertValue checks Get returns want as a string, the type every backend hands back
+func assertValue(t *testing.T, s store.InMemoryStore, key, want string) {
+	t.Helper()
+	val, err := s.Get(key)
+	if !assert.NoError(t, err, key) {
+		return
+	}
+	if assert.IsType(t, "", val, key) {
+		assert.Equal(t, want, val, key)
+	}
+}
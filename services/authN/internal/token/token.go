@@ -1,10 +1,10 @@
 package token
 
 import (
-	"crypto/rsa"
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/ashish19912009/zrms/services/authN/internal/config"
@@ -17,45 +17,119 @@ import (
 )
 
 type TokenManager interface {
-	GenerateAccessToken(accountID, FranchiseID, employeeID, mobileNo, accountType, name string, duration time.Duration) (string, error)
-	GenerateRefreshToken(accountID, accountType string, duration time.Duration) (string, error)
+	GenerateAccessToken(employeeID, FranchiseID, accountID, mobileNo, accountType, name, sessionID string, perms *model.TokenPermissions, duration time.Duration) (string, error)
+	GenerateRefreshToken(accountID, accountType, sessionID string, duration time.Duration) (string, string, error)
+	GenerateServiceToken(clientID string, scopes []string, duration time.Duration) (string, *model.AuthClaims, error)
+	GenerateImpersonationToken(user *model.User, actor *model.Actor, duration time.Duration) (string, *model.AuthClaims, error)
 	VerifyAccessToken(tokenString string) (*model.AuthClaims, error)
 	VerifyRefreshToken(tokenString string) (*model.AuthClaims, error)
 }
 
 type jwtManager struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
-	issuer     string
-	audience   string
-	alg        string
-	typ        string
-	kid        string
+	mu             sync.RWMutex
+	keyring        *jwtkeys.Keyring
+	privateKeyPath string
+	publicKeyPath  string
+	keyringDir     string
+	issuer         string
+	audience       string
+	alg            string
+	typ            string
+	kid            string
 }
 
-func NewjwtManager(privateKeyPath, publicKeyPath string, jwtHeader config.JWTHeaderConfig) (*jwtManager, error) {
-	keys, err := jwtkeys.LoadKeys(privateKeyPath, publicKeyPath)
-	if err != nil {
+// NewjwtManager loads the keyring from keyringDir, or the single key pair from
+// privateKeyPath/publicKeyPath under the configured kid when no keyring is set up
+func NewjwtManager(privateKeyPath, publicKeyPath, keyringDir string, jwtHeader config.JWTHeaderConfig) (*jwtManager, error) {
+	jm := &jwtManager{
+		privateKeyPath: privateKeyPath,
+		publicKeyPath:  publicKeyPath,
+		keyringDir:     keyringDir,
+		issuer:         os.Getenv(constants.EnvVariable.JWT_ISSUER),
+		audience:       os.Getenv(constants.EnvVariable.JWT_AUDIENCE),
+		alg:            jwtHeader.Alg,
+		typ:            jwtHeader.Typ,
+		kid:            jwtHeader.Kid,
+	}
+	if err := jm.Reload(); err != nil {
 		return nil, err
 	}
-	_ = keys.PublicKey
-	return &jwtManager{
-		issuer:     os.Getenv("JWT_ISSUER"),
-		audience:   os.Getenv("JWT_AUDIENCE"),
-		privateKey: keys.PrivateKey,
-		publicKey:  keys.PublicKey,
-		alg:        jwtHeader.Alg,
-		typ:        jwtHeader.Typ,
-		kid:        jwtHeader.Kid,
-	}, nil
+	return jm, nil
+}
+
+// Reload reads the keys from disk again, used after a new key has been promoted
+func (jm *jwtManager) Reload() error {
+	var ring *jwtkeys.Keyring
+	if jm.keyringDir != "" {
+		var err error
+		ring, err = jwtkeys.LoadKeyring(jm.keyringDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		keys, err := jwtkeys.LoadKeys(jm.privateKeyPath, jm.publicKeyPath)
+		if err != nil {
+			return err
+		}
+		ring = jwtkeys.NewSingleKeyring(jm.kid, keys)
+	}
+
+	// the configured algorithm decides which kind of key may sign, a mismatch is a deployment mistake
+	if active := ring.ActiveKey(); jm.alg != "" && active.Alg != jm.alg {
+		return fmt.Errorf(constants.ErrSigningAlgMismatch, active.Kid, active.Alg, jm.alg)
+	}
+
+	jm.mu.Lock()
+	jm.keyring = ring
+	jm.mu.Unlock()
+
+	logger.Info(constants.KeyringLoaded, map[string]interface{}{
+		"active_kid": ring.Active,
+		"alg":        ring.ActiveKey().Alg,
+		"keys":       len(ring.Keys),
+	})
+	return nil
+}
+
+// Keyring returns the keys currently in use, the JWKS endpoint publishes all of them
+func (jm *jwtManager) Keyring() *jwtkeys.Keyring {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+	return jm.keyring
+}
+
+// keyFunc picks the verification key by the kid in the token header,
+// tokens without a kid were issued before rotation and use the active key.
+// The token must be signed with the algorithm of that key, never the one it claims.
+func (jm *jwtManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	ring := jm.Keyring()
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = ring.Active
+	}
+	key, ok := ring.VerificationKey(kid)
+	if !ok {
+		return nil, fmt.Errorf(constants.ErrUnknownKeyID, kid)
+	}
+	if token.Method.Alg() != key.Alg {
+		return nil, errors.New(constants.ErrUnexpectedSigningMethod)
+	}
+	return key.PublicKey, nil
 }
 
-func (jm *jwtManager) PublicKey() *rsa.PublicKey {
-	return jm.publicKey
+// sign signs the claims with the active key, using the signing method of its algorithm
+func (jm *jwtManager) sign(claims model.AuthClaims, header map[string]interface{}) (string, error) {
+	signingKey := jm.Keyring().ActiveKey()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(signingKey.Alg), claims)
+	for k, v := range header {
+		token.Header[k] = v
+	}
+	token.Header["kid"] = signingKey.Kid
+	return token.SignedString(signingKey.PrivateKey)
 }
 
-// GenerateToken creates a new access token
-func (j *jwtManager) GenerateAccessToken(employeeID, FranchiseID, accountID, mobileNo, accountType, name string, duration time.Duration) (string, error) {
+// GenerateToken creates a new access token, perms is nil when permissions aren't embedded
+func (j *jwtManager) GenerateAccessToken(employeeID, FranchiseID, accountID, mobileNo, accountType, name, sessionID string, perms *model.TokenPermissions, duration time.Duration) (string, error) {
 	if employeeID == "" || accountID == "" || mobileNo == "" || accountType == "" || name == "" {
 		logger.Error(constants.TokenParamMissing, nil, map[string]interface{}{
 			"method": constants.Methods.GenerateAccToken,
@@ -68,7 +142,8 @@ func (j *jwtManager) GenerateAccessToken(employeeID, FranchiseID, accountID, mob
 		AccountType: accountType,
 		Name:        name,
 		MobileNo:    mobileNo,
-		TokenType:   "access",
+		TokenType:   constants.TokenTypeAccess,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.New().String(),
 			Subject:   accountID,
@@ -78,16 +153,17 @@ func (j *jwtManager) GenerateAccessToken(employeeID, FranchiseID, accountID, mob
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 		},
 	}
+	if perms != nil {
+		claims.Permissions = perms.Permissions
+		claims.PermissionsHash = perms.Hash
+		claims.PermissionsVersion = perms.Version
+	}
 
 	tokenChan := make(chan string)
 	errChan := make(chan error)
 
 	go func() {
-		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-		token.Header["alg"] = j.alg
-		token.Header["typ"] = j.typ
-		token.Header["kid"] = j.kid
-		signedToken, err := token.SignedString(j.privateKey)
+		signedToken, err := j.sign(claims, map[string]interface{}{"typ": j.typ})
 		if err != nil {
 			errChan <- err
 			return
@@ -103,18 +179,19 @@ func (j *jwtManager) GenerateAccessToken(employeeID, FranchiseID, accountID, mob
 	}
 }
 
-// GenerateRefreshToken creates a new refresh token
-func (j *jwtManager) GenerateRefreshToken(accountID, accountType string, duration time.Duration) (string, error) {
+// GenerateRefreshToken creates a new refresh token and returns it together with its jti
+func (j *jwtManager) GenerateRefreshToken(accountID, accountType, sessionID string, duration time.Duration) (string, string, error) {
 	if accountID == "" {
 		logger.Error(constants.TokenParamMissing, nil, map[string]interface{}{
 			"method": constants.Methods.GenerateRefreshToken,
 		})
-		return "", fmt.Errorf(constants.TokenParamMissing)
+		return "", "", fmt.Errorf(constants.TokenParamMissing)
 	}
 
 	claims := model.AuthClaims{
 		AccountType: accountType,
-		TokenType:   "refresh",
+		TokenType:   constants.TokenTypeRefresh,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.New().String(),
 			Subject:   accountID,
@@ -128,8 +205,7 @@ func (j *jwtManager) GenerateRefreshToken(accountID, accountType string, duratio
 	errChan := make(chan error)
 
 	go func() {
-		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-		signedToken, err := token.SignedString(j.privateKey)
+		signedToken, err := j.sign(claims, nil)
 		if err != nil {
 			errChan <- err
 			return
@@ -139,21 +215,80 @@ func (j *jwtManager) GenerateRefreshToken(accountID, accountType string, duratio
 
 	select {
 	case signedToken := <-tokenChan:
-		return signedToken, nil
+		return signedToken, claims.RegisteredClaims.ID, nil
 	case err := <-errChan:
-		return "", err
+		return "", "", err
+	}
+}
+
+// GenerateServiceToken issues a token for a registered service client, it carries no account or session
+func (j *jwtManager) GenerateServiceToken(clientID string, scopes []string, duration time.Duration) (string, *model.AuthClaims, error) {
+	if clientID == "" {
+		logger.Error(constants.TokenParamMissing, nil, map[string]interface{}{
+			"method": constants.Methods.GenerateServiceToken,
+		})
+		return "", nil, fmt.Errorf(constants.TokenParamMissing)
+	}
+
+	now := time.Now()
+	claims := model.AuthClaims{
+		TokenType: constants.TokenTypeService,
+		Scopes:    scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   clientID,
+			Issuer:    j.issuer,
+			Audience:  jwt.ClaimStrings{j.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
+	}
+	signedToken, err := j.sign(claims, map[string]interface{}{"typ": j.typ})
+	if err != nil {
+		return "", nil, err
+	}
+	return signedToken, &claims, nil
+}
+
+// GenerateImpersonationToken issues an access token for user carrying the act claim of the admin.
+// It has no session of its own, authentication follows the admin's session instead.
+func (j *jwtManager) GenerateImpersonationToken(user *model.User, actor *model.Actor, duration time.Duration) (string, *model.AuthClaims, error) {
+	if user == nil || actor == nil || user.AccountID == "" || actor.Subject == "" {
+		logger.Error(constants.TokenParamMissing, nil, map[string]interface{}{
+			"method": constants.Methods.GenerateImpersonationToken,
+		})
+		return "", nil, fmt.Errorf(constants.TokenParamMissing)
 	}
+
+	now := time.Now()
+	claims := model.AuthClaims{
+		EmployeeID:  user.EmployeeID,
+		FranchiseID: user.FranchiseID,
+		AccountType: user.AccountType,
+		Name:        user.Name,
+		MobileNo:    user.MobileNo,
+		TokenType:   constants.TokenTypeAccess,
+		Act:         actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   user.AccountID,
+			Issuer:    j.issuer,
+			Audience:  jwt.ClaimStrings{j.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
+	}
+	signedToken, err := j.sign(claims, map[string]interface{}{"typ": j.typ})
+	if err != nil {
+		return "", nil, err
+	}
+	return signedToken, &claims, nil
 }
 
 // ValidateToken verifies the signature and expiration of an access token
 
 func (j *jwtManager) VerifyAccessToken(tokenString string) (*model.AuthClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &model.AuthClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, errors.New(constants.ErrUnexpectedSigningMethod)
-		}
-		return j.publicKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &model.AuthClaims{}, j.keyFunc)
 
 	if err != nil {
 		return nil, err
@@ -169,14 +304,8 @@ func (j *jwtManager) VerifyAccessToken(tokenString string) (*model.AuthClaims, e
 
 // VerifyRefreshToken verifies the signature and expiration of a refresh token.
 func (j *jwtManager) VerifyRefreshToken(tokenString string) (*model.AuthClaims, error) {
-	// Parse the refresh token with the expected signing method
-	token, err := jwt.ParseWithClaims(tokenString, &model.AuthClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Check if the token's signing method is ECDSA (for ES256)
-		if token.Method != jwt.SigningMethodRS256 {
-			return nil, errors.New(constants.ErrUnexpectedSigningMethod)
-		}
-		return j.publicKey, nil
-	})
+	// Parse the refresh token, keyFunc enforces the signing method of the key it was signed with
+	token, err := jwt.ParseWithClaims(tokenString, &model.AuthClaims{}, j.keyFunc)
 
 	if err != nil {
 		return nil, err
@@ -1,16 +1,39 @@
 package main
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authN/internal/jwtkeys"
 )
 
 func main() {
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	alg := flag.String("alg", jwtkeys.AlgRS256, "signing algorithm of the generated key: RS256, ES256 or EdDSA, must match jwtHeader.alg")
+	rotate := flag.Bool("rotate", false, "generate a new signing key in the keyring directory and make it the active one")
+	dir := flag.String("dir", "../certs/keyring", "keyring directory used with -rotate")
+	maxTokenTTL := flag.Duration("max-token-ttl", 7*24*time.Hour, "longest lifetime of a token signed by the keyring (REFRESH_TOKEN_TTL), retired keys are kept this long")
+	legacyKid := flag.String("legacy-kid", "zrms-authN-2025-key-tHe-GOAT-gO@L", "jwtHeader.keyID the legacy key pair signed with, used on the first -rotate")
+	legacyPublic := flag.String("legacy-public", "../certs/public_key.pem", "legacy public key imported into the keyring on the first -rotate")
+	flag.Parse()
+
+	if *rotate {
+		legacy := legacyKey{kid: *legacyKid, publicPath: *legacyPublic}
+		if err := rotateKeyring(*dir, *alg, *maxTokenTTL, legacy); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	privateKey, err := generateKey(*alg)
 	if err != nil {
 		panic(err)
 	}
@@ -20,40 +43,129 @@ func main() {
 		panic(err)
 	}
 
-	// Save private key
-	privateFile, err := os.Create("../certs/private_key.pem")
-	if err != nil {
+	if err := writeKeyPair(privateKey, "../certs/private_key.pem", "../certs/public_key.pem"); err != nil {
 		panic(err)
 	}
-	defer privateFile.Close()
 
-	privatePEM := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PRIVATE KEY",
-			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-		},
-	)
-	privateFile.Write(privatePEM)
+	fmt.Printf("%s key pair generated successfully in ./certs/\n", *alg)
+}
 
-	// Save public key
-	publicFile, err := os.Create("../certs/public_key.pem")
-	if err != nil {
-		panic(err)
+// legacyKey is the single key pair used before the keyring, see jwtPrivateKeyPath and jwtHeader.keyID
+type legacyKey struct {
+	kid        string
+	publicPath string
+}
+
+// rotateKeyring adds a new key to dir, promotes it to active and retires the previous one.
+// Retired keys stay published until they have been retired for longer than maxTokenTTL,
+// so tokens signed before the rotation keep verifying until they expire.
+func rotateKeyring(dir, alg string, maxTokenTTL time.Duration, legacy legacyKey) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
 	}
-	defer publicFile.Close()
 
-	pubASN1, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	manifest, err := jwtkeys.ReadManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		manifest, err = seedManifest(dir, legacy)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	kid := fmt.Sprintf("authN-%s", now.Format("20060102150405"))
+
+	privateKey, err := generateKey(alg)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if err := writeKeyPair(privateKey, jwtkeys.PrivateKeyFile(dir, kid), jwtkeys.PublicKeyFile(dir, kid)); err != nil {
+		return err
+	}
+
+	entries := make([]jwtkeys.ManifestEntry, 0, len(manifest.Keys)+1)
+	for _, entry := range manifest.Keys {
+		if entry.RetiredAt == nil {
+			entry.RetiredAt = &now
+		}
+		// only the active key signs, retired keys just verify
+		os.Remove(jwtkeys.PrivateKeyFile(dir, entry.Kid))
+		if now.Sub(*entry.RetiredAt) > maxTokenTTL {
+			// every token this key signed has expired
+			os.Remove(jwtkeys.PublicKeyFile(dir, entry.Kid))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	// keep the manifest oldest first, the way it grows
+	entries = append(entries, jwtkeys.ManifestEntry{Kid: kid, CreatedAt: now})
+
+	if err := jwtkeys.WriteManifest(dir, &jwtkeys.Manifest{Active: kid, Keys: entries}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Signing key %s generated and promoted in %s, send SIGHUP to the authN server to pick it up\n", kid, dir)
+	return nil
+}
+
+// seedManifest starts the keyring of dir with the legacy public key under its configured kid,
+// so tokens signed before the first rotation keep verifying after it
+func seedManifest(dir string, legacy legacyKey) (*jwtkeys.Manifest, error) {
+	publicPEM, err := os.ReadFile(legacy.publicPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &jwtkeys.Manifest{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if legacy.kid == "" {
+		return nil, errors.New("legacy key found but -legacy-kid is empty, set it to jwtHeader.keyID")
+	}
+	if err := os.WriteFile(jwtkeys.PublicKeyFile(dir, legacy.kid), publicPEM, 0o644); err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now().UTC()
+	if info, err := os.Stat(legacy.publicPath); err == nil {
+		createdAt = info.ModTime().UTC()
+	}
+	fmt.Printf("Legacy key %s imported into %s\n", legacy.kid, dir)
+	return &jwtkeys.Manifest{
+		Active: legacy.kid,
+		Keys:   []jwtkeys.ManifestEntry{{Kid: legacy.kid, CreatedAt: createdAt}},
+	}, nil
+}
+
+// generateKey creates a private key for one of the supported JWT algorithms
+func generateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case jwtkeys.AlgRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case jwtkeys.AlgES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwtkeys.AlgEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, use RS256, ES256 or EdDSA", alg)
 	}
+}
 
-	publicPEM := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: pubASN1,
-		},
-	)
-	publicFile.Write(publicPEM)
+func writeKeyPair(privateKey crypto.Signer, privatePath, publicPath string) error {
+	// Save private key
+	privatePEM, err := jwtkeys.MarshalPrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(privatePath, privatePEM, 0o600); err != nil {
+		return err
+	}
 
-	fmt.Println("RSA key pair generated successfully in ./certs/")
+	// Save public key
+	publicPEM, err := jwtkeys.MarshalPublicKey(privateKey.Public())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(publicPath, publicPEM, 0o644)
 }
@@ -0,0 +1,183 @@
+// Package passwordhash hashes passwords for the authN and account services.
+// Every hash carries its algorithm and parameters, so hashes made with older
+// settings keep verifying and can be upgraded the next time the password is known.
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported password hash algorithm")
+	ErrInvalidHash          = errors.New("invalid password hash")
+)
+
+// Argon2Params are the argon2id cost settings, Memory is in KiB
+type Argon2Params struct {
+	Memory      uint32 `yaml:"memory"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+	SaltLength  uint32 `yaml:"saltLength"`
+	KeyLength   uint32 `yaml:"keyLength"`
+}
+
+// Config selects the algorithm new hashes are made with
+type Config struct {
+	Algorithm  string       `yaml:"algorithm"`  // "argon2id" (default) or "bcrypt"
+	BcryptCost int          `yaml:"bcryptCost"` // used when Algorithm is bcrypt
+	Argon2     Argon2Params `yaml:"argon2"`
+}
+
+// Default is argon2id with the second recommended setting of RFC 9106
+func Default() Config {
+	return Config{
+		Algorithm:  AlgArgon2id,
+		BcryptCost: 12,
+		Argon2: Argon2Params{
+			Memory:      64 * 1024,
+			Iterations:  3,
+			Parallelism: 2,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}
+}
+
+// Hasher makes hashes with the configured algorithm and verifies hashes of every supported one
+type Hasher struct {
+	cfg Config
+}
+
+// New fills unset parameters from Default and rejects unknown algorithms
+func New(cfg Config) (*Hasher, error) {
+	def := Default()
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = def.Algorithm
+	}
+	if cfg.Algorithm != AlgArgon2id && cfg.Algorithm != AlgBcrypt {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, cfg.Algorithm)
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = def.BcryptCost
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if cfg.Argon2.Memory == 0 {
+		cfg.Argon2.Memory = def.Argon2.Memory
+	}
+	if cfg.Argon2.Iterations == 0 {
+		cfg.Argon2.Iterations = def.Argon2.Iterations
+	}
+	if cfg.Argon2.Parallelism == 0 {
+		cfg.Argon2.Parallelism = def.Argon2.Parallelism
+	}
+	if cfg.Argon2.SaltLength == 0 {
+		cfg.Argon2.SaltLength = def.Argon2.SaltLength
+	}
+	if cfg.Argon2.KeyLength == 0 {
+		cfg.Argon2.KeyLength = def.Argon2.KeyLength
+	}
+	return &Hasher{cfg: cfg}, nil
+}
+
+// Hash returns the encoded hash of password, argon2id hashes use the PHC string format
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == AlgBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		return string(hash), err
+	}
+
+	p := h.cfg.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", AlgArgon2id, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches the encoded hash, whatever algorithm made it.
+// The error is only set when the hash itself can't be read.
+func (h *Hasher) Verify(encoded, password string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether encoded was made with another algorithm or weaker parameters
+// than the configured ones. Hashes that can't be read always need a rehash.
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if isBcrypt(encoded) {
+		if h.cfg.Algorithm != AlgBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost < h.cfg.BcryptCost
+	}
+
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil || h.cfg.Algorithm != AlgArgon2id {
+		return true
+	}
+	want := h.cfg.Argon2
+	return p.Memory < want.Memory || p.Iterations < want.Iterations || p.Parallelism < want.Parallelism ||
+		uint32(len(salt)) < want.SaltLength || uint32(len(key)) < want.KeyLength
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgArgon2id {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
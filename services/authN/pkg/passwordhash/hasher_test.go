@@ -0,0 +1,81 @@
+package passwordhash_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordhash"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// small parameters keep the tests fast
+var testArgon2 = passwordhash.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+	h, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.AlgArgon2id, Argon2: testArgon2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	hash, err := h.Hash("Valid1@pass")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected encoding: %s", hash)
+	}
+	if ok, err := h.Verify(hash, "Valid1@pass"); err != nil || !ok {
+		t.Fatalf("expected match, got %v %v", ok, err)
+	}
+	if ok, _ := h.Verify(hash, "Wrong1@pass"); ok {
+		t.Fatal("expected mismatch")
+	}
+	if h.NeedsRehash(hash) {
+		t.Fatal("hash made with the current parameters must not need a rehash")
+	}
+}
+
+func TestBcryptHashNeedsRehash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("Valid1@pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+
+	h, _ := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.AlgArgon2id, Argon2: testArgon2})
+	if ok, err := h.Verify(string(legacy), "Valid1@pass"); err != nil || !ok {
+		t.Fatalf("bcrypt hashes must keep verifying, got %v %v", ok, err)
+	}
+	if !h.NeedsRehash(string(legacy)) {
+		t.Fatal("bcrypt hash must be upgraded to argon2id")
+	}
+
+	b, _ := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.AlgBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	if !b.NeedsRehash(string(legacy)) {
+		t.Fatal("bcrypt hash with a lower cost must be rehashed")
+	}
+}
+
+func TestStrongerArgon2idParamsNeedRehash(t *testing.T) {
+	weak, _ := passwordhash.New(passwordhash.Config{Argon2: testArgon2})
+	hash, _ := weak.Hash("Valid1@pass")
+
+	stronger := testArgon2
+	stronger.Iterations = 2
+	h, _ := passwordhash.New(passwordhash.Config{Argon2: stronger})
+	if !h.NeedsRehash(hash) {
+		t.Fatal("hash made with fewer iterations must be rehashed")
+	}
+	if ok, err := h.Verify(hash, "Valid1@pass"); err != nil || !ok {
+		t.Fatalf("older parameters must keep verifying, got %v %v", ok, err)
+	}
+}
+
+func TestInvalidConfigAndHash(t *testing.T) {
+	if _, err := passwordhash.New(passwordhash.Config{Algorithm: "md5"}); err == nil {
+		t.Fatal("expected unsupported algorithm error")
+	}
+	h, _ := passwordhash.New(passwordhash.Config{Argon2: testArgon2})
+	if _, err := h.Verify("$argon2id$v=19$broken", "x"); err == nil {
+		t.Fatal("expected invalid hash error")
+	}
+}
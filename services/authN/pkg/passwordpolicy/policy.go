@@ -0,0 +1,111 @@
+// Package passwordpolicy holds the password rules shared by the authN and account services,
+// so a password accepted when an account is created is also accepted when it is changed.
+package passwordpolicy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultMinLength   = 8
+	DefaultMaxLength   = 64
+	DefaultHistorySize = 5
+)
+
+var (
+	ErrEmpty          = errors.New("password is required")
+	ErrTooShort       = fmt.Errorf("password must be at least %d characters long", DefaultMinLength)
+	ErrTooLong        = fmt.Errorf("password must not exceed %d characters", DefaultMaxLength)
+	ErrMissingUpper   = errors.New("password must contain at least one uppercase letter")
+	ErrMissingLower   = errors.New("password must contain at least one lowercase letter")
+	ErrMissingDigit   = errors.New("password must contain at least one digit")
+	ErrMissingSpecial = errors.New("password must contain at least one special character")
+	ErrContainsSpace  = errors.New("password must not contain spaces")
+	ErrReused         = errors.New("password was used recently, choose a different one")
+)
+
+// Policy describes what a password has to look like
+type Policy struct {
+	MinLength      int  `yaml:"minLength"`
+	MaxLength      int  `yaml:"maxLength"`
+	RequireUpper   bool `yaml:"requireUpper"`
+	RequireLower   bool `yaml:"requireLower"`
+	RequireDigit   bool `yaml:"requireDigit"`
+	RequireSpecial bool `yaml:"requireSpecial"`
+	AllowSpaces    bool `yaml:"allowSpaces"`
+	HistorySize    int  `yaml:"historySize"` // previous passwords that can't be used again, 0 disables the check
+}
+
+// Default is the policy both services enforce unless configured otherwise
+func Default() Policy {
+	return Policy{
+		MinLength:      DefaultMinLength,
+		MaxLength:      DefaultMaxLength,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+		HistorySize:    DefaultHistorySize,
+	}
+}
+
+// lengthError reports the configured limit while still matching ErrTooShort / ErrTooLong with errors.Is
+type lengthError struct {
+	rule error
+	msg  string
+}
+
+func (e *lengthError) Error() string { return e.msg }
+func (e *lengthError) Unwrap() error { return e.rule }
+
+// Validate checks the password against every rule and returns the first one it breaks
+func (p Policy) Validate(password string) error {
+	password = strings.TrimSpace(password)
+	if password == "" {
+		return ErrEmpty
+	}
+
+	length := len([]rune(password))
+	if p.MinLength > 0 && length < p.MinLength {
+		return &lengthError{rule: ErrTooShort, msg: fmt.Sprintf("password must be at least %d characters long", p.MinLength)}
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return &lengthError{rule: ErrTooLong, msg: fmt.Sprintf("password must not exceed %d characters", p.MaxLength)}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial, hasSpace bool
+	for _, r := range password {
+		switch {
+		case unicode.IsSpace(r):
+			hasSpace = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSpecial = true
+		}
+	}
+
+	if hasSpace && !p.AllowSpaces {
+		return ErrContainsSpace
+	}
+	if p.RequireUpper && !hasUpper {
+		return ErrMissingUpper
+	}
+	if p.RequireLower && !hasLower {
+		return ErrMissingLower
+	}
+	if p.RequireDigit && !hasDigit {
+		return ErrMissingDigit
+	}
+	if p.RequireSpecial && !hasSpecial {
+		return ErrMissingSpecial
+	}
+	return nil
+}
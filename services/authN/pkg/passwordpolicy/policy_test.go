@@ -0,0 +1,43 @@
+package passwordpolicy_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ashish19912009/zrms/services/authN/pkg/passwordpolicy"
+)
+
+func TestValidate(t *testing.T) {
+	policy := passwordpolicy.Default()
+	tests := []struct {
+		password string
+		want     error
+	}{
+		{"Valid1@pass", nil},
+		{"", passwordpolicy.ErrEmpty},
+		{"Sh0rt@", passwordpolicy.ErrTooShort},
+		{"nouppercase1@", passwordpolicy.ErrMissingUpper},
+		{"NOLOWERCASE1@", passwordpolicy.ErrMissingLower},
+		{"NoDigitHere@", passwordpolicy.ErrMissingDigit},
+		{"NoSpecial123", passwordpolicy.ErrMissingSpecial},
+		{"Has Space1@", passwordpolicy.ErrContainsSpace},
+	}
+	for _, tt := range tests {
+		if err := policy.Validate(tt.password); !errors.Is(err, tt.want) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.password, err, tt.want)
+		}
+	}
+}
+
+func TestValidateConfiguredLength(t *testing.T) {
+	policy := passwordpolicy.Default()
+	policy.MinLength = 12
+
+	err := policy.Validate("Valid1@pass")
+	if !errors.Is(err, passwordpolicy.ErrTooShort) {
+		t.Fatalf("expected ErrTooShort, got %v", err)
+	}
+	if err.Error() != "password must be at least 12 characters long" {
+		t.Fatalf("unexpected message: %s", err)
+	}
+}
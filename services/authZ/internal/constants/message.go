@@ -64,6 +64,7 @@ const (
 	ErrUnsupportedDatabase    = "unsupported database"
 	ErrKeyNotFound            = "key not found"
 	ErrInvalidConfig          = "invalid config"
+	ErrKeysNotSupported       = "key pattern matching not supported"
 	// Config error handling messages
 	ConfigOverride          = "overriding config type with environment variable: %s"
 	FailedToParse           = "failed to parse YAML config"
@@ -89,19 +90,36 @@ const (
 	DragonflyType = "dragonfly"
 	BadgerType    = "badger"
 	LightningType = "lightning"
+	TieredType    = "tiered"
+	EvictionNone  = "none"
+	EvictionLRU   = "lru"
+	EvictionLFU   = "lfu"
+	EvictionTTL   = "ttl"
 
-	InvalidRedisConfig     = "invalid redis config"
-	InvalidMemcachedConfig = "invalid memcached config"
-	InvalidDragonflyConfig = "invalid dragonfly config"
-	InvalidBadgerConfig    = "invalid badger config"
-	CapacityReached        = "cache capacity reached"
-	FailedToStoreCache     = "failed to store in cache: %w"
-	VerficationFailed      = "cache verification failed: %w"
-	FailedToRetrieve       = "failed to retrieve cached value: %w"
-	InvalidCacheValueType  = "invalid cache value type: %T"
-	DoesnotMatch           = "stored data doesn't match original"
-	FailedToUnmarshal      = "failed to unmarshal %w"
-	FailedToRead           = "failed to read config file: %w"
+	InvalidRedisConfig         = "invalid redis config"
+	InvalidMemcachedConfig     = "invalid memcached config"
+	InvalidDragonflyConfig     = "invalid dragonfly config"
+	InvalidBadgerConfig        = "invalid badger config"
+	CapacityReached            = "cache capacity reached"
+	ValueExceedsMaxBytes       = "value larger than lightning max_bytes"
+	UnsupportedEvictionPolicy  = "unsupported lightning eviction policy, expected none, lru, lfu or ttl"
+	SnapshotCorrupt            = "lightning snapshot is corrupt"
+	SnapshotVersionUnsupported = "unsupported lightning snapshot version"
+	SnapshotFailed             = "failed to write lightning snapshot"
+	SnapshotLoadFailed         = "failed to load lightning snapshot, starting empty"
+	SnapshotWritten            = "lightning snapshot written"
+	SnapshotLoaded             = "lightning snapshot loaded"
+	InvalidTieredConfig        = "invalid tiered config, l2 must name another store type with its section set"
+	TieredL1Unbounded          = "tiered l1 needs max_items or max_bytes, an eviction policy and no snapshot_path"
+	TieredNoInvalidation       = "tiered l2 has no pub/sub, replicas may serve stale l1 entries for up to l1_max_ttl"
+	TieredPublishFailed        = "failed to publish tiered l1 invalidation"
+	FailedToStoreCache         = "failed to store in cache: %w"
+	VerficationFailed          = "cache verification failed: %w"
+	FailedToRetrieve           = "failed to retrieve cached value: %w"
+	InvalidCacheValueType      = "invalid cache value type: %T"
+	DoesnotMatch               = "stored data doesn't match original"
+	FailedToUnmarshal          = "failed to unmarshal %w"
+	FailedToRead               = "failed to read config file: %w"
 
 	// DButils
 	Repository                  = "Repository"
@@ -7,12 +7,21 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/ashish19912009/zrms/services/authZ/pb"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
+// serviceScopes is the scope a service token needs for each RPC, user tokens are checked by the policy instead
+var serviceScopes = map[string]string{
+	pb.AuthZService_CheckAccess_FullMethodName:      "authz:check",
+	pb.AuthZService_BatchCheckAccess_FullMethodName: "authz:check",
+}
+
 type JWTInterceptor struct {
 	jwkSetURL string
 	keySet    jwk.Set
@@ -71,8 +80,35 @@ func (ji *JWTInterceptor) Unary() grpc.UnaryServerInterceptor {
 			return nil, err
 		}
 
+		if tokenType, _ := token.Get("token_type"); tokenType == "service" {
+			if !hasScope(token, serviceScopes[info.FullMethod]) {
+				return nil, status.Error(codes.PermissionDenied, "scope not granted to service token")
+			}
+		}
+
 		// You may add custom claim validations here (e.g., expiration, issuer, roles)
 		ctx = context.WithValue(ctx, "user", token)
 		return handler(ctx, req)
 	}
 }
+
+// hasScope reports whether the scopes claim of a service token contains scope
+func hasScope(token jwt.Token, scope string) bool {
+	if scope == "" {
+		return false
+	}
+	claim, ok := token.Get("scopes")
+	if !ok {
+		return false
+	}
+	scopes, ok := claim.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
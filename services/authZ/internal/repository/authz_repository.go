@@ -12,7 +12,7 @@ import (
 )
 
 type AuthZRepository interface {
-	GetAccountRole(ctx context.Context, franchiseID, accountID string) (string, string, string, error)
+	GetAccountRole(ctx context.Context, franchiseID, accountID string) (string, string, string, int64, error)
 	GetRolePermissions(ctx context.Context, role_id string) (map[string][]string, error)
 	GetDirectPermissions(ctx context.Context, accountID string) (map[string]bool, error)
 }
@@ -30,18 +30,20 @@ func NewAuthZRepository(db *sql.DB) *authZRepo {
 	}
 }
 
-// GetAccountRole fetches the role ID for a given account
-func (r *authZRepo) GetAccountRole(ctx context.Context, franchiseID, accountID string) (string, string, string, error) {
+// GetAccountRole fetches the role ID for a given account, and the version of its permissions
+// that the database bumps on every role or permission change
+func (r *authZRepo) GetAccountRole(ctx context.Context, franchiseID, accountID string) (string, string, string, int64, error) {
 	var method = constants.Methods.GetAccountRole
 	var table = constants.DB.Table_Franchise_Accounts
 	if err := dbutils.CheckDBConn(r.db, method); err != nil {
-		return "", "", "", err
+		return "", "", "", 0, err
 	}
 	// Define columns with alias prefixes
 	columns := []string{
 		"id",
 		"franchise_id",
 		"role_id",
+		"permissions_version",
 	}
 
 	conditions := map[string]any{
@@ -63,22 +65,23 @@ func (r *authZRepo) GetAccountRole(ctx context.Context, franchiseID, accountID s
 	// Use the BuildSelectQuery helper function to build the query
 	query, args, err := dbutils.BuildSelectQuery(method, schema_outlet, table, columns, conditions, opts)
 	if err != nil {
-		return "", "", "", err
+		return "", "", "", 0, err
 	}
 	// Execute query and scan the result into the model
 	var ID sql.NullString
 	var FID sql.NullString
 	var roleID sql.NullString
+	var permissionsVersion sql.NullInt64
 	if err := dbutils.ExecuteAndScanRow(ctx, method, r.db, query, args,
-		&ID, &FID, &roleID); err != nil {
-		return "", "", "", err
+		&ID, &FID, &roleID, &permissionsVersion); err != nil {
+		return "", "", "", 0, err
 	}
 	if roleID.Valid {
-		return ID.String, FID.String, roleID.String, nil
+		return ID.String, FID.String, roleID.String, permissionsVersion.Int64, nil
 	}
-	return "", "", "", nil
+	return "", "", "", 0, nil
 	// query := `
-	// 	SELECT ta.id,ta.franchise_id, ta.role_id
+	// 	SELECT ta.id,ta.franchise_id, ta.role_id, ta.permissions_version
 	// 	FROM outlet.team_accounts ta
 	// 	WHERE ta.franchise_id = $1 AND ta.id = $2
 	// `
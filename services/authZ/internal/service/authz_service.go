@@ -56,8 +56,16 @@ func NewAuthZService(drepo repository.AuthZRepository, policyPath string, cacheR
 	}, nil
 }
 
-func makeCacheKey(accountID, resource, action string) (string, string) {
-	return fmt.Sprintf("account_id:%s:", accountID), fmt.Sprintf("%s:%s:", resource, action)
+// makeCacheKey keys a decision by the permissions version of the account too, decisions made
+// before a role or permission change are never served again and expire with their TTL
+func makeCacheKey(accountID, resource, action string, permissionsVersion int64) (string, string) {
+	return fmt.Sprintf("account_id:%s:", accountID), fmt.Sprintf("%s:%s:%d:", resource, action, permissionsVersion)
+}
+
+// decisionVersion is the version handed out with a decision, it changes with the policy and
+// with the permissions of the account so tokens carrying an older one are checked again
+func decisionVersion(policyVersion string, permissionsVersion int64) string {
+	return fmt.Sprintf("%s+%d", policyVersion, permissionsVersion)
 }
 
 // IsAuthorized checks if an account has permission to perform an action on a resource
@@ -72,7 +80,7 @@ func (s *authZService) IsAuthorized(ctx context.Context, franchiseID, accountID,
 		"layer", layer,
 		"method", method,
 	)
-	accID, frID, roleID, err := s.drepo.GetAccountRole(ctx, franchiseID, accountID)
+	accID, frID, roleID, permissionsVersion, err := s.drepo.GetAccountRole(ctx, franchiseID, accountID)
 	if err != nil {
 		logger.Error(constants.FailedFetchAccount, err, logCtx)
 		return false, "", 0, 0, "", fmt.Errorf(constants.FailedFetchAccount, err)
@@ -87,7 +95,7 @@ func (s *authZService) IsAuthorized(ctx context.Context, franchiseID, accountID,
 	}
 
 	result := &pb.Decision{}
-	var tenantPrefix, resourceActionPostfix = makeCacheKey(accountID, resource, action)
+	var tenantPrefix, resourceActionPostfix = makeCacheKey(accountID, resource, action, permissionsVersion)
 	err = s.cRepo.Get(ctx, tenantPrefix, resourceActionPostfix, result)
 	if err != nil {
 		if err != store.ErrKeyNotFound {
@@ -123,6 +131,7 @@ func (s *authZService) IsAuthorized(ctx context.Context, franchiseID, accountID,
 		logger.Error(constants.EvaluationErr, err, logCtx)
 		return false, "", 0, 0, "", fmt.Errorf(constants.EvaluationErr, err)
 	}
+	policy_version = decisionVersion(policy_version, permissionsVersion)
 	if time.Duration(decisionTTL) > 0 {
 		//fmt.Printf("TTL tenantPrefix:%s, resourceActionPostfix: %s", tenantPrefix, resourceActionPostfix)
 		s.cRepo.StoreWithTTL(ctx, tenantPrefix, resourceActionPostfix, &pb.Decision{
@@ -176,7 +185,7 @@ func (s *authZService) IsAuthorizedBatch(
 	}
 
 	// 1. Verify account and get role
-	accID, frID, roleID, err := s.drepo.GetAccountRole(ctx, franchiseID, accountID)
+	accID, frID, roleID, permissionsVersion, err := s.drepo.GetAccountRole(ctx, franchiseID, accountID)
 	if err != nil {
 		logger.Error(constants.FailedFetchAccount, err, logCtx)
 		return nil, fmt.Errorf(constants.FailedFetchAccount, err)
@@ -189,7 +198,7 @@ func (s *authZService) IsAuthorizedBatch(
 	cacheMisses := make([]int, 0) // Track indices of cache misses
 	for i, rec := range resources {
 		result := &pb.Decision{}
-		tenantPrefix, resourceActionPostfix := makeCacheKey(accountID, rec.Resource, rec.Action)
+		tenantPrefix, resourceActionPostfix := makeCacheKey(accountID, rec.Resource, rec.Action, permissionsVersion)
 		err := s.cRepo.Get(ctx, tenantPrefix, resourceActionPostfix, result)
 
 		if err == nil && result.Allowed {
@@ -244,6 +253,7 @@ func (s *authZService) IsAuthorizedBatch(
 			logger.Error(constants.FailedOPAEval, err, logCtx)
 			return nil, fmt.Errorf(constants.FailedOPAEval, ra.Resource, ra.Action, err)
 		}
+		policyVersion = decisionVersion(policyVersion, permissionsVersion)
 
 		// Create response
 		responses[idx] = &model.CheckBatchAccessResponse{
@@ -257,7 +267,7 @@ func (s *authZService) IsAuthorizedBatch(
 		}
 
 		// Cache the decision
-		tenantPrefix, resourceActionPostfix := makeCacheKey(accountID, ra.Resource, ra.Action)
+		tenantPrefix, resourceActionPostfix := makeCacheKey(accountID, ra.Resource, ra.Action, permissionsVersion)
 		decision := &pb.Decision{
 			Allowed:       allowed,
 			Reason:        reason,
@@ -0,0 +1,59 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ashish19912009/zrms/services/authZ/internal/store"
+	"github.com/ashish19912009/zrms/services/authZ/internal/store/storetest"
+)
+
+func TestConformanceLightningDB(t *testing.T) {
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			return store.NewLightningDB(&store.LightningConfig{InitialCapacity: 16})
+		},
+	})
+}
+
+func TestConformanceRedis(t *testing.T) {
+	var mr *miniredis.Miniredis
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			mr = miniredis.RunT(t)
+			s, err := store.NewRedisStore(&store.RedisConfig{Address: mr.Addr()})
+			if err != nil {
+				t.Fatalf("redis store: %v", err)
+			}
+			return s
+		},
+		Advance: func(d time.Duration) { mr.FastForward(d) },
+	})
+}
+
+func TestConformanceTiered(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the L1 expires by the wall clock, the TTL cases take a few seconds")
+	}
+	var mr *miniredis.Miniredis
+	storetest.Run(t, storetest.Backend{
+		New: func(t *testing.T) store.InMemoryStore {
+			mr = miniredis.RunT(t)
+			l2, err := store.NewRedisStore(&store.RedisConfig{Address: mr.Addr()})
+			if err != nil {
+				t.Fatalf("redis store: %v", err)
+			}
+			s, err := store.NewTieredStore(l2, &store.TieredConfig{L1MaxTTL: time.Minute})
+			if err != nil {
+				t.Fatalf("tiered store: %v", err)
+			}
+			return s
+		},
+		// L1 keeps the wall clock, miniredis only moves when told to
+		Advance: func(d time.Duration) {
+			time.Sleep(d)
+			mr.FastForward(d)
+		},
+	})
+}
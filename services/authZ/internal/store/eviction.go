@@ -0,0 +1,227 @@
+package store
+
+import (
+	"container/heap"
+	"container/list"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authZ/internal/constants"
+)
+
+// evictionPolicy picks the key LightningDB drops when max_items or max_bytes is reached.
+// All methods are called with the store lock held.
+type evictionPolicy interface {
+	add(key string, expiration time.Time) // key inserted or overwritten
+	access(key string)                    // key read
+	remove(key string)
+	victim() (string, bool)
+}
+
+func newEvictionPolicy(name string) (evictionPolicy, bool) {
+	switch name {
+	case "", constants.EvictionNone:
+		return nil, true
+	case constants.EvictionLRU:
+		return newLRUPolicy(), true
+	case constants.EvictionLFU:
+		return newLFUPolicy(), true
+	case constants.EvictionTTL:
+		return newTTLPolicy(), true
+	default:
+		return nil, false
+	}
+}
+
+// lruPolicy evicts the least recently used key, add, access and victim are O(1)
+type lruPolicy struct {
+	order *list.List // front is the most recently used
+	keys  map[string]*list.Element
+}
+
+func newLRUPolicy() *lruPolicy {
+	return &lruPolicy{order: list.New(), keys: make(map[string]*list.Element)}
+}
+
+func (p *lruPolicy) add(key string, _ time.Time) {
+	if el, ok := p.keys[key]; ok {
+		p.order.MoveToFront(el)
+		return
+	}
+	p.keys[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy) access(key string) {
+	if el, ok := p.keys[key]; ok {
+		p.order.MoveToFront(el)
+	}
+}
+
+func (p *lruPolicy) remove(key string) {
+	if el, ok := p.keys[key]; ok {
+		p.order.Remove(el)
+		delete(p.keys, key)
+	}
+}
+
+func (p *lruPolicy) victim() (string, bool) {
+	el := p.order.Back()
+	if el == nil {
+		return "", false
+	}
+	return el.Value.(string), true
+}
+
+// lfuPolicy evicts the least frequently used key, ties go to the least recently used one.
+// Keys sit in one list per use count and the counts are kept in ascending order, so every
+// operation is O(1).
+type lfuPolicy struct {
+	freqs *list.List // of *lfuBucket, ascending count
+	keys  map[string]*lfuEntry
+}
+
+type lfuBucket struct {
+	count uint64
+	keys  *list.List // front is the most recently used
+}
+
+type lfuEntry struct {
+	bucket *list.Element
+	el     *list.Element
+}
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{freqs: list.New(), keys: make(map[string]*lfuEntry)}
+}
+
+func (p *lfuPolicy) add(key string, _ time.Time) {
+	if _, ok := p.keys[key]; ok {
+		p.access(key)
+		return
+	}
+	front := p.freqs.Front()
+	if front == nil || front.Value.(*lfuBucket).count != 1 {
+		front = p.freqs.PushFront(&lfuBucket{count: 1, keys: list.New()})
+	}
+	p.keys[key] = &lfuEntry{bucket: front, el: front.Value.(*lfuBucket).keys.PushFront(key)}
+}
+
+func (p *lfuPolicy) access(key string) {
+	entry, ok := p.keys[key]
+	if !ok {
+		return
+	}
+	current := entry.bucket.Value.(*lfuBucket)
+	next := entry.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).count != current.count+1 {
+		next = p.freqs.InsertAfter(&lfuBucket{count: current.count + 1, keys: list.New()}, entry.bucket)
+	}
+	current.keys.Remove(entry.el)
+	if current.keys.Len() == 0 {
+		p.freqs.Remove(entry.bucket)
+	}
+	entry.bucket = next
+	entry.el = next.Value.(*lfuBucket).keys.PushFront(key)
+}
+
+func (p *lfuPolicy) remove(key string) {
+	entry, ok := p.keys[key]
+	if !ok {
+		return
+	}
+	bucket := entry.bucket.Value.(*lfuBucket)
+	bucket.keys.Remove(entry.el)
+	if bucket.keys.Len() == 0 {
+		p.freqs.Remove(entry.bucket)
+	}
+	delete(p.keys, key)
+}
+
+func (p *lfuPolicy) victim() (string, bool) {
+	front := p.freqs.Front()
+	if front == nil {
+		return "", false
+	}
+	return front.Value.(*lfuBucket).keys.Back().Value.(string), true
+}
+
+// ttlPolicy evicts the key that expires first, keys without a TTL only once no other key is left.
+// Expirations are kept in a min-heap, so unlike lru and lfu it is O(log n).
+type ttlPolicy struct {
+	heap ttlHeap
+	keys map[string]*ttlEntry
+	seq  uint64
+}
+
+type ttlEntry struct {
+	key        string
+	expiration time.Time
+	seq        uint64 // insertion order, breaks ties and orders keys without a TTL
+	index      int
+}
+
+func newTTLPolicy() *ttlPolicy {
+	return &ttlPolicy{keys: make(map[string]*ttlEntry)}
+}
+
+func (p *ttlPolicy) add(key string, expiration time.Time) {
+	p.seq++
+	if entry, ok := p.keys[key]; ok {
+		entry.expiration, entry.seq = expiration, p.seq
+		heap.Fix(&p.heap, entry.index)
+		return
+	}
+	entry := &ttlEntry{key: key, expiration: expiration, seq: p.seq}
+	p.keys[key] = entry
+	heap.Push(&p.heap, entry)
+}
+
+func (p *ttlPolicy) access(string) {}
+
+func (p *ttlPolicy) remove(key string) {
+	if entry, ok := p.keys[key]; ok {
+		heap.Remove(&p.heap, entry.index)
+		delete(p.keys, key)
+	}
+}
+
+func (p *ttlPolicy) victim() (string, bool) {
+	if len(p.heap) == 0 {
+		return "", false
+	}
+	return p.heap[0].key, true
+}
+
+type ttlHeap []*ttlEntry
+
+func (h ttlHeap) Len() int { return len(h) }
+
+func (h ttlHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if a.expiration.IsZero() != b.expiration.IsZero() {
+		return b.expiration.IsZero()
+	}
+	if !a.expiration.Equal(b.expiration) {
+		return a.expiration.Before(b.expiration)
+	}
+	return a.seq < b.seq
+}
+
+func (h ttlHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *ttlHeap) Push(x interface{}) {
+	entry := x.(*ttlEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *ttlHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
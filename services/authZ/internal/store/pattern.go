@@ -0,0 +1,102 @@
+package store
+
+// matchPattern reports whether key matches a Redis glob pattern, the syntax KEYS accepts:
+// * any run of characters, ? a single character, [abc], [^abc] and [a-z] classes and \ escapes.
+// Backends that list keys themselves use it so Keys behaves the same on all of them.
+func matchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			matched, rest := matchClass(pattern[1:], key[0])
+			if !matched {
+				return false
+			}
+			key = key[1:]
+			pattern = rest
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(key) == 0
+}
+
+// matchClass matches c against the class that starts after '[' and returns the pattern after ']'
+func matchClass(class string, c byte) (bool, string) {
+	negate := false
+	if len(class) > 0 && (class[0] == '^' || class[0] == '!') {
+		negate = true
+		class = class[1:]
+	}
+	matched := false
+	for len(class) > 0 && class[0] != ']' {
+		lo := class[0]
+		if lo == '\\' && len(class) > 1 {
+			class = class[1:]
+			lo = class[0]
+		}
+		hi := lo
+		if len(class) > 2 && class[1] == '-' && class[2] != ']' {
+			hi = class[2]
+			class = class[2:]
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+		class = class[1:]
+	}
+	if len(class) > 0 {
+		class = class[1:] // the closing ']'
+	}
+	return matched != negate, class
+}
+
+// literalPrefix is the part of the pattern before the first wildcard, used to narrow prefix scans
+func literalPrefix(pattern string) string {
+	prefix := make([]byte, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return string(prefix)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		prefix = append(prefix, pattern[i])
+	}
+	return string(prefix)
+}
@@ -53,11 +53,11 @@ func (p *ProtoStore) GetProto(key string, out proto.Message) error {
 	if err != nil {
 		return err
 	}
-	compressed, ok := raw.([]byte)
+	compressed, ok := raw.(string)
 	if !ok {
 		return errors.New("invalid cache value type")
 	}
-	decompressed, err := p.decoder.DecodeAll(compressed, nil)
+	decompressed, err := p.decoder.DecodeAll([]byte(compressed), nil)
 	if err != nil {
 		return fmt.Errorf("zstd decompression failed: %w", err)
 	}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore implements the InMemoryStore interface using Redis
+type RedisStore struct {
+	client *redis.Client
+	ctx    context.Context
+	ttl    time.Duration
+}
+
+// NewRedisStore initializes a new Redis store
+func NewRedisStore(config *RedisConfig) (*RedisStore, error) {
+	if config == nil {
+		return nil, errors.New("redis config cannot be nil")
+	}
+
+	opts := &redis.Options{
+		Addr:         config.Address,
+		Password:     config.Password,
+		DB:           config.DB,
+		PoolSize:     config.PoolSize,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+	}
+
+	client := redis.NewClient(opts)
+	ctx := context.Background()
+
+	if _, err := client.Ping(ctx).Result(); err != nil {
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+
+	ttl := config.TTL
+	if ttl == 0 {
+		ttl = time.Hour * 48 // Default TTL
+	}
+
+	return &RedisStore{
+		client: client,
+		ctx:    ctx,
+		ttl:    ttl,
+	}, nil
+}
+
+// Set stores a key-value pair in Redis
+func (r *RedisStore) Set(key string, value interface{}) error {
+	return r.client.Set(r.ctx, key, value, time.Hour).Err()
+}
+
+// SetWithTTL stores a key-value pair that expires after ttl, a ttl <= 0 never expires
+func (r *RedisStore) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(r.ctx, r.ttl)
+	defer cancel()
+	if ttl < 0 {
+		ttl = 0 // go-redis treats -1 as KEEPTTL
+	}
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Get retrieves a value from Redis
+func (r *RedisStore) Get(key string) (interface{}, error) {
+	value, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, errors.New("error in redis operation")
+	}
+	return value, nil
+}
+
+// getWithTTL reads the value and its remaining TTL in one round trip, a ttl of 0 never expires
+func (r *RedisStore) getWithTTL(key string) (interface{}, time.Duration, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := r.client.Pipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(r.ctx, key)
+		pttl = pipe.PTTL(r.ctx, key)
+		return nil
+	})
+	if get.Err() == redis.Nil {
+		return nil, 0, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, 0, errors.New("error in redis operation")
+	}
+	ttl := pttl.Val()
+	if ttl < 0 {
+		ttl = 0 // -1 has no expiration, -2 expired between the two commands and is caught by the next read
+	}
+	return get.Val(), ttl, nil
+}
+
+// Delete removes a key from Redis
+func (r *RedisStore) Delete(key string) error {
+	deleted, err := r.client.Del(r.ctx, key).Result()
+	if err != nil {
+		return errors.New("error in redis operation")
+	}
+	if deleted == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+func (r *RedisStore) Exists(key string) (bool, error) {
+	exists, err := r.client.Exists(r.ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis exists check failed: %w", err)
+	}
+	return exists == 1, nil
+}
+
+func (r *RedisStore) Keys(pattern string) ([]string, error) {
+	keys, err := r.client.Keys(r.ctx, pattern).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis keys operation failed: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *RedisStore) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisStore) FlushAll() error {
+	if err := r.client.FlushAll(r.ctx).Err(); err != nil {
+		return fmt.Errorf("failed to flush redis store: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisStore) publish(channel, message string) error {
+	return r.client.Publish(r.ctx, channel, message).Err()
+}
+
+// subscribe calls handle for every message on channel until the returned Closer is closed.
+// go-redis reconnects on its own, messages published while disconnected are lost.
+func (r *RedisStore) subscribe(channel string, handle func(message string)) (io.Closer, error) {
+	sub := r.client.Subscribe(r.ctx, channel)
+	if _, err := sub.Receive(r.ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("redis subscribe failed: %w", err)
+	}
+	go func() {
+		for msg := range sub.Channel() {
+			handle(msg.Payload)
+		}
+	}()
+	return sub, nil
+}
+
+// Interface compliance check
+var _ InMemoryStore = (*RedisStore)(nil)
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSnapshotDB(path string) *LightningDB {
+	return NewLightningDB(&LightningConfig{InitialCapacity: 16, SnapshotPath: path})
+}
+
+func TestLightningDBSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightning.snapshot")
+	db := newSnapshotDB(path)
+	require.NoError(t, db.Set("session:1", `{"id":"1"}`))
+	require.NoError(t, db.Set("compressed", []byte{0, 1, 0xff}))
+	require.NoError(t, db.SetWithTTL("refresh:1", "token", time.Hour))
+	require.NoError(t, db.Set("unsupported", 42))
+	require.NoError(t, db.Close())
+
+	restored := newSnapshotDB(path)
+	defer restored.Close()
+
+	val, err := restored.Get("session:1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"1"}`, val)
+	val, err = restored.Get("compressed")
+	assert.NoError(t, err)
+	assert.Equal(t, string([]byte{0, 1, 0xff}), val)
+
+	// the expiration is stored as an absolute time, the downtime counts against the TTL
+	want := db.shard("refresh:1").store["refresh:1"].expiration
+	got := restored.shard("refresh:1").store["refresh:1"].expiration
+	assert.True(t, want.Equal(got), "expiration %v, want %v", got, want)
+
+	_, err = restored.Get("unsupported")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int64(3), restored.items.Load())
+	assert.Equal(t, db.bytes.Load()-itemSize("unsupported", 42), restored.bytes.Load())
+}
+
+func TestLightningDBSnapshotSkipsExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightning.snapshot")
+	db := newSnapshotDB(path)
+	require.NoError(t, db.SetWithTTL("short", "v", 50*time.Millisecond))
+	require.NoError(t, db.SetWithTTL("long", "v", time.Hour))
+	require.NoError(t, db.Close())
+
+	time.Sleep(100 * time.Millisecond)
+
+	restored := newSnapshotDB(path)
+	defer restored.Close()
+	exists, _ := restored.Exists("short")
+	assert.False(t, exists)
+	exists, _ = restored.Exists("long")
+	assert.True(t, exists)
+	assert.Equal(t, int64(1), restored.items.Load())
+}
+
+func TestLightningDBSnapshotFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lightning.snapshot")
+	db := newSnapshotDB(path)
+	require.NoError(t, db.Set("key", "value"))
+	require.NoError(t, db.Snapshot())
+	require.NoError(t, db.Snapshot())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are renamed or removed")
+}
+
+func TestLightningDBSnapshotDamaged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightning.snapshot")
+	db := newSnapshotDB(path)
+	require.NoError(t, db.Set("key", "value"))
+	require.NoError(t, db.Close())
+	valid, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	flipped := append([]byte(nil), valid...)
+	flipped[len(snapshotMagic)+3] ^= 0xff
+	newVersion := append([]byte(nil), valid...)
+	newVersion[len(snapshotMagic)] = snapshotVersion + 1
+
+	cases := map[string][]byte{
+		"flipped byte": flipped,
+		"truncated":    valid[:len(valid)-3],
+		"empty":        {},
+		"new version":  newVersion,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+			restored := newSnapshotDB(path)
+			assert.Equal(t, int64(0), restored.items.Load())
+		})
+	}
+
+	assert.ErrorIs(t, newSnapshotDB("").restoreSnapshot(newVersion), ErrSnapshotVersion)
+	assert.ErrorIs(t, newSnapshotDB("").restoreSnapshot(flipped), ErrSnapshotCorrupt)
+}
+
+func TestLightningDBSnapshotInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightning.snapshot")
+	db := NewLightningDB(&LightningConfig{SnapshotPath: path, SnapshotInterval: 20 * time.Millisecond})
+	defer db.Close()
+	require.NoError(t, db.Set("key", "value"))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLightningDBSnapshotDisabled(t *testing.T) {
+	db := NewLightningDB(&LightningConfig{InitialCapacity: 16})
+	require.NoError(t, db.Set("key", "value"))
+	assert.NoError(t, db.Snapshot())
+	assert.NoError(t, db.Close())
+}
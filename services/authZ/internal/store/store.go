@@ -16,9 +16,15 @@ var (
 	ErrUnsupportedDatabase = errors.New(constants.ErrUnsupportedDatabase)
 	ErrKeyNotFound         = errors.New(constants.ErrKeyNotFound)
 	ErrInvalidConfig       = errors.New(constants.ErrInvalidConfig)
+	ErrKeysNotSupported    = errors.New(constants.ErrKeysNotSupported)
 )
 
-// InMemoryStore defines the interface for all in-memory databases
+// InMemoryStore defines the interface for all in-memory databases.
+// Every backend has to pass the conformance suite in storetest, in short:
+//   - Get, and Delete of a missing or expired key return ErrKeyNotFound
+//   - Get returns the stored bytes as a string, whether they were set as a string or a []byte
+//   - SetWithTTL with a ttl <= 0 stores the value without expiration
+//   - Keys takes a Redis glob pattern (*, ?, [abc], [a-z], \x), backends that can't list keys return ErrKeysNotSupported
 type InMemoryStore interface {
 	Set(key string, value interface{}) error
 	SetWithTTL(key string, value interface{}, ttl time.Duration) error
@@ -38,13 +44,29 @@ type Config struct {
 	Dragonfly *DragonflyConfig `yaml:"dragonfly,omitempty"`
 	Badger    *BadgerConfig    `yaml:"badger,omitempty"`
 	Lightning *LightningConfig `yaml:"lightning,omitempty"`
+	Tiered    *TieredConfig    `yaml:"tiered,omitempty"`
+}
+
+// TieredConfig layers a LightningDB L1 in front of the store named by L2, configured in its own section
+type TieredConfig struct {
+	L2                  string           `yaml:"l2"` // type of the shared store, e.g. redis
+	L1                  *LightningConfig `yaml:"l1"`
+	L1MaxTTL            time.Duration    `yaml:"l1_max_ttl"`           // longest an L1 entry lives, bounds staleness, 0 = 1m
+	InvalidationChannel string           `yaml:"invalidation_channel"` // pub/sub channel shared by the replicas, only used when L2 supports it
 }
 
 type LightningConfig struct {
 	InitialCapacity int           `yaml:"initial_capacity"`
-	MaxItems        int           `yaml:"max_items"` // 0 = unlimited
+	Shards          int           `yaml:"shards"`          // rounded up to a power of two, 0 = 32
+	MaxItems        int           `yaml:"max_items"`       // 0 = unlimited
+	MaxBytes        int64         `yaml:"max_bytes"`       // estimated size of keys and values, 0 = unlimited
+	EvictionPolicy  string        `yaml:"eviction_policy"` // none (default), lru, lfu or ttl
 	CleanupInterval time.Duration `yaml:"cleanup_interval"`
-	FallbackStore   InMemoryStore `yaml:"-"` // For runtime fallback
+	// SnapshotPath is loaded at startup and written on Close, empty disables snapshots.
+	// SnapshotInterval adds periodic snapshots so a crash loses at most one interval, 0 = only on Close.
+	SnapshotPath     string        `yaml:"snapshot_path"`
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"`
+	FallbackStore    InMemoryStore `yaml:"-"` // For runtime fallback, only used without an eviction policy
 }
 
 type RedisConfig struct {
@@ -123,6 +145,10 @@ func (c *Config) Validate() error {
 		if c.Lightning == nil {
 			c.Lightning = &LightningConfig{InitialCapacity: 1000, MaxItems: 0}
 		}
+		if _, ok := newEvictionPolicy(c.Lightning.EvictionPolicy); !ok {
+			logger.Error(constants.UnsupportedEvictionPolicy, nil, map[string]interface{}{"eviction_policy": c.Lightning.EvictionPolicy})
+			return ErrInvalidConfig
+		}
 	case constants.RedisType:
 		if c.Redis == nil {
 			logger.Error(constants.InvalidRedisConfig, nil, map[string]interface{}{constants.TypeKey: c.Type})
@@ -143,10 +169,31 @@ func (c *Config) Validate() error {
 			logger.Error(constants.InvalidBadgerConfig, nil, map[string]interface{}{constants.TypeKey: c.Type})
 			return ErrInvalidConfig
 		}
+	case constants.TieredType:
+		if c.Tiered == nil || c.Tiered.L2 == "" || c.Tiered.L2 == constants.TieredType || c.Tiered.L2 == constants.LightningType {
+			logger.Error(constants.InvalidTieredConfig, nil, map[string]interface{}{constants.TypeKey: c.Type})
+			return ErrInvalidConfig
+		}
+		if l1 := c.Tiered.L1; l1 != nil {
+			policy, ok := newEvictionPolicy(l1.EvictionPolicy)
+			if !ok || policy == nil || (l1.MaxItems <= 0 && l1.MaxBytes <= 0) || l1.SnapshotPath != "" {
+				logger.Error(constants.TieredL1Unbounded, nil, map[string]interface{}{"eviction_policy": l1.EvictionPolicy})
+				return ErrInvalidConfig
+			}
+		}
+		return c.l2Config().Validate()
 	}
 	return nil
 }
 
+// l2Config is the config of the store behind the tiered L1
+func (c *Config) l2Config() *Config {
+	l2 := *c
+	l2.Type = c.Tiered.L2
+	l2.Tiered = nil
+	return &l2
+}
+
 // StoreManager manages the selected in-memory store
 type StoreManager struct {
 	store   InMemoryStore
@@ -187,6 +234,22 @@ func NewStoreFromConfig(config *Config) (InMemoryStore, error) {
 	switch storeType {
 	case constants.LightningType, "":
 		return NewLightningDB(config.Lightning), nil
+	case constants.RedisType:
+		return NewRedisStore(config.Redis)
+	case constants.TieredType:
+		if config.Tiered == nil {
+			return nil, ErrInvalidConfig
+		}
+		l2, err := NewStoreFromConfig(config.l2Config())
+		if err != nil {
+			return nil, err
+		}
+		tiered, err := NewTieredStore(l2, config.Tiered)
+		if err != nil {
+			l2.Close()
+			return nil, err
+		}
+		return tiered, nil
 	default:
 		logger.Error(constants.UnsupportedDatabaseType, nil, map[string]interface{}{constants.TypeKey: config.Type})
 		return nil, ErrUnsupportedDatabase
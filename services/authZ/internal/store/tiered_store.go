@@ -0,0 +1,273 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"hash/maphash"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashish19912009/zrms/services/authZ/internal/constants"
+	"github.com/ashish19912009/zrms/services/authZ/internal/logger"
+)
+
+const (
+	defaultL1MaxTTL            = time.Minute
+	defaultInvalidationChannel = "authz:l1:invalidate"
+
+	// tieredSlots guard the L1 fills, a write to one key only holds back fills of the keys sharing its slot
+	tieredSlots = 256
+
+	invalidateKey = 'd'
+	invalidateAll = 'f'
+)
+
+// invalidationBus is implemented by L2 stores that can tell the other replicas about writes
+type invalidationBus interface {
+	publish(channel, message string) error
+	subscribe(channel string, handle func(message string)) (io.Closer, error)
+}
+
+// ttlReader is implemented by L2 stores that return the remaining TTL along with the value
+type ttlReader interface {
+	getWithTTL(key string) (interface{}, time.Duration, error)
+}
+
+// TieredStore serves reads from a bounded LightningDB (L1) in front of a shared store (L2).
+// Reads go to L2 on an L1 miss and fill L1, writes go to L2 first and then to L1. L1 keeps an
+// entry for at most l1_max_ttl, which is also how stale a replica can get when it misses an
+// invalidation, or always when L2 has no pub/sub. Writes publish the key on the invalidation
+// channel and every other replica drops it from its L1.
+type TieredStore struct {
+	l1      *LightningDB
+	l2      InMemoryStore
+	maxTTL  time.Duration
+	id      string // prefixes our invalidations so we skip them when they come back
+	channel string
+	bus     invalidationBus // nil when L2 has no pub/sub
+	sub     io.Closer
+	seed    maphash.Seed
+	slots   [tieredSlots]tieredSlot
+}
+
+// tieredSlot counts the invalidations of its keys. A fill only lands in L1 when no write or
+// invalidation happened since L2 was read, otherwise it could bring back a value just replaced.
+type tieredSlot struct {
+	mu         sync.Mutex
+	generation uint64
+}
+
+// NewTieredStore puts an L1 built from config.L1 in front of l2 and subscribes to the
+// invalidation channel when l2 supports pub/sub. The store owns l2 and closes it on Close.
+func NewTieredStore(l2 InMemoryStore, config *TieredConfig) (*TieredStore, error) {
+	if config == nil {
+		config = &TieredConfig{}
+	}
+	l1Config := config.L1
+	if l1Config == nil {
+		l1Config = &LightningConfig{InitialCapacity: 1000, MaxItems: 10000, EvictionPolicy: constants.EvictionLRU, CleanupInterval: time.Minute}
+	}
+	maxTTL := config.L1MaxTTL
+	if maxTTL <= 0 {
+		maxTTL = defaultL1MaxTTL
+	}
+	channel := config.InvalidationChannel
+	if channel == "" {
+		channel = defaultInvalidationChannel
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	t := &TieredStore{
+		l1:      NewLightningDB(l1Config),
+		l2:      l2,
+		maxTTL:  maxTTL,
+		id:      hex.EncodeToString(id),
+		channel: channel,
+		seed:    maphash.MakeSeed(),
+	}
+	bus, ok := l2.(invalidationBus)
+	if !ok {
+		logger.Info(constants.TieredNoInvalidation, map[string]interface{}{"l1_max_ttl": maxTTL.String()})
+		return t, nil
+	}
+	sub, err := bus.subscribe(channel, t.handleInvalidation)
+	if err != nil {
+		t.l1.Close()
+		return nil, err
+	}
+	t.bus, t.sub = bus, sub
+	return t, nil
+}
+
+// Set stores the value in L2 and L1
+func (t *TieredStore) Set(key string, value interface{}) error {
+	if err := t.l2.Set(key, value); err != nil {
+		t.invalidate(key) // L2 may or may not hold the new value now
+		return err
+	}
+	t.update(key, value, 0)
+	t.publish(invalidateKey, key)
+	return nil
+}
+
+// SetWithTTL stores the value in L2 with ttl and in L1 with ttl capped at l1_max_ttl
+func (t *TieredStore) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	if err := t.l2.SetWithTTL(key, value, ttl); err != nil {
+		t.invalidate(key)
+		return err
+	}
+	t.update(key, value, ttl)
+	t.publish(invalidateKey, key)
+	return nil
+}
+
+// Get serves L1 and reads through to L2 on a miss
+func (t *TieredStore) Get(key string) (interface{}, error) {
+	if value, err := t.l1.Get(key); err == nil {
+		return value, nil
+	}
+
+	slot := t.slot(key)
+	slot.mu.Lock()
+	generation := slot.generation
+	slot.mu.Unlock()
+
+	var value interface{}
+	var ttl time.Duration
+	var err error
+	if r, ok := t.l2.(ttlReader); ok {
+		value, ttl, err = r.getWithTTL(key)
+	} else {
+		value, err = t.l2.Get(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.fill(slot, generation, key, value, ttl)
+	return value, nil
+}
+
+// Delete removes the key from L2 and L1, the error is the one of L2
+func (t *TieredStore) Delete(key string) error {
+	err := t.l2.Delete(key)
+	t.invalidate(key)
+	t.publish(invalidateKey, key)
+	return err
+}
+
+// Exists answers from L1 when it holds the key, L2 otherwise
+func (t *TieredStore) Exists(key string) (bool, error) {
+	if exists, _ := t.l1.Exists(key); exists {
+		return true, nil
+	}
+	return t.l2.Exists(key)
+}
+
+// Keys lists L2, L1 only holds a subset
+func (t *TieredStore) Keys(pattern string) ([]string, error) {
+	return t.l2.Keys(pattern)
+}
+
+// FlushAll empties L2 and the L1 of every replica
+func (t *TieredStore) FlushAll() error {
+	err := t.l2.FlushAll()
+	t.flushL1()
+	t.publish(invalidateAll, "")
+	return err
+}
+
+// Close stops listening for invalidations and closes L1 and L2
+func (t *TieredStore) Close() error {
+	var errs []error
+	if t.sub != nil {
+		errs = append(errs, t.sub.Close())
+	}
+	errs = append(errs, t.l1.Close(), t.l2.Close())
+	return errors.Join(errs...)
+}
+
+func (t *TieredStore) slot(key string) *tieredSlot {
+	return &t.slots[maphash.String(t.seed, key)%tieredSlots]
+}
+
+// fill caches a value read from L2 unless the key was written or invalidated since generation
+func (t *TieredStore) fill(slot *tieredSlot, generation uint64, key string, value interface{}, ttl time.Duration) {
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+
+	if slot.generation == generation {
+		_ = t.l1.SetWithTTL(key, value, t.l1TTL(ttl)) // a full L1 only costs the next read a round trip
+	}
+}
+
+// update replaces the L1 entry after a write to L2
+func (t *TieredStore) update(key string, value interface{}, ttl time.Duration) {
+	slot := t.slot(key)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+
+	slot.generation++
+	if err := t.l1.SetWithTTL(key, value, t.l1TTL(ttl)); err != nil {
+		_ = t.l1.Delete(key) // don't keep serving the previous value
+	}
+}
+
+func (t *TieredStore) invalidate(key string) {
+	slot := t.slot(key)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+
+	slot.generation++
+	_ = t.l1.Delete(key)
+}
+
+func (t *TieredStore) flushL1() {
+	for i := range t.slots {
+		t.slots[i].mu.Lock()
+		t.slots[i].generation++
+	}
+	_ = t.l1.FlushAll()
+	for i := range t.slots {
+		t.slots[i].mu.Unlock()
+	}
+}
+
+func (t *TieredStore) l1TTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > t.maxTTL {
+		return t.maxTTL
+	}
+	return ttl
+}
+
+// publish sends "<id>:<op><key>", a lost message leaves the other replicas stale for at most l1_max_ttl
+func (t *TieredStore) publish(op byte, key string) {
+	if t.bus == nil {
+		return
+	}
+	if err := t.bus.publish(t.channel, t.id+":"+string(op)+key); err != nil {
+		logger.Error(constants.TieredPublishFailed, err, map[string]interface{}{"channel": t.channel, "key": key})
+	}
+}
+
+func (t *TieredStore) handleInvalidation(message string) {
+	id, rest, ok := strings.Cut(message, ":")
+	if !ok || id == t.id || rest == "" {
+		return
+	}
+	switch rest[0] {
+	case invalidateKey:
+		t.invalidate(rest[1:])
+	case invalidateAll:
+		t.flushL1()
+	}
+}
+
+// Interface compliance check
+var _ InMemoryStore = (*TieredStore)(nil)
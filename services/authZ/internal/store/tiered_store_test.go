@@ -0,0 +1,179 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTieredReplica(t *testing.T, mr *miniredis.Miniredis, config *TieredConfig) *TieredStore {
+	t.Helper()
+	l2, err := NewRedisStore(&RedisConfig{Address: mr.Addr()})
+	require.NoError(t, err)
+	s, err := NewTieredStore(l2, config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func l1Value(s *TieredStore, key string) (interface{}, bool) {
+	val, err := s.l1.Get(key)
+	return val, err == nil
+}
+
+func TestTieredStoreReadThrough(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTieredReplica(t, mr, nil)
+	require.NoError(t, mr.Set("decision", "allow"))
+
+	val, err := s.Get("decision")
+	require.NoError(t, err)
+	assert.Equal(t, "allow", val)
+	_, cached := l1Value(s, "decision")
+	assert.True(t, cached)
+
+	// served from L1 without going to Redis
+	mr.Del("decision")
+	val, err = s.Get("decision")
+	require.NoError(t, err)
+	assert.Equal(t, "allow", val)
+
+	_, err = s.Get("missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestTieredStoreL1TTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTieredReplica(t, mr, &TieredConfig{L1MaxTTL: time.Minute})
+
+	l1Expiration := func(key string) time.Duration {
+		it := s.l1.shard(key).store[key]
+		return time.Until(it.expiration)
+	}
+
+	require.NoError(t, s.SetWithTTL("long", "v", time.Hour))
+	assert.InDelta(t, time.Minute, l1Expiration("long"), float64(time.Second))
+	assert.InDelta(t, time.Hour, mr.TTL("long"), float64(time.Second))
+
+	require.NoError(t, s.SetWithTTL("short", "v", 10*time.Second))
+	assert.InDelta(t, 10*time.Second, l1Expiration("short"), float64(time.Second))
+
+	require.NoError(t, s.Set("no-ttl", "v"))
+	assert.InDelta(t, time.Minute, l1Expiration("no-ttl"), float64(time.Second))
+
+	// a read-through fill keeps the remaining TTL of Redis when it is shorter
+	require.NoError(t, mr.Set("filled", "v"))
+	mr.SetTTL("filled", 5*time.Second)
+	_, err := s.Get("filled")
+	require.NoError(t, err)
+	assert.InDelta(t, 5*time.Second, l1Expiration("filled"), float64(time.Second))
+}
+
+func TestTieredStoreInvalidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := newTieredReplica(t, mr, nil)
+	b := newTieredReplica(t, mr, nil)
+
+	require.NoError(t, a.Set("decision", "allow"))
+	val, err := b.Get("decision")
+	require.NoError(t, err)
+	assert.Equal(t, "allow", val)
+
+	require.NoError(t, a.Set("decision", "deny"))
+	assert.Eventually(t, func() bool {
+		val, err := b.Get("decision")
+		return err == nil && val == "deny"
+	}, time.Second, 10*time.Millisecond, "b drops its L1 entry when a writes")
+
+	require.NoError(t, a.Delete("decision"))
+	assert.Eventually(t, func() bool {
+		_, cached := l1Value(b, "decision")
+		return !cached
+	}, time.Second, 10*time.Millisecond)
+	_, err = b.Get("decision")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, a.Set("other", "v"))
+	_, err = b.Get("other")
+	require.NoError(t, err)
+	require.NoError(t, a.FlushAll())
+	assert.Eventually(t, func() bool {
+		_, cached := l1Value(b, "other")
+		return !cached
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTieredStoreStaleFill(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTieredReplica(t, mr, nil)
+
+	// a fill that read L2 before a write must not land in L1 after it
+	slot := s.slot("decision")
+	generation := slot.generation
+	s.invalidate("decision")
+
+	s.fill(slot, generation, "decision", "stale", 0)
+	_, cached := l1Value(s, "decision")
+	assert.False(t, cached)
+
+	s.fill(slot, slot.generation, "decision", "fresh", 0)
+	val, cached := l1Value(s, "decision")
+	assert.True(t, cached)
+	assert.Equal(t, "fresh", val)
+}
+
+func TestTieredStoreL2Failure(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTieredReplica(t, mr, nil)
+	require.NoError(t, s.Set("decision", "allow"))
+
+	mr.SetError("READONLY")
+	assert.Error(t, s.Set("decision", "deny"))
+	mr.SetError("")
+
+	_, cached := l1Value(s, "decision")
+	assert.False(t, cached, "a failed write drops the L1 entry instead of keeping a value L2 may no longer have")
+}
+
+func TestTieredStoreWithoutPubSub(t *testing.T) {
+	s, err := NewTieredStore(NewLightningDB(nil), &TieredConfig{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Nil(t, s.bus)
+	require.NoError(t, s.Set("key", "value"))
+	val, err := s.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", val)
+}
+
+func TestTieredConfigValidate(t *testing.T) {
+	redis := &RedisConfig{Address: "localhost:6379"}
+	cases := map[string]struct {
+		config *Config
+		valid  bool
+	}{
+		"redis l2":     {&Config{Type: "tiered", Redis: redis, Tiered: &TieredConfig{L2: "redis"}}, true},
+		"no section":   {&Config{Type: "tiered", Redis: redis}, false},
+		"no l2":        {&Config{Type: "tiered", Redis: redis, Tiered: &TieredConfig{}}, false},
+		"tiered l2":    {&Config{Type: "tiered", Tiered: &TieredConfig{L2: "tiered"}}, false},
+		"missing l2":   {&Config{Type: "tiered", Tiered: &TieredConfig{L2: "redis"}}, false},
+		"unbounded l1": {&Config{Type: "tiered", Redis: redis, Tiered: &TieredConfig{L2: "redis", L1: &LightningConfig{EvictionPolicy: "lru"}}}, false},
+		"no eviction":  {&Config{Type: "tiered", Redis: redis, Tiered: &TieredConfig{L2: "redis", L1: &LightningConfig{MaxItems: 10}}}, false},
+		"snapshot":     {&Config{Type: "tiered", Redis: redis, Tiered: &TieredConfig{L2: "redis", L1: &LightningConfig{MaxItems: 10, EvictionPolicy: "lru", SnapshotPath: "l1.snapshot"}}}, false},
+		"bounded l1":   {&Config{Type: "tiered", Redis: redis, Tiered: &TieredConfig{L2: "redis", L1: &LightningConfig{MaxBytes: 1 << 20, EvictionPolicy: "lfu"}}}, true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+			}
+		})
+	}
+}